@@ -4,31 +4,88 @@ import (
 	"bookshelf/internal/api"
 	"bookshelf/internal/db"
 	"bookshelf/internal/models"
-	"bufio"
-	"encoding/json"
+	"context"
+	"database/sql"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var addManual bool
+var addAnyEdition bool
+var addForm bookForm
+var addQuery api.SearchQuery
+
 var addCmd = &cobra.Command{
 	Use:   "add [title]",
 	Short: "Search and add a book to your shelf",
-	Long:  `Search for a book by title and add it to your reading list.`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runAdd,
+	Long: `Search for a book by title and add it to your reading list. After picking
+the book, pick the edition you own so its page count, cover, ISBN, publisher
+and format are right; --any-edition skips this.
+
+Narrow an ambiguous title with the same filters as search: --title, --author,
+--subject, --isbn, --year and --lang.
+
+Use --manual for books the metadata providers don't know about. Pass the details as
+flags, or leave out --title/--author to fill them in with your editor.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if addManual || searchFieldsGiven(cmd) {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	RunE: runAdd,
+}
+
+func init() {
+	addProviderFlag(addCmd)
+	addCmd.Flags().BoolVar(&addAnyEdition, "any-edition", false, "Skip the edition picker and use the work's details")
+	addCmd.Flags().BoolVar(&addManual, "manual", false, "Enter the book's details yourself instead of searching")
+	addCmd.Flags().StringVar(&addForm.Title, "title", "", "Title, or with --manual the book's title")
+	addCmd.Flags().StringVar(&addForm.Author, "author", "", "Author, or with --manual the book's author")
+	addCmd.Flags().StringVar(&addForm.ISBN, "isbn", "", "ISBN, or with --manual the book's ISBN")
+	addSearchFieldFlags(addCmd, &addQuery)
+	addCmd.Flags().IntVar(&addForm.Pages, "pages", 0, "Page count (with --manual)")
+	addCmd.Flags().StringVar(&addForm.CoverURL, "cover", "", "Cover image URL (with --manual)")
+	addCmd.Flags().StringVar(&addForm.Description, "description", "", "Description (with --manual)")
+	addCmd.Flags().StringSliceVar(&addForm.Genres, "genres", nil, "Comma-separated genres (with --manual)")
+	addCmd.Flags().StringVar(&addForm.Format, "format", "", "Format: hardcover, paperback, ebook or audiobook (with --manual)")
+	addCmd.Flags().StringVar(&addForm.Duration, "duration", "", "Running time for audiobooks, e.g. 11h25m (with --manual)")
 }
 
 func runAdd(cmd *cobra.Command, args []string) error {
-	query := strings.Join(args, " ")
-	client := api.NewClient()
+	if addManual {
+		for _, name := range []string{"subject", "year", "lang"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s can't be used with --manual", name)
+			}
+		}
+		return runAddManual(args)
+	}
+	for _, name := range []string{"pages", "cover", "description", "genres", "format", "duration"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can only be used with --manual", name)
+		}
+	}
 
-	fmt.Printf("Searching for \"%s\"...\n\n", query)
+	query := addQuery
+	query.Text = strings.Join(args, " ")
+	query.Title, query.Author, query.ISBN = addForm.Title, addForm.Author, addForm.ISBN
+	query.Limit = 5
+	if err := query.Validate(); err != nil {
+		return err
+	}
 
-	docs, err := client.Search(query, 5)
+	client, err := metadataProvider()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Searching for %s...\n\n", query)
+
+	docs, err := client.Search(cmd.Context(), query)
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
@@ -48,9 +105,10 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Print("\nSelect a book (1-5) or 0 to cancel: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
+	input, err := readLine(cmd.Context(), stdin)
+	if err != nil {
+		return err
+	}
 
 	choice, err := strconv.Atoi(input)
 	if err != nil || choice < 0 || choice > len(docs) {
@@ -63,35 +121,176 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	selected := docs[choice-1]
-
-	// Fetch additional details if available
-	var description *string
-	var genres *string
-	if selected.Key != "" {
-		work, err := client.GetWorkDetails(selected.Key)
-		if err == nil {
-			description = work.DescriptionText()
-			// Get top 5 subjects as genres
-			if subjects := work.TopSubjects(5); len(subjects) > 0 {
-				if jsonBytes, err := json.Marshal(subjects); err == nil {
-					jsonStr := string(jsonBytes)
-					genres = &jsonStr
-				}
-			}
+	return addSearchResult(cmd.Context(), client, stdin, docs[choice-1])
+}
+
+// addSearchResult adds the chosen search result to the shelf as want-to-read,
+// with the work's description and genres and, unless --any-edition, the
+// details of the edition picked from reader.
+func addSearchResult(ctx context.Context, client api.MetadataProvider, reader *lineReader, selected api.SearchDoc) error {
+	book, err := insertSearchResult(ctx, client, reader, selected, models.StatusWantToRead)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nAdded \"%s\" by %s (ID: %d)\n", book.Title, book.Author, book.ID)
+	return nil
+}
+
+// insertSearchResult adds the chosen search result like addSearchResult, but
+// on the given shelf and without saying so, returning the book with its ID.
+func insertSearchResult(ctx context.Context, client api.MetadataProvider, reader *lineReader, selected api.SearchDoc, status models.BookStatus) (models.Book, error) {
+	book := api.BookFromSearch(ctx, client, selected)
+
+	if selected.Key != "" && !addAnyEdition {
+		edition, err := pickEdition(ctx, client, reader, selected.Key)
+		if ctx.Err() != nil {
+			return book, ctx.Err()
+		} else if err != nil {
+			fmt.Printf("Couldn't list editions (%v), using the work's details.\n", err)
+		} else if edition != nil {
+			applyEdition(&book, edition)
+		}
+	}
+
+	bookID, err := db.InsertBook(&book)
+	if err != nil {
+		return book, fmt.Errorf("failed to add book: %w", err)
+	}
+	book.ID = bookID
+
+	if err := db.CreateReadingEntry(bookID, status); err != nil {
+		return book, fmt.Errorf("failed to create reading entry: %w", err)
+	}
+	return book, nil
+}
+
+// maxEditionChoices caps the edition picker; popular works have hundreds.
+const maxEditionChoices = 10
+
+// pickEdition lists a work's editions and asks which one you own. Returns nil
+// to keep the work's details: when there are no editions, or you skip.
+func pickEdition(ctx context.Context, client api.MetadataProvider, reader *lineReader, key string) (*api.Edition, error) {
+	editions, err := client.GetEditions(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(editions) {
+	case 0:
+		return nil, nil
+	case 1:
+		fmt.Printf("\nUsing the only edition: %s\n", describeEdition(&editions[0]))
+		return &editions[0], nil
+	}
+
+	shown := editions
+	if len(shown) > maxEditionChoices {
+		shown = shown[:maxEditionChoices]
+	}
+
+	fmt.Printf("\nEditions (%d):\n", len(editions))
+	for i := range shown {
+		fmt.Printf("  %d. %s\n", i+1, describeEdition(&shown[i]))
+	}
+	if len(editions) > len(shown) {
+		fmt.Printf("  ...and %d more\n", len(editions)-len(shown))
+	}
+
+	fmt.Printf("\nSelect your edition (1-%d) or Enter to skip: ", len(shown))
+	input, err := readLine(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	if input == "" {
+		return nil, nil
+	}
+
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > len(shown) {
+		fmt.Println("Invalid selection, using the work's details.")
+		return nil, nil
+	}
+	return &shown[choice-1], nil
+}
+
+// describeEdition renders an edition as "Paperback, Ace, 2005, 412 pages, eng, ISBN 9780441013593".
+func describeEdition(e *api.Edition) string {
+	var parts []string
+	if e.Format() != "" {
+		parts = append(parts, e.Format())
+	} else if e.PhysicalFormat != "" {
+		parts = append(parts, e.PhysicalFormat)
+	}
+	if e.Publisher() != "" {
+		parts = append(parts, e.Publisher())
+	}
+	if e.PublishDate != "" {
+		parts = append(parts, e.PublishDate)
+	}
+	if e.NumberOfPages > 0 {
+		parts = append(parts, fmt.Sprintf("%d pages", e.NumberOfPages))
+	}
+	if e.Language() != "" {
+		parts = append(parts, e.Language())
+	}
+	if isbns := e.ISBNs(); len(isbns) > 0 {
+		parts = append(parts, "ISBN "+isbns[0])
+	}
+	if len(parts) == 0 {
+		return e.Title
+	}
+	return strings.Join(parts, ", ")
+}
+
+// applyEdition replaces the work-level pages, cover and ISBN with the
+// edition's, and records the edition's details.
+func applyEdition(book *models.Book, e *api.Edition) {
+	if pages := e.Pages(); pages != nil {
+		book.Pages = sql.NullInt64{Int64: int64(*pages), Valid: true}
+	}
+	if cover := e.CoverURL(); cover != nil {
+		book.CoverURL = sql.NullString{String: *cover, Valid: true}
+	}
+	if isbns := e.ISBNs(); len(isbns) > 0 {
+		book.ISBN = sql.NullString{String: isbns[0], Valid: true}
+	}
+	if strings.HasPrefix(e.Key, "/books/") {
+		book.EditionKey = models.NullString(e.Key)
+	}
+	book.Publisher = models.NullString(e.Publisher())
+	book.PublishDate = models.NullString(e.PublishDate)
+	book.Language = models.NullString(e.Language())
+	book.Format = models.NullString(e.Format())
+}
+
+func nullStringPtr(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	return models.NullString(*s)
+}
+
+func runAddManual(args []string) error {
+	form := addForm
+	if form.Title == "" {
+		form.Title = strings.Join(args, " ")
+	}
+
+	// Fall back to the editor when the required fields weren't given as flags
+	if strings.TrimSpace(form.Title) == "" || strings.TrimSpace(form.Author) == "" {
+		edited, err := editBookForm("# New book\n", form)
+		if err != nil {
+			return err
 		}
+		form = edited
+	} else if err := form.validate(); err != nil {
+		return err
 	}
 
-	bookID, err := db.AddBook(
-		selected.Title,
-		selected.Author(),
-		selected.FirstISBN(),
-		selected.CoverURL(),
-		description,
-		&selected.Key,
-		genres,
-		selected.Pages(),
-	)
+	var book models.Book
+	form.applyTo(&book)
+
+	bookID, err := db.InsertBook(&book)
 	if err != nil {
 		return fmt.Errorf("failed to add book: %w", err)
 	}
@@ -100,6 +299,6 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create reading entry: %w", err)
 	}
 
-	fmt.Printf("\nAdded \"%s\" by %s (ID: %d)\n", selected.Title, selected.Author(), bookID)
+	fmt.Printf("Added \"%s\" by %s (ID: %d)\n", book.Title, book.Author, bookID)
 	return nil
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/server"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var apiAddr string
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Serve your bookshelf as a JSON API",
+}
+
+var apiServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start the JSON API server",
+	Long: `Serve your books, goals, stats and config as a JSON API, for scripts and
+other apps. The endpoints are described by the OpenAPI document at
+/openapi.json, e.g. http://localhost:8080/openapi.json.
+
+It listens on localhost:8080 by default. If api.token is set (see 'bookshelf
+api token'), requests need the header "Authorization: Bearer <token>".
+Without a token the server only listens on, and answers requests to,
+localhost; set one to listen on another address with --addr. Request bodies
+must be sent as application/json.
+
+Stop the server with Ctrl-C.`,
+	Args: cobra.NoArgs,
+	RunE: runAPIServe,
+}
+
+var apiTokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Generate a new API token",
+	Long: `Generate a random bearer token, save it as api.token and print it. Any
+previous token stops working once the server is restarted. Remove the token
+with 'bookshelf config unset api.token'.`,
+	Args: cobra.NoArgs,
+	RunE: runAPIToken,
+}
+
+func init() {
+	apiServeCmd.Flags().StringVar(&apiAddr, "addr", "localhost:8080", "Address to listen on")
+	apiCmd.AddCommand(apiServeCmd)
+	apiCmd.AddCommand(apiTokenCmd)
+}
+
+func runAPIServe(cmd *cobra.Command, args []string) error {
+	token, err := db.GetConfig(server.TokenConfigKey)
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+
+	if token == "" && !server.IsLoopbackHost(apiAddr) {
+		return fmt.Errorf("refusing to listen on %s without an API token; set one with 'bookshelf api token', or listen on localhost", apiAddr)
+	}
+
+	handler := server.New(server.WithToken(token), server.WithConfigValidator(validateConfig))
+	return serveUntilDone(cmd.Context(), apiAddr, handler, func(url string) {
+		fmt.Printf("Serving the bookshelf API on %s\n", url)
+	})
+}
+
+func runAPIToken(cmd *cobra.Command, args []string) error {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := db.SetConfig(server.TokenConfigKey, token); err != nil {
+		return fmt.Errorf("failed to set config: %w", err)
+	}
+	fmt.Println(token)
+	return nil
+}
+
+// serveUntilDone serves handler on addr until ctx is done, then shuts the
+// server down. Once listening it calls announce with the URL to browse to.
+func serveUntilDone(ctx context.Context, addr string, handler http.Handler, announce func(url string)) error {
+	// SQLite allows one writer at a time; queue requests rather than fail
+	// them with "database is locked".
+	db.DB.SetMaxOpenConns(1)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	announce("http://" + displayAddr(listener.Addr()))
+	fmt.Println("Press Ctrl-C to stop.")
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(listener) }()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to stop server: %w", err)
+	}
+	fmt.Println("Stopped.")
+	return nil
+}
+
+// displayAddr turns a listener's address into one to browse to, using
+// localhost when listening on every interface.
+func displayAddr(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var authorLimit int
+
+var authorCmd = &cobra.Command{
+	Use:   "author [name]",
+	Short: "Show an author's bio, your books by them and what you haven't read",
+	Long: `Look up an author on Open Library and show their bio, the books by them on
+your shelf, and their works you don't have yet. Pick one of those works to add
+it to your shelf.
+
+The author's details are saved, so they're still shown when Open Library can't
+be reached.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAuthor,
+}
+
+func init() {
+	authorCmd.Flags().IntVarP(&authorLimit, "limit", "l", 20, "Maximum number of unread works to list")
+	authorCmd.Flags().BoolVar(&addAnyEdition, "any-edition", false, "Skip the edition picker when adding a work")
+}
+
+func runAuthor(cmd *cobra.Command, args []string) error {
+	name := strings.Join(args, " ")
+	if authorLimit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+
+	client, err := openLibraryClient()
+	if err != nil {
+		return err
+	}
+
+	author, err := lookupAuthor(cmd.Context(), client, name)
+	if err != nil {
+		return err
+	}
+
+	books, err := db.GetBooksByAuthor(author.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get books: %w", err)
+	}
+
+	printAuthor(author)
+
+	fmt.Printf("\nOn your shelf (%d):\n", len(books))
+	if len(books) == 0 {
+		fmt.Println("  None yet.")
+	}
+	for _, b := range books {
+		fmt.Printf("  [%d] %s - %s\n", b.Book.ID, b.Book.Title, b.ReadingEntry.Status)
+	}
+
+	unread := unreadWorks(author.WorkList(), books)
+	if len(unread) == 0 {
+		return nil
+	}
+
+	shown := unread
+	if len(shown) > authorLimit {
+		shown = shown[:authorLimit]
+	}
+	fmt.Printf("\nNot on your shelf (%d):\n", len(unread))
+	for i, w := range shown {
+		year := ""
+		if w.FirstPublished != "" {
+			year = fmt.Sprintf(" (%s)", w.FirstPublished)
+		}
+		fmt.Printf("  %d. %s%s\n", i+1, w.Title, year)
+	}
+	if len(unread) > len(shown) {
+		fmt.Printf("  ...and %d more\n", len(unread)-len(shown))
+	}
+
+	fmt.Printf("\nAdd a work (1-%d) or Enter to skip: ", len(shown))
+	input, err := readLine(cmd.Context(), stdin)
+	if err != nil {
+		return err
+	}
+	if input == "" {
+		fmt.Println()
+		return nil
+	}
+
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > len(shown) {
+		fmt.Println("Invalid selection.")
+		return nil
+	}
+
+	work := shown[choice-1]
+	doc := api.SearchDoc{Key: work.Key, Title: work.Title, AuthorName: []string{author.Name}, CoverImage: work.CoverURL}
+	return addSearchResult(cmd.Context(), client, stdin, doc)
+}
+
+// lookupAuthor finds the best match for name on Open Library and saves it. If
+// Open Library can't be reached, the saved author is used instead.
+func lookupAuthor(ctx context.Context, client *api.Client, name string) (*models.Author, error) {
+	author, err := fetchAuthor(ctx, client, name)
+	if err == nil {
+		if _, err := db.SaveAuthor(author); err != nil {
+			return nil, fmt.Errorf("failed to save author: %w", err)
+		}
+		return author, nil
+	}
+	if errors.Is(err, errAuthorNotFound) {
+		return nil, fmt.Errorf("no author found matching \"%s\"", name)
+	}
+
+	saved, dbErr := db.GetAuthorByName(name)
+	if dbErr != nil || saved == nil {
+		return nil, fmt.Errorf("failed to look up author: %w", err)
+	}
+	fmt.Printf("Couldn't reach Open Library (%v), showing saved details from %s.\n\n", err, saved.UpdatedAt.Format("Jan 02, 2006"))
+	return saved, nil
+}
+
+var errAuthorNotFound = errors.New("author not found")
+
+func fetchAuthor(ctx context.Context, client *api.Client, name string) (*models.Author, error) {
+	docs, err := client.SearchAuthors(ctx, name, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, errAuthorNotFound
+	}
+
+	found, err := client.GetAuthor(ctx, docs[0].Key)
+	if err != nil {
+		return nil, err
+	}
+
+	author := &models.Author{
+		OpenLibraryKey: found.Key,
+		Name:           found.Name,
+		Bio:            nullStringPtr(found.BioText()),
+		BirthDate:      models.NullString(found.BirthDate),
+		DeathDate:      models.NullString(found.DeathDate),
+		PhotoURL:       nullStringPtr(found.PhotoURL()),
+	}
+	if author.Name == "" {
+		author.Name = docs[0].Name
+	}
+
+	works := make([]models.AuthorWork, 0, len(found.Works))
+	for _, w := range found.Works {
+		work := models.AuthorWork{Key: w.Key, Title: w.Title, FirstPublished: w.FirstPublishDate}
+		if id := w.CoverID(); id > 0 {
+			work.CoverURL = api.CoverImageURL(id)
+		}
+		works = append(works, work)
+	}
+	if jsonBytes, err := json.Marshal(works); err == nil {
+		author.Works = sql.NullString{String: string(jsonBytes), Valid: true}
+	}
+	return author, nil
+}
+
+func printAuthor(author *models.Author) {
+	fmt.Print(author.Name)
+	if author.BirthDate.Valid || author.DeathDate.Valid {
+		fmt.Printf(" (%s - %s)", author.BirthDate.String, author.DeathDate.String)
+	}
+	fmt.Println()
+
+	if author.PhotoURL.Valid {
+		fmt.Printf("Photo: %s\n", author.PhotoURL.String)
+	}
+	if author.Bio.Valid {
+		fmt.Printf("\n%s\n", oneLine(author.Bio.String, 400))
+	}
+}
+
+// unreadWorks returns the works that aren't on the shelf, matching books by
+// Open Library key and then by title.
+func unreadWorks(works []models.AuthorWork, books []models.BookWithEntry) []models.AuthorWork {
+	have := make(map[string]bool)
+	for _, b := range books {
+		if b.Book.OpenLibraryKey.Valid {
+			have[b.Book.OpenLibraryKey.String] = true
+		}
+		have[normalizeTitle(b.Book.Title)] = true
+	}
+
+	var unread []models.AuthorWork
+	for _, w := range works {
+		if have[w.Key] || have[normalizeTitle(w.Title)] {
+			continue
+		}
+		unread = append(unread, w)
+	}
+	return unread
+}
+
+// normalizeTitle lowercases a title and collapses its whitespace for comparison.
+func normalizeTitle(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), " ")
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// bookRefHelp explains the book argument, in the help of every command
+// taking one.
+const bookRefHelp = `The book can be given by its ID, its ISBN, or words from its title or
+author, e.g. "gatsby" or "fitzgerald"; quote references with spaces. If
+several books match, you're asked which one you meant (with --no-input, the
+command fails and lists them).`
+
+func init() {
+	for _, cmd := range []*cobra.Command{
+		showCmd, startCmd, finishCmd, rateCmd, reviewCmd, removeCmd, refreshCmd,
+		editCmd, datesCmd, progressCmd, sessionCmd, readCmd, quoteAddCmd, quotesCmd,
+	} {
+		cmd.Long += "\n\n" + bookRefHelp
+		cmd.ValidArgsFunction = completeBooks
+	}
+}
+
+// resolveBook finds the book ref refers to: a book ID, an ISBN, or words from
+// its title or author. When the words match several books it asks which one
+// was meant, unless --no-input is set.
+func resolveBook(ctx context.Context, ref string) (*models.BookWithEntry, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("no book given (use an ID, ISBN, or words from the title or author)")
+	}
+
+	id, idErr := strconv.ParseInt(ref, 10, 64)
+	if idErr == nil {
+		book, err := db.GetBook(id)
+		if err == nil {
+			return book, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get book: %w", err)
+		}
+		// Not an ID; maybe a title like "1984".
+	}
+
+	books, err := db.ListBooks(models.ListOptions{SortBy: models.SortByTitle})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	matches := matchBooks(books, ref)
+
+	switch len(matches) {
+	case 0:
+		if idErr == nil {
+			return nil, fmt.Errorf("book with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("no book matches %q", ref)
+	case 1:
+		return &matches[0], nil
+	}
+	return chooseBook(ctx, ref, matches)
+}
+
+// matchBooks returns the books ref is the ISBN of or, failing that, those
+// whose title or author contains every word of ref. Books titled exactly ref
+// win over ones that merely contain it.
+func matchBooks(books []models.BookWithEntry, ref string) []models.BookWithEntry {
+	if isbn := api.NormalizeISBN(ref); isbn != "" && isISBNLike(ref) {
+		var byISBN []models.BookWithEntry
+		for _, book := range books {
+			if book.Book.ISBN.Valid && api.NormalizeISBN(book.Book.ISBN.String) == isbn {
+				byISBN = append(byISBN, book)
+			}
+		}
+		if len(byISBN) > 0 {
+			return byISBN
+		}
+	}
+
+	words := strings.Fields(foldForMatch(ref))
+	if len(words) == 0 {
+		return nil
+	}
+	phrase := strings.Join(words, " ")
+
+	var matches, exact []models.BookWithEntry
+	for _, book := range books {
+		title := foldForMatch(book.Book.Title)
+		text := " " + title + " " + foldForMatch(book.Book.Author) + " "
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		matches = append(matches, book)
+		if strings.Join(strings.Fields(title), " ") == phrase {
+			exact = append(exact, book)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return matches
+}
+
+// isISBNLike reports whether s is written like an ISBN: digits, possibly an
+// X check digit, and separators.
+func isISBNLike(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != 'X' && r != 'x' && r != '-' && r != ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// foldForMatch lowercases s and turns punctuation into spaces, so "Catch-22"
+// matches "catch 22".
+func foldForMatch(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+}
+
+// chooseBook asks which of several matching books ref meant. With
+// --no-input, or no answer, it fails listing them instead.
+func chooseBook(ctx context.Context, ref string, matches []models.BookWithEntry) (*models.BookWithEntry, error) {
+	var list strings.Builder
+	for _, book := range matches {
+		fmt.Fprintf(&list, "\n  %d  %s", book.Book.ID, describeBookRef(book))
+	}
+	ambiguous := fmt.Errorf("%q matches %d books; use the ID of the one you mean:%s", ref, len(matches), list.String())
+	if noInput {
+		return nil, ambiguous
+	}
+
+	fmt.Printf("%d books match %q:\n", len(matches), ref)
+	for i, book := range matches {
+		fmt.Printf("  %d. %s (ID: %d)\n", i+1, describeBookRef(book), book.Book.ID)
+	}
+	fmt.Printf("\nWhich one (1-%d, or 0 to cancel)? ", len(matches))
+	input, err := readLine(ctx, stdin)
+	if err != nil {
+		return nil, err
+	}
+	if input == "" {
+		fmt.Println()
+		return nil, ambiguous
+	}
+
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 0 || choice > len(matches) {
+		return nil, fmt.Errorf("invalid selection: %s", input)
+	}
+	if choice == 0 {
+		return nil, errors.New("cancelled")
+	}
+	return &matches[choice-1], nil
+}
+
+// describeBookRef tells matching books apart, e.g. `"Emma" by Jane Austen,
+// finished`.
+func describeBookRef(book models.BookWithEntry) string {
+	return fmt.Sprintf("\"%s\" by %s, %s", book.Book.Title, book.Book.Author, book.ReadingEntry.Status)
+}
+
+// completeBooks completes a command's book argument with the titles of the
+// books starting with what's been typed, described by their authors.
+func completeBooks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	books, err := db.ListBooks(models.ListOptions{SortBy: models.SortByTitle})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	prefix := strings.ToLower(toComplete)
+	var titles []string
+	for _, book := range books {
+		if strings.HasPrefix(strings.ToLower(book.Book.Title), prefix) {
+			titles = append(titles, book.Book.Title+"\tby "+book.Book.Author)
+		}
+	}
+	return titles, cobra.ShellCompDirectiveNoFileComp
+}
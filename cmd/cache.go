@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultCacheTTL is how long responses are served without revalidating.
+const defaultCacheTTL = 24 * time.Hour
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the metadata response cache",
+	Long: `Responses from metadata providers are cached on disk so repeated lookups
+are fast and work with --offline. Cached responses younger than cache.ttl
+(default 24h) are used as-is; older ones are revalidated with the server.`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache size and location",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheStats,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete all cached responses",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	cache, err := httpCache()
+	if err != nil {
+		return err
+	}
+	stats, err := cache.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	fmt.Printf("Location: %s\n", cache.Dir)
+	fmt.Printf("TTL:      %s\n", formatTTL(cache.TTL))
+	fmt.Printf("Entries:  %d\n", stats.Entries)
+	fmt.Printf("Size:     %s\n", formatBytes(stats.Bytes))
+	if stats.Entries > 0 {
+		fmt.Printf("Oldest:   %s\n", stats.Oldest.Format("Jan 02, 2006 15:04"))
+		fmt.Printf("Newest:   %s\n", stats.Newest.Format("Jan 02, 2006 15:04"))
+	}
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := httpCache()
+	if err != nil {
+		return err
+	}
+	removed, err := cache.Clear()
+	if err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	fmt.Printf("Removed %d cached responses\n", removed)
+	return nil
+}
+
+// httpCache returns the response cache, which lives next to the database.
+func httpCache() (*api.Cache, error) {
+	dbPath, err := db.Path()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := defaultCacheTTL
+	value, err := db.GetConfig("cache.ttl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	if value != "" {
+		if ttl, err = parseTTL(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return api.NewCache(filepath.Join(filepath.Dir(dbPath), "cache"), ttl, offline), nil
+}
+
+// parseTTL accepts Go durations ("12h", "90m") plus whole days ("7d").
+func parseTTL(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid cache TTL: %s (use e.g. 12h, 30m or 7d)", value)
+	}
+	return d, nil
+}
+
+func formatTTL(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"bookshelf/internal/api"
 	"bookshelf/internal/db"
+	"bookshelf/internal/models"
 	"fmt"
 	"os"
 	"sort"
@@ -13,11 +15,20 @@ import (
 
 // Valid configuration keys
 var validConfigKeys = map[string]string{
-	"site.title":       "Website title (default: 'My Bookshelf')",
-	"site.subtitle":    "Website subtitle (default: 'Personal Reading Tracker')",
-	"site.author":      "Your name for attribution in footer",
-	"site.description": "Meta description for SEO",
-	"site.base_url":    "Base URL for canonical links (e.g., https://example.com)",
+	"site.title":           "Website title (default: 'My Bookshelf')",
+	"site.subtitle":        "Website subtitle (default: 'Personal Reading Tracker')",
+	"site.author":          "Your name for attribution in footer",
+	"site.description":     "Meta description for SEO",
+	"site.base_url":        "Base URL for canonical links (e.g., https://example.com)",
+	"rating.scale":         "Rating scale: stars, half-stars or 10-point (default: stars)",
+	"metadata.providers":   "Metadata providers, comma-separated: openlibrary, google (default: openlibrary)",
+	"google_books.api_key": "Google Books API key (optional, raises the request quota)",
+	"cache.ttl":            "How long cached metadata is used before revalidating, e.g. 12h or 7d (default: 24h)",
+	"contact.email":        "Email sent in the User-Agent so API operators can reach you (recommended by Open Library)",
+	"http.timeout":         "Timeout for each metadata request, e.g. 30s (default: 10s)",
+	"http.proxy":           "HTTP proxy for metadata requests, e.g. http://proxy.example.com:3128",
+	"calibre.library":      "Calibre library folder for 'bookshelf sync calibre' (set by 'bookshelf import calibre')",
+	"api.token":            "Bearer token 'bookshelf api serve' requires, if set (generate one with 'bookshelf api token')",
 }
 
 var configCmd = &cobra.Command{
@@ -72,8 +83,8 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 	value := strings.Join(args[1:], " ")
 
-	if _, valid := validConfigKeys[key]; !valid {
-		return fmt.Errorf("unknown config key: %s\nUse 'bookshelf config keys' to see available keys", key)
+	if err := validateConfig(key, value); err != nil {
+		return err
 	}
 
 	if err := db.SetConfig(key, value); err != nil {
@@ -84,6 +95,42 @@ func runConfigSet(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// validateConfig checks that key is known and value makes sense for it. It's
+// shared by config set and the API server.
+func validateConfig(key, value string) error {
+	if _, valid := validConfigKeys[key]; !valid {
+		return fmt.Errorf("unknown config key: %s\nUse 'bookshelf config keys' to see available keys", key)
+	}
+
+	switch key {
+	case "rating.scale":
+		if !models.RatingScale(value).IsValid() {
+			return fmt.Errorf("invalid rating scale: %s (use: stars, half-stars, 10-point)", value)
+		}
+	case "cache.ttl":
+		if _, err := parseTTL(value); err != nil {
+			return err
+		}
+	case "contact.email":
+		if !strings.Contains(value, "@") {
+			return fmt.Errorf("invalid email: %s", value)
+		}
+	case "http.timeout":
+		if _, err := parseTimeout(value); err != nil {
+			return err
+		}
+	case "http.proxy":
+		if _, err := parseProxy(value); err != nil {
+			return err
+		}
+	case "metadata.providers":
+		if _, err := api.NewProviders(value, api.ProviderOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func runConfigGet(cmd *cobra.Command, args []string) error {
 	key := args[0]
 
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var datesStarted string
+var datesFinished string
+var datesClearStarted bool
+var datesClearFinished bool
+
+var datesCmd = &cobra.Command{
+	Use:   "dates [book]",
+	Short: "Show or correct a book's start and finish dates",
+	Long: `Show a book's start and finish dates, or correct them with --started and
+--finished. Dates can be exact (2026-03-14, 2025-06) or fuzzy ("last tuesday",
+"3 weeks ago"). The start date must not be after the finish date.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDates,
+}
+
+func init() {
+	datesCmd.Flags().StringVar(&datesStarted, "started", "", "Set the start date")
+	datesCmd.Flags().StringVar(&datesFinished, "finished", "", "Set the finish date")
+	datesCmd.Flags().BoolVar(&datesClearStarted, "clear-started", false, "Remove the start date")
+	datesCmd.Flags().BoolVar(&datesClearFinished, "clear-finished", false, "Remove the finish date")
+}
+
+func runDates(cmd *cobra.Command, args []string) error {
+	if datesStarted != "" && datesClearStarted {
+		return fmt.Errorf("--started and --clear-started cannot be used together")
+	}
+	if datesFinished != "" && datesClearFinished {
+		return fmt.Errorf("--finished and --clear-finished cannot be used together")
+	}
+
+	book, err := resolveBook(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	id := book.Book.ID
+
+	started := book.ReadingEntry.StartedAt
+	finished := book.ReadingEntry.FinishedAt
+	changed := false
+
+	if datesStarted != "" {
+		date, err := parseDateFlag(datesStarted)
+		if err != nil {
+			return err
+		}
+		started = sql.NullTime{Time: date, Valid: true}
+		changed = true
+	}
+	if datesFinished != "" {
+		date, err := parseDateFlag(datesFinished)
+		if err != nil {
+			return err
+		}
+		finished = sql.NullTime{Time: date, Valid: true}
+		changed = true
+	}
+	if datesClearStarted {
+		started = sql.NullTime{}
+		changed = true
+	}
+	if datesClearFinished {
+		finished = sql.NullTime{}
+		changed = true
+	}
+
+	if changed {
+		if err := db.SetReadingDates(id, started, finished); err != nil {
+			return fmt.Errorf("failed to update dates: %w", err)
+		}
+		fmt.Printf("Updated dates for \"%s\"\n", book.Book.Title)
+	} else {
+		fmt.Printf("\"%s\"\n", book.Book.Title)
+	}
+
+	fmt.Printf("  Started:  %s\n", formatOptionalDate(started))
+	fmt.Printf("  Finished: %s\n", formatOptionalDate(finished))
+	return nil
+}
+
+// parseDateFlag parses an exact or fuzzy date given on the command line.
+// Dates in the future are rejected.
+func parseDateFlag(value string) (time.Time, error) {
+	return models.ParseReadingDate(value, time.Now())
+}
+
+func formatOptionalDate(t sql.NullTime) string {
+	if !t.Valid {
+		return "-"
+	}
+	return t.Time.Format("Jan 02, 2006")
+}
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit [book]",
+	Short: "Edit a book's metadata",
+	Long: `Open a book's metadata in your default editor ($EDITOR) as YAML.
+Fix a wrong title, author or page count and save to write the changes back.
+Clearing a value removes it from the book.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEdit,
+}
+
+// bookForm is the editable, YAML-friendly view of models.Book.
+type bookForm struct {
+	Title          string   `yaml:"title"`
+	Author         string   `yaml:"author"`
+	ISBN           string   `yaml:"isbn"`
+	Pages          int      `yaml:"pages"`
+	CoverURL       string   `yaml:"cover_url"`
+	Description    string   `yaml:"description"`
+	OpenLibraryKey string   `yaml:"open_library_key"`
+	Genres         []string `yaml:"genres"`
+	Tags           []string `yaml:"tags"`
+	Series         string   `yaml:"series"`
+	SeriesIndex    float64  `yaml:"series_index"`
+	Format         string   `yaml:"format"`
+	Duration       string   `yaml:"duration"`
+	Publisher      string   `yaml:"publisher"`
+	PublishDate    string   `yaml:"publish_date"`
+	Language       string   `yaml:"language"`
+	EditionKey     string   `yaml:"edition_key"`
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	book, err := resolveBook(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	id := book.Book.ID
+
+	original := bookFormFrom(book.Book)
+	header := fmt.Sprintf("# Editing book %d, added %s\n", id, book.Book.CreatedAt.Format("Jan 02, 2006"))
+	edited, err := editBookForm(header, original)
+	if err != nil {
+		return err
+	}
+
+	changed := changedFields(original, edited)
+	if len(changed) == 0 {
+		fmt.Println("No changes.")
+		return nil
+	}
+
+	updated := book.Book
+	edited.applyTo(&updated)
+	if err := db.UpdateBook(&updated); err != nil {
+		return fmt.Errorf("failed to update book: %w", err)
+	}
+
+	fmt.Printf("Updated \"%s\": %s\n", updated.Title, strings.Join(changed, ", "))
+	return nil
+}
+
+// editBookForm opens form in the editor and parses and validates the result.
+func editBookForm(header string, form bookForm) (bookForm, error) {
+	body, err := yaml.Marshal(form)
+	if err != nil {
+		return form, fmt.Errorf("failed to encode book: %w", err)
+	}
+
+	header += "# Blank values are cleared. Lines starting with # are ignored.\n\n"
+	content, err := editInEditor("bookshelf-book-*.yaml", header+string(body))
+	if err != nil {
+		return form, err
+	}
+
+	var edited bookForm
+	if err := yaml.Unmarshal([]byte(content), &edited); err != nil {
+		return form, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if err := edited.validate(); err != nil {
+		return form, err
+	}
+	return edited, nil
+}
+
+func bookFormFrom(book models.Book) bookForm {
+	form := bookForm{
+		Title:          book.Title,
+		Author:         book.Author,
+		ISBN:           book.ISBN.String,
+		Pages:          int(book.Pages.Int64),
+		CoverURL:       book.CoverURL.String,
+		Description:    book.Description.String,
+		OpenLibraryKey: book.OpenLibraryKey.String,
+		Series:         book.Series.String,
+		SeriesIndex:    book.SeriesIndex.Float64,
+		Format:         book.Format.String,
+		Publisher:      book.Publisher.String,
+		PublishDate:    book.PublishDate.String,
+		Language:       book.Language.String,
+		EditionKey:     book.EditionKey.String,
+	}
+	if book.DurationMinutes.Valid {
+		form.Duration = models.FormatMinutes(int(book.DurationMinutes.Int64))
+	}
+	if book.Genres.Valid && book.Genres.String != "" {
+		var genres []string
+		if err := json.Unmarshal([]byte(book.Genres.String), &genres); err == nil && len(genres) > 0 {
+			form.Genres = genres
+		}
+	}
+	form.Tags = book.TagList()
+	return form
+}
+
+func (f *bookForm) validate() error {
+	f.Title = strings.TrimSpace(f.Title)
+	f.Author = strings.TrimSpace(f.Author)
+	if f.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if f.Author == "" {
+		return fmt.Errorf("author is required")
+	}
+	if f.Pages < 0 {
+		return fmt.Errorf("pages must be positive")
+	}
+	if f.SeriesIndex < 0 {
+		return fmt.Errorf("series_index must be positive")
+	}
+	f.Series = strings.TrimSpace(f.Series)
+	f.Format = strings.ToLower(strings.TrimSpace(f.Format))
+	if f.Format != "" && !models.BookFormat(f.Format).IsValid() {
+		return fmt.Errorf("invalid format: %s (use: %s)", f.Format, formatNames())
+	}
+	f.Duration = strings.TrimSpace(f.Duration)
+	if f.Duration != "" {
+		minutes, err := parseMinutes(f.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %s (use e.g. 11h25m)", f.Duration)
+		}
+		if minutes == 0 {
+			return fmt.Errorf("duration must be positive")
+		}
+	}
+
+	var genres []string
+	for _, g := range f.Genres {
+		if g = strings.TrimSpace(g); g != "" {
+			genres = append(genres, g)
+		}
+	}
+	f.Genres = genres
+
+	var tags []string
+	for _, t := range f.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	f.Tags = tags
+	return nil
+}
+
+// applyTo copies the form onto book, turning blank values into NULLs.
+func (f bookForm) applyTo(book *models.Book) {
+	book.Title = f.Title
+	book.Author = f.Author
+	book.ISBN = models.NullString(f.ISBN)
+	book.Pages = sql.NullInt64{Int64: int64(f.Pages), Valid: f.Pages > 0}
+	book.CoverURL = models.NullString(f.CoverURL)
+	book.Description = models.NullString(f.Description)
+	book.OpenLibraryKey = models.NullString(f.OpenLibraryKey)
+	book.Series = models.NullString(f.Series)
+	book.SeriesIndex = sql.NullFloat64{Float64: f.SeriesIndex, Valid: f.Series != "" && f.SeriesIndex > 0}
+	book.Format = models.NullString(f.Format)
+	book.DurationMinutes = sql.NullInt64{}
+	if minutes, err := parseMinutes(f.Duration); err == nil && minutes > 0 {
+		book.DurationMinutes = sql.NullInt64{Int64: int64(minutes), Valid: true}
+	}
+	book.Publisher = models.NullString(f.Publisher)
+	book.PublishDate = models.NullString(f.PublishDate)
+	book.Language = models.NullString(f.Language)
+	book.EditionKey = models.NullString(f.EditionKey)
+
+	book.Genres = sql.NullString{}
+	if len(f.Genres) > 0 {
+		if jsonBytes, err := json.Marshal(f.Genres); err == nil {
+			book.Genres = sql.NullString{String: string(jsonBytes), Valid: true}
+		}
+	}
+	book.SetTags(f.Tags)
+}
+
+// changedFields lists the YAML names of the fields that differ between two forms.
+func changedFields(before, after bookForm) []string {
+	var changed []string
+	bv, av := reflect.ValueOf(before), reflect.ValueOf(after)
+	for i := 0; i < bv.NumField(); i++ {
+		if !reflect.DeepEqual(bv.Field(i).Interface(), av.Field(i).Interface()) {
+			changed = append(changed, bv.Type().Field(i).Tag.Get("yaml"))
+		}
+	}
+	return changed
+}
+
+func formatNames() string {
+	names := make([]string, len(models.BookFormats))
+	for i, f := range models.BookFormats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
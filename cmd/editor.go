@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// editInEditor writes content to a temp file, opens it in $EDITOR (falling
+// back to vi) and returns the edited text. It fails under --no-input.
+func editInEditor(pattern, content string) (string, error) {
+	if noInput {
+		return "", fmt.Errorf("can't open an editor with --no-input")
+	}
+	tmpFile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	tmpFile.WriteString(content)
+	tmpFile.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	editorCmd := exec.Command(editor, tmpFile.Name())
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	edited, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read temp file: %w", err)
+	}
+	return string(edited), nil
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/export"
+	"bookshelf/internal/models"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat  string
+	exportColumns string
+	exportProfile string
+	exportOutput  string
+	exportDir     string
+	exportStatus  string
+	exportSearch  string
+	exportSort    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export your books for spreadsheets and other apps",
+	Long:  exportLong(),
+	Args:  cobra.NoArgs,
+	RunE:  runExport,
+}
+
+func exportLong() string {
+	var b strings.Builder
+	b.WriteString(`Export your books as CSV, to stdout or a file, or as a folder of Markdown
+notes. Filter and sort them as with 'bookshelf list'.
+
+For CSV, choose the columns with --columns (default: ` + strings.Join(export.DefaultColumns, ",") + `):
+
+`)
+	for _, name := range export.ColumnNames() {
+		fmt.Fprintf(&b, "  %-17s %s\n", name, export.Columns[name].Description)
+	}
+	b.WriteString(`
+Dates are written as 2006-01-02 and genres and tags as "a; b".
+
+With --profile goodreads the columns match a Goodreads library export instead,
+so the file can be imported into Goodreads.
+
+With --format markdown --dir <folder>, each book gets a note in the folder,
+e.g. for an Obsidian vault: YAML front matter (title, author, isbn, status,
+rating, started, finished, genres, tags, cover) followed by your review and
+the description. Export again to update the notes; front matter fields of your
+own and anything you write below the line
+
+  ` + export.NotesMarker + `
+
+are kept.`)
+	return b.String()
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "csv", "Export format: csv or markdown")
+	exportCmd.Flags().StringVar(&exportColumns, "columns", strings.Join(export.DefaultColumns, ","), "Comma-separated columns to write")
+	exportCmd.Flags().StringVar(&exportProfile, "profile", "", "Write a preset layout instead of --columns: goodreads")
+	exportCmd.Flags().StringVar(&exportOutput, "output", "", "File to write (default: stdout)")
+	exportCmd.Flags().StringVar(&exportDir, "dir", "", "Folder to write Markdown notes into")
+	exportCmd.Flags().StringVarP(&exportStatus, "status", "s", "", "Filter by status (want-to-read, reading, finished, did-not-finish)")
+	exportCmd.Flags().StringVarP(&exportSearch, "search", "q", "", "Search by title or author")
+	exportCmd.Flags().StringVarP(&exportSort, "sort", "o", "added", "Sort by: added, title, author, rating")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	switch exportFormat {
+	case "csv":
+		if exportDir != "" {
+			return fmt.Errorf("--dir can only be used with --format markdown")
+		}
+	case "markdown":
+		for _, name := range []string{"columns", "profile", "output"} {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s can only be used with --format csv", name)
+			}
+		}
+		if exportDir == "" {
+			return fmt.Errorf("--format markdown needs --dir")
+		}
+	default:
+		return fmt.Errorf("invalid format: %s (use: csv, markdown)", exportFormat)
+	}
+	if exportProfile != "" && exportProfile != "goodreads" {
+		return fmt.Errorf("invalid profile: %s (use: goodreads)", exportProfile)
+	}
+	if exportProfile != "" && cmd.Flags().Changed("columns") {
+		return fmt.Errorf("--columns can't be used with --profile")
+	}
+
+	columns, err := export.ParseColumns(exportColumns)
+	if err != nil {
+		return err
+	}
+
+	opts, err := models.ParseListOptions(exportStatus, exportSearch, exportSort)
+	if err != nil {
+		return err
+	}
+
+	books, err := db.ListBooks(opts)
+	if err != nil {
+		return fmt.Errorf("failed to list books: %w", err)
+	}
+
+	if exportFormat == "markdown" {
+		result, err := export.WriteMarkdown(exportDir, books)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %d books to %s: %d new, %d updated, %d unchanged\n",
+			len(books), exportDir, result.Created, result.Updated, result.Unchanged)
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", exportOutput, err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if exportProfile == "goodreads" {
+		err = export.WriteGoodreadsCSV(w, books)
+	} else {
+		err = export.WriteCSV(w, books, columns)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	if exportOutput != "" {
+		fmt.Printf("Exported %d books to %s\n", len(books), exportOutput)
+	}
+	return nil
+}
@@ -4,45 +4,69 @@ import (
 	"bookshelf/internal/db"
 	"bookshelf/internal/models"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var finishNoDate bool
+var finishDate string
 
 var finishCmd = &cobra.Command{
-	Use:   "finish [id]",
+	Use:   "finish [book]",
 	Short: "Mark a book as finished",
-	Long:  `Finish reading a book. This will set its status to "finished" and record the finish date.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runFinish,
+	Long: `Finish reading a book. This will set its status to "finished" and record the finish date.
+
+Use --date to backdate the finish, e.g. --date 2026-03-14 or --date "last tuesday".`,
+	Args: cobra.ExactArgs(1),
+	RunE: runFinish,
 }
 
 func init() {
 	finishCmd.Flags().BoolVar(&finishNoDate, "no-date", false, "Don't record the finish date (for books finished at an unknown time)")
+	finishCmd.Flags().StringVarP(&finishDate, "date", "d", "", "Finish date, exact or fuzzy (e.g. 2026-03-14, \"last tuesday\")")
 }
 
 func runFinish(cmd *cobra.Command, args []string) error {
-	id, err := strconv.ParseInt(args[0], 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid book ID: %s", args[0])
+	if finishNoDate && finishDate != "" {
+		return fmt.Errorf("--date and --no-date cannot be used together")
 	}
 
-	exists, err := db.BookExists(id)
+	book, err := resolveBook(cmd.Context(), args[0])
 	if err != nil {
-		return fmt.Errorf("failed to check book: %w", err)
+		return err
 	}
-	if !exists {
-		return fmt.Errorf("book with ID %d not found", id)
+	id := book.Book.ID
+
+	var date *time.Time
+	if finishDate != "" {
+		parsed, err := parseDateFlag(finishDate)
+		if err != nil {
+			return err
+		}
+		if err := book.ReadingEntry.CheckStatusDate(models.StatusFinished, parsed); err != nil {
+			return err
+		}
+		date = &parsed
+	} else if !finishNoDate {
+		now := time.Now()
+		date = &now
 	}
 
-	if err := db.UpdateStatusWithDate(id, models.StatusFinished, !finishNoDate); err != nil {
+	scale, err := db.GetRatingScale()
+	if err != nil {
+		return fmt.Errorf("failed to get rating scale: %w", err)
+	}
+
+	if err := db.UpdateStatusOn(id, models.StatusFinished, date); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
 
-	book, _ := db.GetBook(id)
-	fmt.Printf("Finished reading \"%s\"\n", book.Book.Title)
-	fmt.Println("Don't forget to rate it with: bookshelf rate", id, "<1-5>")
+	fmt.Printf("Finished reading \"%s\"", book.Book.Title)
+	if finishDate != "" {
+		fmt.Printf(" on %s", date.Format("Jan 02, 2006"))
+	}
+	fmt.Println()
+	fmt.Printf("Don't forget to rate it with: bookshelf rate %d <rating> (between %s)\n", id, scale.Range())
 	return nil
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bookshelf/internal/importer"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <format> <file>",
+	Short: "Import books from another reading tracker",
+	Long: `Import an export from another reading tracker. Formats:
+
+  storygraph    StoryGraph's library export (CSV)
+  librarything  LibraryThing's export (tab-delimited or JSON)
+  kindle        A Kindle's "My Clippings.txt", as quotes
+  calibre       A Calibre library folder
+
+Statuses, reading dates, ratings (rounded to half stars), reviews and tags are
+kept. Each format maps its own columns onto these; what a format doesn't
+record is left empty. Books already on your shelf, matched by ISBN or by title
+and author, only have their missing details filled in, so importing the same
+file again is safe, and carries on where an import that failed stopped.
+
+Kindle highlights, with any notes made on them, are saved as quotes on the
+books they're from, matched by title and author. Add the books first; the
+titles that don't match are listed.
+
+A Calibre library's ebooks are added as want-to-read, with their authors,
+ISBN, tags, series, rating (rounded to half stars) and cover. Each keeps its
+Calibre ID, so 'bookshelf sync calibre' can add ebooks new to the library
+later without duplicating the rest.`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: importFormats,
+	RunE:      runImport,
+}
+
+// importFormats are the formats import reads: book exports, Kindle clippings
+// and Calibre libraries.
+var importFormats = append(slices.Clone(importer.FormatNames), importer.FormatKindle, importer.FormatCalibre)
+
+func runImport(cmd *cobra.Command, args []string) error {
+	format, path := strings.ToLower(args[0]), args[1]
+	if !slices.Contains(importFormats, format) {
+		return fmt.Errorf("unknown import format: %s (use: %s)", format, strings.Join(importFormats, ", "))
+	}
+
+	if format == importer.FormatCalibre {
+		return runCalibreImport(path, "Imported")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+
+	if format == importer.FormatKindle {
+		return importClippings(f, path)
+	}
+
+	records, err := importer.Parse(format, f)
+	if err != nil {
+		return err
+	}
+
+	result, err := importer.Import(records)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d rows from %s: %d created, %d updated, %d skipped.\n",
+		len(records), path, result.Created, result.Updated, result.Skipped)
+	return nil
+}
+
+func importClippings(f *os.File, path string) error {
+	clippings, err := importer.ParseKindleClippings(f)
+	if err != nil {
+		return fmt.Errorf("failed to read clippings: %w", err)
+	}
+
+	result, err := importer.ImportClippings(clippings)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Read %d highlights from %s: %d new quotes, %d already saved.\n",
+		len(clippings), path, result.Imported, result.Duplicates)
+	if len(result.Unmatched) > 0 {
+		fmt.Printf("\nNo matching book on your shelf for %d titles:\n", len(result.Unmatched))
+		for _, title := range result.Unmatched {
+			fmt.Printf("  %s\n", title)
+		}
+		fmt.Println("\nAdd them with 'bookshelf add' and import again.")
+	}
+	return nil
+}
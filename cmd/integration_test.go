@@ -1,6 +1,11 @@
 package cmd_test
 
 import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bookshelf/internal/testutil"
+	"database/sql"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -47,6 +52,77 @@ func runCLI(t *testing.T, dbPath string, args ...string) (string, error) {
 	return string(output), err
 }
 
+// runCLIWithEditor executes the CLI with $EDITOR set to a shell script
+func runCLIWithEditor(t *testing.T, dbPath, script string, args ...string) (string, error) {
+	t.Helper()
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write editor script: %v", err)
+	}
+
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Env = append(os.Environ(), "BOOKSHELF_DB_PATH="+dbPath, "EDITOR="+editor)
+
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// runCLIWithInput executes the CLI with a test database, feeding input to stdin
+func runCLIWithInput(t *testing.T, dbPath, input string, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Env = append(os.Environ(), "BOOKSHELF_DB_PATH="+dbPath)
+	cmd.Stdin = strings.NewReader(input)
+
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// seedBook adds a book directly to the test database and returns its ID
+func seedBook(t *testing.T, dbPath, title, author string, pages *int) int64 {
+	t.Helper()
+
+	book := models.Book{Title: title, Author: author}
+	if pages != nil {
+		book.Pages = sql.NullInt64{Int64: int64(*pages), Valid: true}
+	}
+	return seedBookWith(t, dbPath, book)
+}
+
+// seedBookWith adds a want-to-read book with the given details to the test database
+func seedBookWith(t *testing.T, dbPath string, book models.Book) int64 {
+	t.Helper()
+
+	var err error
+	db.DB, err = sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	id, err := db.InsertBook(&book)
+	if err != nil {
+		t.Fatalf("failed to add book: %v", err)
+	}
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		t.Fatalf("failed to create reading entry: %v", err)
+	}
+	return id
+}
+
+// useFakeOpenLibrary points the CLI at a fake Open Library serving the
+// fixtures in internal/testutil for the rest of the test
+func useFakeOpenLibrary(t *testing.T) {
+	t.Helper()
+	server := testutil.NewOpenLibraryServer(t)
+	t.Setenv("BOOKSHELF_OPENLIBRARY_URL", server.URL)
+}
+
 // createTestDB creates a temporary database path
 func createTestDB(t *testing.T) (string, func()) {
 	t.Helper()
@@ -105,7 +181,7 @@ func TestShowInvalidID(t *testing.T) {
 		args   []string
 		errMsg string
 	}{
-		{"non-numeric id", []string{"show", "abc"}, "invalid book ID"},
+		{"no matching book", []string{"show", "abc"}, `no book matches "abc"`},
 		{"not found", []string{"show", "999"}, "not found"},
 	}
 
@@ -131,7 +207,7 @@ func TestStartInvalidID(t *testing.T) {
 		args   []string
 		errMsg string
 	}{
-		{"non-numeric id", []string{"start", "abc"}, "invalid book ID"},
+		{"no matching book", []string{"start", "abc"}, `no book matches "abc"`},
 		{"not found", []string{"start", "999"}, "not found"},
 	}
 
@@ -157,7 +233,7 @@ func TestFinishInvalidID(t *testing.T) {
 		args   []string
 		errMsg string
 	}{
-		{"non-numeric id", []string{"finish", "abc"}, "invalid book ID"},
+		{"no matching book", []string{"finish", "abc"}, `no book matches "abc"`},
 		{"not found", []string{"finish", "999"}, "not found"},
 	}
 
@@ -183,7 +259,7 @@ func TestRateValidation(t *testing.T) {
 		args   []string
 		errMsg string
 	}{
-		{"invalid id", []string{"rate", "abc", "5"}, "invalid book ID"},
+		{"no matching book", []string{"rate", "abc", "5"}, `no book matches "abc"`},
 		{"rating too low", []string{"rate", "1", "0"}, "between 1 and 5"},
 		{"rating too high", []string{"rate", "1", "6"}, "between 1 and 5"},
 		{"non-numeric rating", []string{"rate", "1", "abc"}, "between 1 and 5"},
@@ -212,7 +288,7 @@ func TestRemoveInvalidID(t *testing.T) {
 		args   []string
 		errMsg string
 	}{
-		{"invalid id", []string{"remove", "abc", "-f"}, "invalid book ID"},
+		{"no matching book", []string{"remove", "abc", "-f"}, `no book matches "abc"`},
 		{"not found", []string{"remove", "999", "-f"}, "not found"},
 	}
 
@@ -280,7 +356,7 @@ func TestHelpCommands(t *testing.T) {
 	defer cleanup()
 
 	// Test that help works for various commands
-	commands := []string{"list", "show", "start", "finish", "rate", "review", "stats", "publish", "remove", "search", "add", "goal", "config"}
+	commands := []string{"list", "show", "start", "finish", "rate", "review", "stats", "publish", "remove", "search", "add", "goal", "config", "session", "read", "edit", "dates", "cache"}
 
 	for _, cmd := range commands {
 		t.Run(cmd, func(t *testing.T) {
@@ -514,4 +590,1189 @@ func TestConfigValidation(t *testing.T) {
 	if err == nil {
 		t.Error("expected error for unset non-existent key")
 	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"bad email", []string{"config", "set", "contact.email", "nobody"}, "invalid email"},
+		{"bad timeout", []string{"config", "set", "http.timeout", "soon"}, "invalid HTTP timeout"},
+		{"zero timeout", []string{"config", "set", "http.timeout", "0s"}, "invalid HTTP timeout"},
+		{"bad proxy", []string{"config", "set", "http.proxy", "proxy:3128"}, "invalid proxy URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+
+	for _, args := range [][]string{
+		{"config", "set", "contact.email", "me@example.com"},
+		{"config", "set", "http.timeout", "30s"},
+		{"config", "set", "http.proxy", "http://proxy.example.com:3128"},
+	} {
+		if output, err := runCLI(t, dbPath, args...); err != nil {
+			t.Errorf("%v failed: %v\n%s", args, err, output)
+		}
+	}
+}
+
+func TestSessionValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no matching book", []string{"session", "abc", "--pages", "10"}, `no book matches "abc"`},
+		{"no amounts", []string{"session", "1"}, "provide --pages, --minutes or both"},
+		{"negative pages", []string{"session", "1", "--pages", "-5"}, "must be positive"},
+		{"bad date", []string{"session", "1", "--pages", "10", "--date", "14/03/2026"}, "invalid date"},
+		{"not found", []string{"session", "999", "--pages", "10"}, "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestReadValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no id", []string{"read"}, "provide a book"},
+		{"no matching book", []string{"read", "abc"}, `no book matches "abc"`},
+		{"not found", []string{"read", "999"}, "not found"},
+		{"nothing to resume", []string{"read", "--resume"}, "no reading timer to resume"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestReadTimer(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	pages := 300
+	seedBook(t, dbPath, "Moby Dick", "Herman Melville", &pages)
+
+	output, err := runCLIWithInput(t, dbPath, "q\n42\n", "read", "1")
+	if err != nil {
+		t.Fatalf("read failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Reading \"Moby Dick\"") {
+		t.Errorf("expected timer to start, got: %s", output)
+	}
+	if !strings.Contains(output, "Logged session: 42 pages") {
+		t.Errorf("expected session to be logged, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "show", "1")
+	if err != nil {
+		t.Fatalf("show failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Status: reading", "Progress: page 42 of 300 (14%)", "Sessions: 1"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	// The timer is cleared once the session is logged
+	output, err = runCLI(t, dbPath, "read", "--resume")
+	if err == nil || !strings.Contains(output, "no reading timer to resume") {
+		t.Errorf("expected no timer to resume, got: %s", output)
+	}
+}
+
+func TestAddManual(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "add", "--manual", "--title", "Zine Quarterly", "--author", "Local Press", "--pages", "48", "--genres", "zines,art", "--format", "Paperback")
+	if err != nil {
+		t.Fatalf("add --manual failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Added \"Zine Quarterly\" by Local Press (ID: 1)") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "show", "1")
+	if err != nil {
+		t.Fatalf("show failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Title:  Zine Quarterly", "Pages:  48", "Status: want-to-read", "Format: paperback"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	// Missing author falls back to the editor form
+	output, err = runCLIWithEditor(t, dbPath, `sed -i 's/^author: ""/author: Me/' "$1"`, "add", "--manual", "My", "Memoir")
+	if err != nil {
+		t.Fatalf("add --manual via editor failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Added \"My Memoir\" by Me") {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
+
+func TestAddManualValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no query", []string{"add"}, "requires at least 1 arg"},
+		{"manual flag without --manual", []string{"add", "gatsby", "--pages", "100"}, "--pages can only be used with --manual"},
+		{"negative pages", []string{"add", "--manual", "--title", "T", "--author", "A", "--pages", "-1"}, "pages must be positive"},
+		{"invalid format", []string{"add", "--manual", "--title", "T", "--author", "A", "--format", "scroll"}, "invalid format: scroll"},
+		{"format without --manual", []string{"add", "gatsby", "--format", "ebook"}, "--format can only be used with --manual"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestAudiobookProgress(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "add", "--manual", "--title", "Dune", "--author", "Frank Herbert", "--format", "audiobook", "--duration", "21h")
+	if err != nil {
+		t.Fatalf("add --manual failed: %v\nOutput: %s", err, output)
+	}
+	pages := 200
+	seedBook(t, dbPath, "Printed Book", "Author", &pages)
+
+	output, err = runCLI(t, dbPath, "progress", "1", "3h12m")
+	if err != nil {
+		t.Fatalf("progress failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "3h 12m of 21h (15%)") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "show", "1")
+	if err != nil {
+		t.Fatalf("show failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Status: reading", "Duration:  21h", "Progress: 3h 12m of 21h (15%)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	output, err = runCLI(t, dbPath, "progress", "2", "50")
+	if err != nil {
+		t.Fatalf("progress failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "page 50 of 200 (25%)") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"page for an audiobook", []string{"progress", "1", "120"}, "is an audiobook"},
+		{"past the end", []string{"progress", "1", "22:00"}, "past the end of the book (21h)"},
+		{"page past the end", []string{"progress", "2", "201"}, "past the end of the book (200 pages)"},
+		{"invalid time", []string{"progress", "1", "soon"}, "invalid time: soon"},
+		{"invalid duration", []string{"add", "--manual", "--title", "T", "--author", "A", "--duration", "long"}, "invalid duration: long"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestEdit(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	pages := 100
+	seedBook(t, dbPath, "The Grate Gatsby", "F. Scott Fitzgerald", &pages)
+
+	output, err := runCLIWithEditor(t, dbPath, `sed -i -e 's/Grate/Great/' -e 's/^pages: 100/pages: 180/' "$1"`, "edit", "1")
+	if err != nil {
+		t.Fatalf("edit failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Updated \"The Great Gatsby\": title, pages") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	output, _ = runCLI(t, dbPath, "show", "1")
+	if !strings.Contains(output, "Title:  The Great Gatsby") || !strings.Contains(output, "Pages:  180") {
+		t.Errorf("expected edited fields in show output, got: %s", output)
+	}
+
+	// Saving without changes is a no-op
+	output, err = runCLIWithEditor(t, dbPath, "true", "edit", "1")
+	if err != nil {
+		t.Fatalf("edit failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "No changes.") {
+		t.Errorf("expected 'No changes.', got: %s", output)
+	}
+
+	// Removing the title is rejected
+	output, err = runCLIWithEditor(t, dbPath, `sed -i 's/^title: .*/title: ""/' "$1"`, "edit", "1")
+	if err == nil || !strings.Contains(output, "title is required") {
+		t.Errorf("expected 'title is required' error, got: %s", output)
+	}
+}
+
+func TestBackdatedDates(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	output, err := runCLI(t, dbPath, "start", "1", "--date", "2025-06-01")
+	if err != nil {
+		t.Fatalf("start --date failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "on Jun 01, 2025") {
+		t.Errorf("expected start date in output, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "finish", "1", "--date", "2025-05-01")
+	if err == nil || !strings.Contains(output, "is after finish date") {
+		t.Errorf("expected finish before start to fail, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "finish", "1", "--date", "2025-06-20")
+	if err != nil {
+		t.Fatalf("finish --date failed: %v\nOutput: %s", err, output)
+	}
+
+	output, err = runCLI(t, dbPath, "goal", "set", "2025", "10")
+	if err != nil {
+		t.Fatalf("goal set failed: %v\nOutput: %s", err, output)
+	}
+	output, _ = runCLI(t, dbPath, "goal", "show", "2025")
+	if !strings.Contains(output, "1/10 books") {
+		t.Errorf("expected backdated book to count toward 2025 goal, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "dates", "1", "--started", "2025-05")
+	if err != nil {
+		t.Fatalf("dates failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Started:  May 01, 2025", "Finished: Jun 20, 2025"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	output, err = runCLI(t, dbPath, "dates", "1", "--started", "2025-07-01")
+	if err == nil || !strings.Contains(output, "is after finish date") {
+		t.Errorf("expected start after finish to fail, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "start", "1", "--date", "2025-07-01")
+	if err == nil || !strings.Contains(output, "is after finish date") {
+		t.Errorf("expected start --date after finish to fail, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "dates", "1", "--clear-started")
+	if err != nil || !strings.Contains(output, "Started:  -") {
+		t.Errorf("expected start date cleared, got: %s", output)
+	}
+}
+
+func TestStartAndFinishSameDay(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	if output, err := runCLI(t, dbPath, "start", "1"); err != nil {
+		t.Fatalf("start failed: %v\nOutput: %s", err, output)
+	}
+	output, err := runCLI(t, dbPath, "dates", "1", "--finished", "today")
+	if err != nil {
+		t.Fatalf("dates --finished today failed: %v\nOutput: %s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "finish", "1", "--date", "today")
+	if err != nil {
+		t.Fatalf("finish --date today failed: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestDateValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"unparseable", []string{"start", "1", "--date", "someday"}, "invalid date"},
+		{"future", []string{"finish", "1", "--date", "2999-01-01"}, "in the future"},
+		{"date and no-date", []string{"start", "1", "--date", "today", "--no-date"}, "cannot be used together"},
+		{"conflicting clear", []string{"dates", "1", "--started", "today", "--clear-started"}, "cannot be used together"},
+		{"log bad date", []string{"log", "dune", "--date", "someday"}, "invalid date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestRatingScales(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	output, err := runCLI(t, dbPath, "rate", "1", "4.5")
+	if err == nil || !strings.Contains(output, "between 1 and 5") {
+		t.Errorf("expected half stars rejected on default scale, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "config", "set", "rating.scale", "percent")
+	if err == nil || !strings.Contains(output, "invalid rating scale") {
+		t.Errorf("expected invalid scale error, got: %s", output)
+	}
+
+	if output, err := runCLI(t, dbPath, "config", "set", "rating.scale", "half-stars"); err != nil {
+		t.Fatalf("config set failed: %v\nOutput: %s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "rate", "1", "4.5")
+	if err != nil {
+		t.Fatalf("rate failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "4.5/5") {
+		t.Errorf("expected '4.5/5' in output, got: %s", output)
+	}
+
+	// The stored rating is converted when the scale changes
+	if output, err := runCLI(t, dbPath, "config", "set", "rating.scale", "10-point"); err != nil {
+		t.Fatalf("config set failed: %v\nOutput: %s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "show", "1")
+	if err != nil || !strings.Contains(output, "9/10") {
+		t.Errorf("expected '9/10' in show output, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "rate", "1", "7")
+	if err != nil || !strings.Contains(output, "7/10") {
+		t.Errorf("expected '7/10' in rate output, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "finish", "1")
+	if err != nil || !strings.Contains(output, "bookshelf rate 1 <rating> (between 1 and 10)") {
+		t.Errorf("expected the finish hint on the 10-point scale, got: %s", output)
+	}
+}
+
+func TestProviderValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "search", "dune", "--provider", "amazon")
+	if err == nil || !strings.Contains(output, "unknown metadata provider") {
+		t.Errorf("expected unknown provider error, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "config", "set", "metadata.providers", "openlibrary,amazon")
+	if err == nil || !strings.Contains(output, "unknown metadata provider") {
+		t.Errorf("expected config validation error, got: %s", output)
+	}
+
+	if output, err := runCLI(t, dbPath, "config", "set", "metadata.providers", "google,openlibrary"); err != nil {
+		t.Fatalf("config set failed: %v\nOutput: %s", err, output)
+	}
+}
+
+func TestOfflineAndCache(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "search", "dune", "--offline")
+	if err == nil || !strings.Contains(output, "not in the offline cache") {
+		t.Errorf("expected offline cache miss, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "cache", "stats")
+	if err != nil {
+		t.Fatalf("cache stats failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Entries:  0") || !strings.Contains(output, "TTL:      1d") {
+		t.Errorf("expected empty cache with default TTL, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "config", "set", "cache.ttl", "soon")
+	if err == nil || !strings.Contains(output, "invalid cache TTL") {
+		t.Errorf("expected invalid TTL error, got: %s", output)
+	}
+	runCLI(t, dbPath, "config", "set", "cache.ttl", "7d")
+	output, _ = runCLI(t, dbPath, "cache", "stats")
+	if !strings.Contains(output, "TTL:      7d") {
+		t.Errorf("expected configured TTL, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "cache", "clear")
+	if err != nil || !strings.Contains(output, "Removed 0 cached responses") {
+		t.Errorf("expected cache clear to succeed, got: %s", output)
+	}
+}
+
+func TestRefreshValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "refresh", "--concurrency", "0")
+	if err == nil || !strings.Contains(output, "--concurrency must be at least 1") {
+		t.Errorf("expected concurrency error, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "refresh")
+	if err != nil || !strings.Contains(output, "No books with Open Library keys or ISBNs found") {
+		t.Errorf("expected nothing to refresh, got: %s", output)
+	}
+
+	seedBook(t, dbPath, "Zine", "Local Press", nil)
+	output, err = runCLI(t, dbPath, "refresh", "1")
+	if err == nil || !strings.Contains(output, "no Open Library key or ISBN") {
+		t.Errorf("expected missing key error, got: %s", output)
+	}
+}
+
+func TestRefreshFieldsValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "refresh", "--fields", "pages,title")
+	if err == nil || !strings.Contains(output, "unknown field: title") {
+		t.Errorf("expected unknown field error, got: %s", output)
+	}
+}
+
+func TestAuthorValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no name", []string{"author"}, "requires at least 1 arg"},
+		{"zero limit", []string{"author", "Frank Herbert", "--limit", "0"}, "--limit must be at least 1"},
+		{"offline and never looked up", []string{"author", "Frank Herbert", "--offline"}, "not in the offline cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestSearchValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no query or filters", []string{"search"}, "give a query or at least one of --title"},
+		{"invalid sort", []string{"search", "dune", "--sort", "random"}, "invalid sort: random"},
+		{"page zero", []string{"search", "dune", "--page", "0"}, "--page must be at least 1"},
+		{"add without query or filters", []string{"add"}, "requires at least 1 arg"},
+		{"add filter with --manual", []string{"add", "--manual", "--title", "T", "--author", "A", "--subject", "zines"}, "--subject can't be used with --manual"},
+		{"filters only are searched", []string{"search", "--author", "herbert", "--offline"}, "not in the offline cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestSearchAgainstFakeOpenLibrary(t *testing.T) {
+	useFakeOpenLibrary(t)
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "search", "dune")
+	if err != nil {
+		t.Fatalf("search failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Dune Messiah", "Frank Herbert", "1965", "98", "Science fiction", "Found 2 results on page 1."} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	output, err = runCLI(t, dbPath, "search", "nothing like this")
+	if err != nil || !strings.Contains(output, "No books found.") {
+		t.Errorf("expected no results, got: %s", output)
+	}
+}
+
+func TestAddAgainstFakeOpenLibrary(t *testing.T) {
+	useFakeOpenLibrary(t)
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// Pick Dune, then its 1965 hardcover
+	output, err := runCLIWithInput(t, dbPath, "1\n2\n", "add", "dune")
+	if err != nil {
+		t.Fatalf("add failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"1. Dune by Frank Herbert (1965)", "Editions (2):", "2. hardcover, Chilton Books, 1965, 412 pages", "Added \"Dune\" by Frank Herbert (ID: 1)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	output, err = runCLI(t, dbPath, "show", "1")
+	if err != nil {
+		t.Fatalf("show failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"ISBN:   0801950775", "Pages:  412", "Format: hardcover", "Publisher: Chilton Books", "Edition:   /books/OL7353617M", "desert planet Arrakis"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in show output, got: %s", expected, output)
+		}
+	}
+
+	// Cancelling adds nothing
+	output, _ = runCLIWithInput(t, dbPath, "0\n", "add", "dune")
+	if !strings.Contains(output, "Cancelled.") {
+		t.Errorf("expected cancel, got: %s", output)
+	}
+	output, _ = runCLI(t, dbPath, "list")
+	if strings.Count(output, "Dune") != 1 {
+		t.Errorf("expected one book on the shelf, got: %s", output)
+	}
+}
+
+func TestLogAgainstFakeOpenLibrary(t *testing.T) {
+	useFakeOpenLibrary(t)
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLIWithInput(t, dbPath, "2\n", "log", "dune", "--rating", "4", "--date", "2025-06-15")
+	if err != nil {
+		t.Fatalf("log failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Logged \"Dune Messiah\" by Frank Herbert as read (ID: 1)", "Finished: Jun 15, 2025", "Rated: "} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	output, err = runCLI(t, dbPath, "show", "1")
+	if err != nil {
+		t.Fatalf("show failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Status: finished", "Finished: Jun 15, 2025", "Muad'Dib"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in show output, got: %s", expected, output)
+		}
+	}
+}
+
+func TestRefreshAgainstFakeOpenLibrary(t *testing.T) {
+	useFakeOpenLibrary(t)
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	// Known only by ISBN, with a wrong page count
+	seedBookWith(t, dbPath, models.Book{
+		Title:  "Dune",
+		Author: "Frank Herbert",
+		ISBN:   sql.NullString{String: "9780441013593", Valid: true},
+		Pages:  sql.NullInt64{Int64: 500, Valid: true},
+	})
+	seedBook(t, dbPath, "Unknown Zine", "Nobody", nil)
+
+	output, err := runCLI(t, dbPath, "refresh")
+	if err != nil {
+		t.Fatalf("refresh failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "Done: 1 refreshed, 0 unchanged, 0 skipped, 0 failed") {
+		t.Errorf("unexpected output: %s", output)
+	}
+
+	output, _ = runCLI(t, dbPath, "show", "1")
+	if !strings.Contains(output, "Pages:  500") || !strings.Contains(output, "desert planet Arrakis") {
+		t.Errorf("expected gaps filled and pages kept, got: %s", output)
+	}
+
+	// Declining the overwrite keeps the page count; accepting replaces it
+	output, err = runCLIWithInput(t, dbPath, "n\n", "refresh", "1", "--force", "--fields", "pages")
+	if err != nil || !strings.Contains(output, "+ 528") || !strings.Contains(output, "Skipped.") {
+		t.Errorf("expected declined overwrite, got: %s", output)
+	}
+	output, err = runCLIWithInput(t, dbPath, "y\n", "refresh", "1", "--force", "--fields", "pages")
+	if err != nil || !strings.Contains(output, "Refreshed \"Dune\"") {
+		t.Errorf("expected accepted overwrite, got: %s", output)
+	}
+	output, _ = runCLI(t, dbPath, "show", "1")
+	if !strings.Contains(output, "Pages:  528") {
+		t.Errorf("expected page count replaced, got: %s", output)
+	}
+}
+
+func TestAuthorAgainstFakeOpenLibrary(t *testing.T) {
+	useFakeOpenLibrary(t)
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBookWith(t, dbPath, models.Book{
+		Title:          "Dune",
+		Author:         "Frank Herbert",
+		OpenLibraryKey: sql.NullString{String: "/works/OL893415W", Valid: true},
+	})
+
+	// Add Dune Messiah, its only edition is picked automatically
+	output, err := runCLIWithInput(t, dbPath, "1\n", "author", "Frank Herbert")
+	if err != nil {
+		t.Fatalf("author failed: %v\nOutput: %s", err, output)
+	}
+	for _, expected := range []string{"Frank Herbert (8 October 1920 - 11 February 1986)", "best known for the novel Dune",
+		"[1] Dune - want-to-read", "Not on your shelf (2):", "1. Dune Messiah (1969)", "2. Children of Dune (1976)", "Added \"Dune Messiah\" by Frank Herbert (ID: 2)"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected '%s' in output, got: %s", expected, output)
+		}
+	}
+
+	// The saved details are used when offline and the cache can't help
+	runCLI(t, dbPath, "cache", "clear")
+	output, err = runCLIWithInput(t, dbPath, "\n", "author", "herbert", "--offline")
+	if err != nil {
+		t.Fatalf("offline author failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(output, "showing saved details") || !strings.Contains(output, "Not on your shelf (1):") {
+		t.Errorf("expected saved author details, got: %s", output)
+	}
+}
+
+func TestImportStoryGraph(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	export := filepath.Join("..", "internal", "importer", "testdata", "storygraph.csv")
+	output, err := runCLI(t, dbPath, "import", "storygraph", export)
+	if err != nil {
+		t.Fatalf("import failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "4 created, 0 updated, 0 skipped") {
+		t.Errorf("expected import counts, got: %s", output)
+	}
+
+	output, _ = runCLI(t, dbPath, "list", "--status", "did-not-finish")
+	if !strings.Contains(output, "Infinite Jest") {
+		t.Errorf("expected the DNF book, got: %s", output)
+	}
+
+	output, _ = runCLI(t, dbPath, "show", "1")
+	if !strings.Contains(output, "Tags:      sci-fi, classics") || !strings.Contains(output, "Rating: 4.5/5") {
+		t.Errorf("expected tags and rating, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "import", "storygraph", export)
+	if err != nil {
+		t.Fatalf("second import failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "0 created, 0 updated, 4 skipped") {
+		t.Errorf("expected everything skipped, got: %s", output)
+	}
+}
+
+func TestImportValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no args", []string{"import"}, "accepts 2 arg(s)"},
+		{"unknown format", []string{"import", "goodreads", "export.csv"}, "unknown import format: goodreads"},
+		{"missing file", []string{"import", "storygraph", "missing.csv"}, "failed to open export"},
+		{"wrong format", []string{"import", "storygraph", filepath.Join("..", "internal", "importer", "testdata", "librarything.tsv")}, "not a StoryGraph export"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	pages := 180
+	seedBook(t, dbPath, "The Great Gatsby", "F. Scott Fitzgerald", &pages)
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+	runCLI(t, dbPath, "finish", "1", "--date", "2024-03-09")
+	runCLI(t, dbPath, "rate", "1", "4")
+
+	output, err := runCLI(t, dbPath, "export", "--columns", "id,title,status,rating,finished_at,pages", "--sort", "title")
+	if err != nil {
+		t.Fatalf("export failed: %v\n%s", err, output)
+	}
+	expected := "id,title,status,rating,finished_at,pages\n2,Dune,want-to-read,,,\n1,The Great Gatsby,finished,4,2024-03-09,180\n"
+	if output != expected {
+		t.Errorf("unexpected export:\n%s\nexpected:\n%s", output, expected)
+	}
+
+	outFile := filepath.Join(t.TempDir(), "goodreads.csv")
+	output, err = runCLI(t, dbPath, "export", "--profile", "goodreads", "--status", "finished", "--output", outFile)
+	if err != nil {
+		t.Fatalf("goodreads export failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Exported 1 books to") {
+		t.Errorf("expected a summary, got: %s", output)
+	}
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if !strings.Contains(string(data), "The Great Gatsby,F. Scott Fitzgerald,,,4,") || strings.Contains(string(data), "Dune") {
+		t.Errorf("unexpected Goodreads export:\n%s", data)
+	}
+}
+
+func TestExportValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"bad format", []string{"export", "--format", "xlsx"}, "invalid format: xlsx"},
+		{"bad column", []string{"export", "--columns", "title,colour"}, "unknown column: colour"},
+		{"bad profile", []string{"export", "--profile", "storygraph"}, "invalid profile"},
+		{"profile and columns", []string{"export", "--profile", "goodreads", "--columns", "title"}, "--columns can't be used with --profile"},
+		{"bad status", []string{"export", "--status", "done"}, "invalid status"},
+		{"markdown without dir", []string{"export", "--format", "markdown"}, "--format markdown needs --dir"},
+		{"markdown with columns", []string{"export", "--format", "markdown", "--dir", "notes", "--columns", "title"}, "--columns can only be used with --format csv"},
+		{"dir with csv", []string{"export", "--dir", "notes"}, "--dir can only be used with --format markdown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+	runCLIWithEditor(t, dbPath, `printf "Loved it.\n" > "$1"`, "review", "1")
+	dir := filepath.Join(t.TempDir(), "Books")
+
+	output, err := runCLI(t, dbPath, "export", "--format", "markdown", "--dir", dir)
+	if err != nil {
+		t.Fatalf("export failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "1 new, 0 updated, 0 unchanged") {
+		t.Errorf("expected export counts, got: %s", output)
+	}
+
+	note := filepath.Join(dir, "Dune.md")
+	data, err := os.ReadFile(note)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	if !strings.Contains(string(data), "title: Dune\n") || !strings.Contains(string(data), "## Review\n\nLoved it.") {
+		t.Errorf("unexpected note:\n%s", data)
+	}
+
+	os.WriteFile(note, append(data, []byte("\nMy own notes.\n")...), 0644)
+	runCLI(t, dbPath, "start", "1")
+	output, err = runCLI(t, dbPath, "export", "--format", "markdown", "--dir", dir)
+	if err != nil {
+		t.Fatalf("second export failed: %v\n%s", err, output)
+	}
+	data, _ = os.ReadFile(note)
+	if !strings.Contains(string(data), "status: reading\n") || !strings.Contains(string(data), "My own notes.") {
+		t.Errorf("expected updated front matter and kept notes:\n%s", data)
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	pages := 412
+	seedBook(t, dbPath, "Dune", "Frank Herbert", &pages)
+
+	output, _ := runCLI(t, dbPath, "quotes", "1")
+	if !strings.Contains(output, "No quotes from \"Dune\" yet") {
+		t.Errorf("expected no quotes, got: %s", output)
+	}
+
+	output, err := runCLI(t, dbPath, "quote", "add", "1", "I must not fear.", "--page", "8", "--note", "The litany")
+	if err != nil {
+		t.Fatalf("quote add failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Saved quote from \"Dune\"") {
+		t.Errorf("expected confirmation, got: %s", output)
+	}
+
+	output, err = runCLIWithEditor(t, dbPath, `printf "# ignored\nThe spice must flow.\n" > "$1"`, "quote", "add", "1", "--location", "2010")
+	if err != nil {
+		t.Fatalf("quote add with editor failed: %v\n%s", err, output)
+	}
+
+	output, _ = runCLI(t, dbPath, "quotes", "1")
+	for _, expected := range []string{"Quotes from \"Dune\" (2)", "\"I must not fear.\"", "page 8", "Note: The litany", "\"The spice must flow.\"", "location 2010"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output, got: %s", expected, output)
+		}
+	}
+	if strings.Contains(output, "ignored") {
+		t.Errorf("expected comment lines to be dropped, got: %s", output)
+	}
+}
+
+func TestQuoteValidation(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	pages := 412
+	seedBook(t, dbPath, "Dune", "Frank Herbert", &pages)
+
+	tests := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no args", []string{"quote", "add"}, "requires at least 1 arg(s)"},
+		{"no matching book", []string{"quote", "add", "abc", "text"}, `no book matches "abc"`},
+		{"missing book", []string{"quote", "add", "99", "text"}, "book with ID 99 not found"},
+		{"negative page", []string{"quote", "add", "1", "text", "--page=-3"}, "page must be positive"},
+		{"page past the end", []string{"quote", "add", "1", "text", "--page", "500"}, "page 500 is past the end of the book (412 pages)"},
+		{"quotes missing book", []string{"quotes", "99"}, "book with ID 99 not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, dbPath, tt.args...)
+			if err == nil {
+				t.Errorf("expected error, got success")
+			}
+			if !strings.Contains(output, tt.errMsg) {
+				t.Errorf("expected '%s' in output, got: %s", tt.errMsg, output)
+			}
+		})
+	}
+}
+
+func TestImportKindle(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	clippings := filepath.Join("..", "internal", "importer", "testdata", "My Clippings.txt")
+	output, err := runCLI(t, dbPath, "import", "kindle", clippings)
+	if err != nil {
+		t.Fatalf("import failed: %v\n%s", err, output)
+	}
+	for _, expected := range []string{"Read 3 highlights", "1 new quotes, 0 already saved", "No matching book on your shelf for 2 titles", "Piranesi"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output, got: %s", expected, output)
+		}
+	}
+
+	output, _ = runCLI(t, dbPath, "quotes", "1")
+	if !strings.Contains(output, "Fear is the mind-killer.") || !strings.Contains(output, "Note: The litany against fear") {
+		t.Errorf("expected the imported quote, got: %s", output)
+	}
+
+	output, _ = runCLI(t, dbPath, "import", "kindle", clippings)
+	if !strings.Contains(output, "0 new quotes, 1 already saved") {
+		t.Errorf("expected the quote to be skipped, got: %s", output)
+	}
+}
+
+func TestImportAndSyncCalibre(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "sync", "calibre")
+	if err == nil || !strings.Contains(output, "no Calibre library to sync") {
+		t.Errorf("expected an error before any import, got: %s", output)
+	}
+
+	library := filepath.Join("..", "internal", "importer", "testdata", "calibre")
+	output, err = runCLI(t, dbPath, "import", "calibre", library)
+	if err != nil {
+		t.Fatalf("import failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Imported 3 books") || !strings.Contains(output, "3 created, 0 updated, 0 skipped") {
+		t.Errorf("expected import counts, got: %s", output)
+	}
+
+	output, _ = runCLI(t, dbPath, "show", "1")
+	for _, expected := range []string{"Title:  Dune", "Series:    Dune #1", "Format: ebook", "Tags:      Science Fiction, Classics"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output, got: %s", expected, output)
+		}
+	}
+
+	// Sync remembers the library and adds nothing twice
+	output, err = runCLI(t, dbPath, "sync", "calibre")
+	if err != nil {
+		t.Fatalf("sync failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "Synced 3 books") || !strings.Contains(output, "0 created, 0 updated, 3 skipped") {
+		t.Errorf("expected nothing new, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "import", "calibre", filepath.Join(library, "metadata.db"))
+	if err == nil || !strings.Contains(output, "is not a folder") {
+		t.Errorf("expected an error for a file, got: %s", output)
+	}
+}
+
+func TestPublishOPDS(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	outputDir := t.TempDir()
+	output, err := runCLI(t, dbPath, "publish", "--output", outputDir, "--opds")
+	if err != nil {
+		t.Fatalf("publish failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "opds/ (OPDS catalog") {
+		t.Errorf("expected the catalog to be listed, got: %s", output)
+	}
+
+	feed, err := os.ReadFile(filepath.Join(outputDir, "opds", "want-to-read.xml"))
+	if err != nil {
+		t.Fatalf("expected a want-to-read feed: %v", err)
+	}
+	if !strings.Contains(string(feed), "<title>Dune</title>") {
+		t.Errorf("expected the book in the feed, got: %s", feed)
+	}
+}
+
+func TestAPIToken(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "api", "serve", "--addr", ":0")
+	if err == nil || !strings.Contains(output, "refusing to listen on :0 without an API token") {
+		t.Errorf("expected serving every interface without a token to be refused, got: %v\n%s", err, output)
+	}
+
+	output, err = runCLI(t, dbPath, "api", "token")
+	if err != nil {
+		t.Fatalf("api token failed: %v\n%s", err, output)
+	}
+	token := strings.TrimSpace(output)
+	if len(token) != 64 {
+		t.Fatalf("expected a 64 character token, got %q", token)
+	}
+
+	output, _ = runCLI(t, dbPath, "config", "get", "api.token")
+	if !strings.Contains(output, token) {
+		t.Errorf("expected the token to be saved, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "api", "serve", "--addr", "not an address")
+	if err == nil || !strings.Contains(output, "failed to listen on not an address") {
+		t.Errorf("expected a listen error, got: %v\n%s", err, output)
+	}
+}
+
+func TestUIListenError(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	output, err := runCLI(t, dbPath, "ui", "--addr", "not an address")
+	if err == nil || !strings.Contains(output, "failed to listen on not an address") {
+		t.Errorf("expected a listen error, got: %v\n%s", err, output)
+	}
+}
+
+func TestBookReferences(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	gatsby := seedBookWith(t, dbPath, models.Book{
+		Title:  "The Great Gatsby",
+		Author: "F. Scott Fitzgerald",
+		ISBN:   sql.NullString{String: "978-0-7432-7356-5", Valid: true},
+	})
+	emma := seedBook(t, dbPath, "Emma", "Jane Austen", nil)
+	persuasion := seedBook(t, dbPath, "Persuasion", "Jane Austen", nil)
+
+	output, err := runCLI(t, dbPath, "finish", "gatsby")
+	if err != nil || !strings.Contains(output, `Finished reading "The Great Gatsby"`) {
+		t.Errorf("finish by title failed: %v\n%s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "show", "0743273567")
+	if err != nil || !strings.Contains(output, "Title:  The Great Gatsby") {
+		t.Errorf("show by ISBN-10 failed: %v\n%s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "rate", "FITZGERALD", "4")
+	if err != nil || !strings.Contains(output, `Rated "The Great Gatsby"`) {
+		t.Errorf("rate by author failed: %v\n%s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "show", fmt.Sprint(gatsby))
+	if err != nil || !strings.Contains(output, "Rating: 4/5") {
+		t.Errorf("show by ID failed: %v\n%s", err, output)
+	}
+
+	// Ambiguous references fail without input, listing the matches.
+	output, err = runCLI(t, dbPath, "--no-input", "start", "austen")
+	if err == nil || !strings.Contains(output, `"austen" matches 2 books`) ||
+		!strings.Contains(output, fmt.Sprintf("%d  \"Emma\"", emma)) || strings.Contains(output, "Which one") {
+		t.Errorf("expected an ambiguous reference error, got: %v\n%s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "start", "austen")
+	if err == nil || !strings.Contains(output, `"austen" matches 2 books`) {
+		t.Errorf("expected no answer to fail, got: %v\n%s", err, output)
+	}
+
+	// Picking a book then confirming its removal share stdin.
+	output, err = runCLIWithInput(t, dbPath, "2\ny\n", "remove", "austen")
+	if err != nil || !strings.Contains(output, `Removed "Persuasion"`) {
+		t.Errorf("remove after choosing failed: %v\n%s", err, output)
+	}
+	output, _ = runCLI(t, dbPath, "show", fmt.Sprint(persuasion))
+	if !strings.Contains(output, fmt.Sprintf("book with ID %d not found", persuasion)) {
+		t.Errorf("expected Persuasion to be removed, got: %s", output)
+	}
+
+	output, err = runCLI(t, dbPath, "__complete", "show", "em")
+	if err != nil || !strings.Contains(output, "Emma\tby Jane Austen") || strings.Contains(output, "Gatsby") {
+		t.Errorf("expected Emma to complete, got: %v\n%s", err, output)
+	}
+}
+
+func TestNoInput(t *testing.T) {
+	dbPath, cleanup := createTestDB(t)
+	defer cleanup()
+
+	seedBook(t, dbPath, "Dune", "Frank Herbert", nil)
+
+	output, err := runCLIWithInput(t, dbPath, "y\n", "--no-input", "remove", "dune")
+	if err == nil || !strings.Contains(output, "use --force with --no-input") || strings.Contains(output, "[y/N]") {
+		t.Errorf("expected remove to fail without asking, got: %v\n%s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "--no-input", "refresh", "dune", "--force")
+	if err == nil || !strings.Contains(output, "use --yes with --no-input") {
+		t.Errorf("expected refresh --force to need --yes, got: %v\n%s", err, output)
+	}
+	output, err = runCLI(t, dbPath, "--no-input", "review", "dune")
+	if err == nil || !strings.Contains(output, "can't open an editor with --no-input") {
+		t.Errorf("expected review not to open an editor, got: %v\n%s", err, output)
+	}
+
+	output, err = runCLI(t, dbPath, "--no-input", "remove", "dune", "--force")
+	if err != nil || !strings.Contains(output, `Removed "Dune"`) {
+		t.Errorf("remove --force failed: %v\n%s", err, output)
+	}
 }
@@ -23,35 +23,15 @@ var listCmd = &cobra.Command{
 }
 
 func init() {
-	listCmd.Flags().StringVarP(&listStatus, "status", "s", "", "Filter by status (want-to-read, reading, finished)")
+	listCmd.Flags().StringVarP(&listStatus, "status", "s", "", "Filter by status (want-to-read, reading, finished, did-not-finish)")
 	listCmd.Flags().StringVarP(&listSearch, "search", "q", "", "Search by title or author")
 	listCmd.Flags().StringVarP(&listSort, "sort", "o", "added", "Sort by: added, title, author, rating")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	opts := models.ListOptions{}
-
-	if listStatus != "" {
-		status := models.BookStatus(listStatus)
-		if status != models.StatusWantToRead && status != models.StatusReading && status != models.StatusFinished {
-			return fmt.Errorf("invalid status: %s (use: want-to-read, reading, finished)", listStatus)
-		}
-		opts.StatusFilter = &status
-	}
-
-	opts.SearchQuery = listSearch
-
-	switch listSort {
-	case "added", "":
-		opts.SortBy = models.SortByAdded
-	case "title":
-		opts.SortBy = models.SortByTitle
-	case "author":
-		opts.SortBy = models.SortByAuthor
-	case "rating":
-		opts.SortBy = models.SortByRating
-	default:
-		return fmt.Errorf("invalid sort option: %s (use: added, title, author, rating)", listSort)
+	opts, err := models.ParseListOptions(listStatus, listSearch, listSort)
+	if err != nil {
+		return err
 	}
 
 	books, err := db.ListBooks(opts)
@@ -68,13 +48,18 @@ func runList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	scale, err := db.GetRatingScale()
+	if err != nil {
+		return fmt.Errorf("failed to get rating scale: %w", err)
+	}
+
 	table := tablewriter.NewTable(os.Stdout)
 	table.Header("ID", "Title", "Author", "Status", "Rating")
 
 	for _, book := range books {
 		rating := "-"
 		if book.ReadingEntry.Rating.Valid {
-			rating = fmt.Sprintf("%d/5", book.ReadingEntry.Rating.Int64)
+			rating = scale.Format(book.ReadingEntry.Rating.Float64)
 		}
 
 		title := book.Book.Title
@@ -4,44 +4,68 @@ import (
 	"bookshelf/internal/api"
 	"bookshelf/internal/db"
 	"bookshelf/internal/models"
-	"bufio"
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-var logRating int
+var logRating string
+var logDate string
 
 var logCmd = &cobra.Command{
 	Use:   "log [title]",
 	Short: "Log a book you've already read",
 	Long: `Search for a book and add it as already finished. Use this for books
-you read in the past where you don't know the exact start/finish dates.
+you read in the past where you don't know the exact start/finish dates. As
+with add, you then pick the edition you read; --any-edition skips this.
 
-Optionally provide a rating with --rating.`,
+Optionally provide a rating with --rating, and the finish date with --date
+(e.g. --date 2025-06 or --date "last tuesday") so it counts toward that year.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runLog,
 }
 
 func init() {
-	logCmd.Flags().IntVarP(&logRating, "rating", "r", 0, "Rating from 1-5 stars")
+	logCmd.Flags().StringVarP(&logRating, "rating", "r", "", "Rating on your configured scale (default 1-5 stars)")
+	addProviderFlag(logCmd)
+	logCmd.Flags().BoolVar(&addAnyEdition, "any-edition", false, "Skip the edition picker and use the work's details")
+	logCmd.Flags().StringVarP(&logDate, "date", "d", "", "Finish date, exact or fuzzy (e.g. 2025-06, \"last tuesday\")")
 }
 
 func runLog(cmd *cobra.Command, args []string) error {
-	if logRating != 0 && (logRating < 1 || logRating > 5) {
-		return fmt.Errorf("rating must be between 1 and 5")
+	scale, err := db.GetRatingScale()
+	if err != nil {
+		return fmt.Errorf("failed to get rating scale: %w", err)
+	}
+
+	var rating float64
+	if logRating != "" {
+		if rating, err = scale.Parse(logRating); err != nil {
+			return err
+		}
+	}
+
+	var finishedAt *time.Time
+	if logDate != "" {
+		date, err := parseDateFlag(logDate)
+		if err != nil {
+			return err
+		}
+		finishedAt = &date
 	}
 
 	query := strings.Join(args, " ")
-	client := api.NewClient()
+	client, err := metadataProvider()
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("Searching for \"%s\"...\n\n", query)
 
-	docs, err := client.Search(query, 5)
+	docs, err := client.Search(cmd.Context(), api.SearchQuery{Text: query, Limit: 5})
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
@@ -61,9 +85,10 @@ func runLog(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Print("\nSelect a book (1-5) or 0 to cancel: ")
-	reader := bufio.NewReader(os.Stdin)
-	input, _ := reader.ReadString('\n')
-	input = strings.TrimSpace(input)
+	input, err := readLine(cmd.Context(), stdin)
+	if err != nil {
+		return err
+	}
 
 	choice, err := strconv.Atoi(input)
 	if err != nil || choice < 0 || choice > len(docs) {
@@ -76,55 +101,34 @@ func runLog(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	selected := docs[choice-1]
-
-	// Fetch additional details if available
-	var description *string
-	var genres *string
-	if selected.Key != "" {
-		work, err := client.GetWorkDetails(selected.Key)
-		if err == nil {
-			description = work.DescriptionText()
-			if subjects := work.TopSubjects(5); len(subjects) > 0 {
-				if jsonBytes, err := json.Marshal(subjects); err == nil {
-					jsonStr := string(jsonBytes)
-					genres = &jsonStr
-				}
-			}
-		}
-	}
-
-	bookID, err := db.AddBook(
-		selected.Title,
-		selected.Author(),
-		selected.FirstISBN(),
-		selected.CoverURL(),
-		description,
-		&selected.Key,
-		genres,
-		selected.Pages(),
-	)
+	book, err := insertSearchResult(cmd.Context(), client, stdin, docs[choice-1], models.StatusFinished)
 	if err != nil {
-		return fmt.Errorf("failed to add book: %w", err)
+		return err
 	}
+	bookID := book.ID
 
-	// Create entry as finished with no date
-	if err := db.CreateReadingEntry(bookID, models.StatusFinished); err != nil {
-		return fmt.Errorf("failed to create reading entry: %w", err)
+	// Dated only if --date was given
+	if finishedAt != nil {
+		if err := db.UpdateStatusOn(bookID, models.StatusFinished, finishedAt); err != nil {
+			return fmt.Errorf("failed to set finish date: %w", err)
+		}
 	}
 
 	// Apply rating if provided
-	if logRating > 0 {
-		if err := db.UpdateRating(bookID, logRating); err != nil {
+	if rating > 0 {
+		if err := db.UpdateRating(bookID, rating); err != nil {
 			return fmt.Errorf("failed to set rating: %w", err)
 		}
 	}
 
-	fmt.Printf("\nLogged \"%s\" by %s as read (ID: %d)\n", selected.Title, selected.Author(), bookID)
-	if logRating > 0 {
-		fmt.Printf("Rated: %s\n", strings.Repeat("*", logRating))
+	fmt.Printf("\nLogged \"%s\" by %s as read (ID: %d)\n", book.Title, book.Author, bookID)
+	if finishedAt != nil {
+		fmt.Printf("Finished: %s\n", finishedAt.Format("Jan 02, 2006"))
+	}
+	if rating > 0 {
+		fmt.Printf("Rated: %s\n", describeRating(scale, rating))
 	} else {
-		fmt.Printf("Don't forget to rate it with: bookshelf rate %d <1-5>\n", bookID)
+		fmt.Printf("Don't forget to rate it with: bookshelf rate %d <rating>\n", bookID)
 	}
 	return nil
 }
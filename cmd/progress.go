@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var progressCmd = &cobra.Command{
+	Use:   "progress [book] [page|time]",
+	Short: "Record how far into a book you are",
+	Long: `Record your position in a book: a page number, or for audiobooks the time
+listened so far, e.g. 3h12m, 45m or 3:12. A book you haven't started is moved
+to "reading".`,
+	Args: cobra.ExactArgs(2),
+	RunE: runProgress,
+}
+
+func runProgress(cmd *cobra.Command, args []string) error {
+	book, err := resolveBook(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	id := book.Book.ID
+
+	page, pageErr := strconv.Atoi(args[1])
+	if pageErr == nil && book.Book.IsAudiobook() {
+		return fmt.Errorf("\"%s\" is an audiobook: give the time listened, e.g. 3h12m", book.Book.Title)
+	}
+
+	var progress string
+	var atEnd bool
+	if pageErr == nil {
+		if page < 0 {
+			return fmt.Errorf("page must be positive")
+		}
+		if book.Book.Pages.Valid && int64(page) > book.Book.Pages.Int64 {
+			return fmt.Errorf("page %d is past the end of the book (%d pages)", page, book.Book.Pages.Int64)
+		}
+		if err := db.UpdateProgress(id, page); err != nil {
+			return fmt.Errorf("failed to update progress: %w", err)
+		}
+		progress = describePageProgress(int64(page), book.Book.Pages)
+		atEnd = book.Book.Pages.Valid && int64(page) == book.Book.Pages.Int64
+	} else {
+		minutes, err := parseMinutes(args[1])
+		if err != nil {
+			return err
+		}
+		duration := book.Book.DurationMinutes
+		if duration.Valid && int64(minutes) > duration.Int64 {
+			return fmt.Errorf("%s is past the end of the book (%s)", models.FormatMinutes(minutes), models.FormatMinutes(int(duration.Int64)))
+		}
+		if err := db.UpdateListeningProgress(id, minutes); err != nil {
+			return fmt.Errorf("failed to update progress: %w", err)
+		}
+		progress = describeListeningProgress(int64(minutes), duration)
+		atEnd = duration.Valid && int64(minutes) == duration.Int64
+	}
+
+	if book.ReadingEntry.Status == models.StatusWantToRead {
+		if err := db.UpdateStatus(id, models.StatusReading); err != nil {
+			return fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+
+	fmt.Printf("\"%s\": %s\n", book.Book.Title, progress)
+	if atEnd {
+		fmt.Printf("That's the end! Mark it finished with: bookshelf finish %d\n", id)
+	}
+	return nil
+}
+
+// describePageProgress renders "page 120 of 412 (29%)", or "page 120" without a page count.
+func describePageProgress(page int64, pages sql.NullInt64) string {
+	progress := fmt.Sprintf("page %d", page)
+	if pages.Valid && pages.Int64 > 0 {
+		progress += fmt.Sprintf(" of %d (%d%%)", pages.Int64, page*100/pages.Int64)
+	}
+	return progress
+}
+
+// describeListeningProgress renders "3h 12m of 11h 25m (28%)", or "3h 12m" without a duration.
+func describeListeningProgress(minutes int64, duration sql.NullInt64) string {
+	progress := models.FormatMinutes(int(minutes))
+	if duration.Valid && duration.Int64 > 0 {
+		progress += fmt.Sprintf(" of %s (%d%%)", models.FormatMinutes(int(duration.Int64)), minutes*100/duration.Int64)
+	}
+	return progress
+}
+
+var durationPattern = regexp.MustCompile(`^(?:(\d+)h)?\s*(?:(\d+)m)?$`)
+
+// parseMinutes reads a listening time as minutes: "3h12m", "3h 12m", "3h",
+// "45m" or "3:12".
+func parseMinutes(s string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if hours, mins, ok := strings.Cut(s, ":"); ok {
+		h, herr := strconv.Atoi(hours)
+		m, merr := strconv.Atoi(mins)
+		if herr != nil || merr != nil || h < 0 || m < 0 || m >= 60 {
+			return 0, fmt.Errorf("invalid time: %s (use e.g. 3h12m, 45m or 3:12)", s)
+		}
+		return h*60 + m, nil
+	}
+
+	match := durationPattern.FindStringSubmatch(s)
+	if s == "" || match == nil {
+		return 0, fmt.Errorf("invalid time: %s (use e.g. 3h12m, 45m or 3:12)", s)
+	}
+	h, _ := strconv.Atoi(match[1])
+	m, _ := strconv.Atoi(match[2])
+	return h*60 + m, nil
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const progressWidth = 30
+
+// progressBar draws "[=========>      ] 12/40" on the last line of a terminal,
+// keeping it below any lines printed through it. Off a terminal it only
+// prints the lines.
+type progressBar struct {
+	out     *os.File
+	total   int
+	done    int
+	enabled bool
+}
+
+func newProgressBar(out *os.File, total int) *progressBar {
+	p := &progressBar{out: out, total: total, enabled: isTerminal(out) && total > 0}
+	p.draw()
+	return p
+}
+
+// Println prints a line above the bar.
+func (p *progressBar) Println(format string, args ...interface{}) {
+	p.clear()
+	fmt.Printf(format+"\n", args...)
+	p.draw()
+}
+
+// Confirm shows a book's changes above the bar and asks whether to apply them.
+func (p *progressBar) Confirm(ctx context.Context, reader *lineReader, title string, changes []fieldChange) bool {
+	p.clear()
+	fmt.Printf("  [?] %s\n%s\n", title, formatChanges(changes))
+	ok := confirm(ctx, reader, "      Apply these changes?")
+	p.draw()
+	return ok
+}
+
+func (p *progressBar) Increment() {
+	p.done++
+	p.draw()
+}
+
+// Finish removes the bar.
+func (p *progressBar) Finish() {
+	p.clear()
+	p.enabled = false
+}
+
+func (p *progressBar) draw() {
+	if !p.enabled {
+		return
+	}
+	filled := progressWidth * p.done / p.total
+	bar := strings.Repeat("=", filled)
+	if filled < progressWidth {
+		bar += ">" + strings.Repeat(" ", progressWidth-filled-1)
+	}
+	fmt.Fprintf(p.out, "\r[%s] %d/%d", bar, p.done, p.total)
+}
+
+func (p *progressBar) clear() {
+	if p.enabled {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// noInput turns prompts into errors, for scripts: a book reference matching
+// several books, a confirmation or a choice of search result fails rather
+// than asking, and editors aren't opened.
+var noInput bool
+
+// stdin is shared by every prompt, so input one prompt reads ahead isn't lost
+// to the next, e.g. picking a book and then confirming its removal.
+var stdin = newLineReader(os.Stdin)
+
+// errNoInput is returned by prompts under --no-input.
+var errNoInput = errors.New("input is needed, but --no-input is set")
+
+// lineReader reads its input one trimmed line at a time, from a single
+// goroutine started by the first prompt. A read can't be interrupted, so a
+// prompt cancelled mid-read leaves the goroutine waiting; the line it then
+// gets is kept for the next prompt rather than lost.
+type lineReader struct {
+	r     io.Reader
+	once  sync.Once
+	lines chan string
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: r, lines: make(chan string)}
+}
+
+// Lines returns the input's lines. The channel is closed at end of input.
+func (l *lineReader) Lines() <-chan string {
+	l.once.Do(func() {
+		go func() {
+			defer close(l.lines)
+			scanner := bufio.NewScanner(l.r)
+			for scanner.Scan() {
+				l.lines <- strings.TrimSpace(scanner.Text())
+			}
+		}()
+	})
+	return l.lines
+}
+
+// readLine reads a trimmed line of input. Under --no-input it fails with
+// errNoInput instead. Otherwise its only error is ctx's, when it's cancelled
+// first, so Ctrl-C isn't stuck behind a prompt; end of input reads as an
+// empty line, like an answer of Enter.
+func readLine(ctx context.Context, reader *lineReader) (string, error) {
+	if noInput {
+		fmt.Println()
+		return "", errNoInput
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line := <-reader.Lines():
+		return line, nil
+	}
+}
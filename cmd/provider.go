@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var providerFlag string
+
+func addProviderFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&providerFlag, "provider", "", "Metadata providers, comma-separated: openlibrary, google (default: metadata.providers config)")
+}
+
+// metadataProvider builds the providers chosen with --provider, falling back to
+// the metadata.providers config key and then to Open Library.
+func metadataProvider() (api.MetadataProvider, error) {
+	names := providerFlag
+	if names == "" {
+		configured, err := db.GetConfig("metadata.providers")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config: %w", err)
+		}
+		names = configured
+	}
+	if names == "" {
+		names = api.ProviderOpenLibrary
+	}
+
+	opts, err := providerOptions()
+	if err != nil {
+		return nil, err
+	}
+	return api.NewProviders(names, opts)
+}
+
+// openLibraryClient builds an Open Library client for the lookups only it
+// supports, such as authors, whatever providers are configured.
+func openLibraryClient() (*api.Client, error) {
+	opts, err := providerOptions()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := api.NewProvider(api.ProviderOpenLibrary, opts)
+	if err != nil {
+		return nil, err
+	}
+	return provider.(*api.Client), nil
+}
+
+func providerOptions() (api.ProviderOptions, error) {
+	apiKey, err := db.GetConfig("google_books.api_key")
+	if err != nil {
+		return api.ProviderOptions{}, fmt.Errorf("failed to get config: %w", err)
+	}
+	cache, err := httpCache()
+	if err != nil {
+		return api.ProviderOptions{}, err
+	}
+	clientOptions, err := httpClientOptions()
+	if err != nil {
+		return api.ProviderOptions{}, err
+	}
+	return api.ProviderOptions{GoogleBooksAPIKey: apiKey, Cache: cache, ClientOptions: clientOptions}, nil
+}
+
+// httpClientOptions reads the contact.email, http.timeout and http.proxy
+// config keys.
+func httpClientOptions() ([]api.Option, error) {
+	config, err := db.GetAllConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	opts := []api.Option{api.WithUserAgent(api.UserAgent(config["contact.email"]))}
+	if value := config["http.timeout"]; value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, api.WithTimeout(timeout))
+	}
+	if value := config["http.proxy"]; value != "" {
+		proxy, err := parseProxy(value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, api.WithProxy(proxy))
+	}
+	return opts, nil
+}
+
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid HTTP timeout: %s (use e.g. 10s or 1m)", value)
+	}
+	return timeout, nil
+}
+
+func parseProxy(value string) (*url.URL, error) {
+	proxy, err := url.Parse(value)
+	if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL: %s (use e.g. http://proxy.example.com:3128)", value)
+	}
+	return proxy, nil
+}
@@ -7,18 +7,30 @@ import (
 )
 
 var outputDir string
+var publishOPDS bool
 
 var publishCmd = &cobra.Command{
 	Use:   "publish",
 	Short: "Generate a static website",
-	Long:  `Generate a static HTML website from your bookshelf data.`,
-	RunE:  runPublish,
+	Long: `Generate a static HTML website from your bookshelf data.
+
+With --opds, an OPDS catalog is written too, so you can browse the shelf
+from e-reader apps such as KOReader, Thorium or Marvin: add
+<site>/opds/catalog.xml as a catalog. It has feeds of the books you're
+reading, want to read and have finished, and by genre and author, each book
+linking to its page on the site.`,
+	RunE: runPublish,
 }
 
 func init() {
 	publishCmd.Flags().StringVarP(&outputDir, "output", "o", "./public", "Output directory for the static site")
+	publishCmd.Flags().BoolVar(&publishOPDS, "opds", false, "Also write an OPDS catalog for e-reader apps")
 }
 
 func runPublish(cmd *cobra.Command, args []string) error {
-	return publish.Generate(outputDir)
+	var opts []publish.Option
+	if publishOPDS {
+		opts = append(opts, publish.WithOPDS())
+	}
+	return publish.Generate(outputDir, opts...)
 }
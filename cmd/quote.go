@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var quotePage int
+var quoteLocation string
+var quoteNote string
+
+var quoteCmd = &cobra.Command{
+	Use:   "quote",
+	Short: "Save quotes and highlights",
+	Long: `Save passages from your books. List a book's quotes with 'bookshelf quotes',
+and import Kindle highlights with 'bookshelf import kindle'.`,
+}
+
+var quoteAddCmd = &cobra.Command{
+	Use:   "add [book] [text]",
+	Short: "Save a quote from a book",
+	Long: `Save a quote from a book. Give the text as arguments, or leave it out to
+write it in your editor ($EDITOR).`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runQuoteAdd,
+}
+
+var quotesCmd = &cobra.Command{
+	Use:   "quotes [book]",
+	Short: "List a book's quotes",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runQuotes,
+}
+
+func init() {
+	quoteAddCmd.Flags().IntVarP(&quotePage, "page", "p", 0, "Page the quote is on")
+	quoteAddCmd.Flags().StringVarP(&quoteLocation, "location", "l", "", "Location, for ebooks without pages (e.g. 180-182)")
+	quoteAddCmd.Flags().StringVarP(&quoteNote, "note", "n", "", "Your note on the quote")
+	quoteCmd.AddCommand(quoteAddCmd)
+}
+
+func runQuoteAdd(cmd *cobra.Command, args []string) error {
+	if quotePage < 0 {
+		return fmt.Errorf("page must be positive")
+	}
+
+	book, err := resolveBook(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	if quotePage > 0 && book.Book.Pages.Valid && int64(quotePage) > book.Book.Pages.Int64 {
+		return fmt.Errorf("page %d is past the end of the book (%d pages)", quotePage, book.Book.Pages.Int64)
+	}
+
+	text := strings.TrimSpace(strings.Join(args[1:], " "))
+	if text == "" {
+		header := fmt.Sprintf("# Quote from \"%s\" by %s\n# Lines starting with # are ignored.\n", book.Book.Title, book.Book.Author)
+		content, err := editInEditor("bookshelf-quote-*.txt", header)
+		if err != nil {
+			return err
+		}
+		text = stripComments(content)
+	}
+	if text == "" {
+		fmt.Println("Empty quote, nothing saved.")
+		return nil
+	}
+
+	quote := models.Quote{
+		BookID:   book.Book.ID,
+		Text:     text,
+		Location: models.NullString(strings.TrimSpace(quoteLocation)),
+		Note:     models.NullString(strings.TrimSpace(quoteNote)),
+	}
+	if quotePage > 0 {
+		quote.Page = sql.NullInt64{Int64: int64(quotePage), Valid: true}
+	}
+
+	if _, err := db.AddQuote(&quote); err != nil {
+		return fmt.Errorf("failed to save quote: %w", err)
+	}
+	fmt.Printf("Saved quote from \"%s\"\n", book.Book.Title)
+	return nil
+}
+
+func runQuotes(cmd *cobra.Command, args []string) error {
+	book, err := resolveBook(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	id := book.Book.ID
+
+	quotes, err := db.GetQuotes(id)
+	if err != nil {
+		return fmt.Errorf("failed to get quotes: %w", err)
+	}
+	if len(quotes) == 0 {
+		fmt.Printf("No quotes from \"%s\" yet. Add one with 'bookshelf quote add %d'.\n", book.Book.Title, id)
+		return nil
+	}
+
+	fmt.Printf("Quotes from \"%s\" (%d):\n", book.Book.Title, len(quotes))
+	for _, q := range quotes {
+		fmt.Printf("\n  \"%s\"\n", strings.ReplaceAll(q.Text, "\n", "\n   "))
+		if where := quoteLocationText(q); where != "" {
+			fmt.Printf("    - %s\n", where)
+		}
+		if q.Note.Valid {
+			fmt.Printf("    Note: %s\n", q.Note.String)
+		}
+	}
+	return nil
+}
+
+// quoteLocationText describes where a quote is, e.g. "page 12, location 180-182".
+func quoteLocationText(q models.Quote) string {
+	var parts []string
+	if q.Page.Valid {
+		parts = append(parts, fmt.Sprintf("page %d", q.Page.Int64))
+	}
+	if q.Location.Valid {
+		parts = append(parts, "location "+q.Location.String)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// stripComments drops lines starting with # and trims the rest.
+func stripComments(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -2,48 +2,56 @@ package cmd
 
 import (
 	"bookshelf/internal/db"
+	"bookshelf/internal/models"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var rateCmd = &cobra.Command{
-	Use:   "rate [id] [1-5]",
+	Use:   "rate [book] [rating]",
 	Short: "Rate a book",
-	Long:  `Give a book a rating from 1 to 5 stars.`,
-	Args:  cobra.ExactArgs(2),
-	RunE:  runRate,
+	Long: `Give a book a rating on your configured scale: 1 to 5 stars (default),
+0.5 to 5 in half stars, or 1 to 10 points. Change the scale with
+'bookshelf config set rating.scale <stars|half-stars|10-point>'.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRate,
 }
 
 func runRate(cmd *cobra.Command, args []string) error {
-	id, err := strconv.ParseInt(args[0], 10, 64)
+	scale, err := db.GetRatingScale()
 	if err != nil {
-		return fmt.Errorf("invalid book ID: %s", args[0])
+		return fmt.Errorf("failed to get rating scale: %w", err)
 	}
 
-	rating, err := strconv.Atoi(args[1])
-	if err != nil || rating < 1 || rating > 5 {
-		return fmt.Errorf("rating must be between 1 and 5")
+	rating, err := scale.Parse(args[1])
+	if err != nil {
+		return err
 	}
 
-	exists, err := db.BookExists(id)
+	book, err := resolveBook(cmd.Context(), args[0])
 	if err != nil {
-		return fmt.Errorf("failed to check book: %w", err)
-	}
-	if !exists {
-		return fmt.Errorf("book with ID %d not found", id)
+		return err
 	}
 
-	if err := db.UpdateRating(id, rating); err != nil {
+	if err := db.UpdateRating(book.Book.ID, rating); err != nil {
 		return fmt.Errorf("failed to update rating: %w", err)
 	}
 
-	book, _ := db.GetBook(id)
-	stars := ""
-	for i := 0; i < rating; i++ {
-		stars += "*"
-	}
-	fmt.Printf("Rated \"%s\" %s (%d/5)\n", book.Book.Title, stars, rating)
+	fmt.Printf("Rated \"%s\" %s\n", book.Book.Title, describeRating(scale, rating))
 	return nil
 }
+
+// describeRating renders a rating as stars plus its value on the scale,
+// e.g. "****~ (4.5/5)". Point scales show only the value.
+func describeRating(scale models.RatingScale, stars float64) string {
+	if scale == models.RatingScaleTenPoint {
+		return fmt.Sprintf("(%s)", scale.Format(stars))
+	}
+	s := strings.Repeat("*", int(stars))
+	if stars-float64(int(stars)) >= 0.5 {
+		s += "~"
+	}
+	return fmt.Sprintf("%s (%s)", s, scale.Format(stars))
+}
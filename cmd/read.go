@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var readResume bool
+
+var readCmd = &cobra.Command{
+	Use:   "read [book]",
+	Short: "Start a reading timer",
+	Long: `Start a foreground timer for a reading session. Stop it with Ctrl-C or by
+typing q and pressing Enter, then enter the page you stopped on. The session
+is logged and the book's progress updated.
+
+The running timer is saved, so if bookshelf is killed you can pick it up
+again with 'bookshelf read --resume'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRead,
+}
+
+func init() {
+	readCmd.Flags().BoolVar(&readResume, "resume", false, "Resume a timer left running after a crash")
+}
+
+func runRead(cmd *cobra.Command, args []string) error {
+	timer, err := db.GetTimer()
+	if err != nil {
+		return fmt.Errorf("failed to check timer: %w", err)
+	}
+
+	if readResume {
+		if len(args) > 0 {
+			return fmt.Errorf("--resume does not take a book")
+		}
+		if timer == nil {
+			return fmt.Errorf("no reading timer to resume")
+		}
+	} else {
+		if len(args) == 0 {
+			return fmt.Errorf("provide a book, or --resume to continue a saved timer")
+		}
+		if timer != nil {
+			return fmt.Errorf("a timer is already running for book %d, use 'bookshelf read --resume'", timer.BookID)
+		}
+		book, err := resolveBook(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if timer, err = startTimer(book.Book.ID); err != nil {
+			return err
+		}
+	}
+
+	book, err := db.GetBook(timer.BookID)
+	if err != nil {
+		return fmt.Errorf("book not found: %w", err)
+	}
+
+	if readResume {
+		fmt.Printf("Resuming timer for \"%s\" started %s\n", book.Book.Title, timer.StartedAt.Local().Format("Jan 02 15:04"))
+	} else {
+		fmt.Printf("Reading \"%s\"\n", book.Book.Title)
+	}
+	if noInput {
+		// Only Ctrl-C stops the timer, and the ending page isn't asked for.
+		fmt.Println("Press Ctrl-C to stop.")
+		elapsed := waitForStop(cmd.Context(), timer.StartedAt, nil)
+		fmt.Printf("\n\nRead for %s\n", formatElapsed(elapsed))
+		return finishTimer(book, timer, elapsed, nil)
+	}
+	fmt.Println("Press Ctrl-C or type q and Enter to stop.")
+
+	lines := stdin.Lines()
+	elapsed := waitForStop(cmd.Context(), timer.StartedAt, lines)
+	fmt.Printf("\n\nRead for %s\n", formatElapsed(elapsed))
+
+	endPage, err := promptEndPage(book, lines)
+	if err != nil {
+		return err
+	}
+
+	return finishTimer(book, timer, elapsed, endPage)
+}
+
+func startTimer(id int64) (*models.ReadingTimer, error) {
+	book, err := db.GetBook(id)
+	if err != nil {
+		return nil, fmt.Errorf("book with ID %d not found", id)
+	}
+
+	if book.ReadingEntry.Status == models.StatusWantToRead {
+		if err := db.UpdateStatus(id, models.StatusReading); err != nil {
+			return nil, fmt.Errorf("failed to update status: %w", err)
+		}
+	}
+
+	var startPage *int
+	if book.ReadingEntry.CurrentPage.Valid {
+		page := int(book.ReadingEntry.CurrentPage.Int64)
+		startPage = &page
+	}
+
+	if err := db.StartTimer(id, time.Now(), startPage); err != nil {
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+	return db.GetTimer()
+}
+
+// waitForStop redraws the elapsed time every second until ctx is cancelled
+// (Ctrl-C) or a "q" line.
+func waitForStop(ctx context.Context, startedAt time.Time, lines <-chan string) time.Duration {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		fmt.Printf("\r  %s ", formatElapsed(time.Since(startedAt)))
+		select {
+		case <-ctx.Done():
+			return time.Since(startedAt)
+		case line, ok := <-lines:
+			if !ok {
+				// stdin closed; only Ctrl-C can stop the timer now
+				lines = nil
+			} else if strings.EqualFold(line, "q") {
+				return time.Since(startedAt)
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+func promptEndPage(book *models.BookWithEntry, lines <-chan string) (*int, error) {
+	prompt := "Ending page"
+	if book.ReadingEntry.CurrentPage.Valid {
+		prompt += fmt.Sprintf(" (was %d)", book.ReadingEntry.CurrentPage.Int64)
+	}
+	fmt.Printf("%s, or Enter to skip: ", prompt)
+
+	line, ok := <-lines
+	if !ok || line == "" {
+		fmt.Println()
+		return nil, nil
+	}
+
+	page, err := strconv.Atoi(line)
+	if err != nil || page < 0 {
+		return nil, fmt.Errorf("invalid page: %s (timer kept, use 'bookshelf read --resume')", line)
+	}
+	if book.Book.Pages.Valid && int64(page) > book.Book.Pages.Int64 {
+		return nil, fmt.Errorf("page %d is past the end of the book (%d pages)", page, book.Book.Pages.Int64)
+	}
+	return &page, nil
+}
+
+func finishTimer(book *models.BookWithEntry, timer *models.ReadingTimer, elapsed time.Duration, endPage *int) error {
+	minutes := int(math.Round(elapsed.Minutes()))
+
+	var pages int
+	if endPage != nil && timer.StartPage.Valid {
+		pages = *endPage - int(timer.StartPage.Int64)
+	} else if endPage != nil {
+		pages = *endPage
+	}
+
+	var pagesArg, minutesArg *int
+	if pages > 0 {
+		pagesArg = &pages
+	}
+	if minutes > 0 {
+		minutesArg = &minutes
+	}
+
+	if pagesArg != nil || minutesArg != nil {
+		if _, err := db.AddSession(book.Book.ID, timer.StartedAt.Local(), pagesArg, minutesArg, nil); err != nil {
+			return fmt.Errorf("failed to log session: %w", err)
+		}
+		fmt.Printf("Logged session: %s\n", describeSession(pages, minutes))
+	} else {
+		fmt.Println("Session too short, nothing logged.")
+	}
+
+	if endPage != nil {
+		if err := db.UpdateProgress(book.Book.ID, *endPage); err != nil {
+			return fmt.Errorf("failed to update progress: %w", err)
+		}
+		if book.Book.Pages.Valid && int64(*endPage) == book.Book.Pages.Int64 {
+			fmt.Printf("That's the last page! Mark it finished with: bookshelf finish %d\n", book.Book.ID)
+		}
+	}
+
+	return db.ClearTimer()
+}
+
+// formatElapsed renders a duration as HH:MM:SS.
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
@@ -4,130 +4,404 @@ import (
 	"bookshelf/internal/api"
 	"bookshelf/internal/db"
 	"bookshelf/internal/models"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 )
 
+var refreshConcurrency int
+var refreshForce bool
+var refreshFieldList []string
+var refreshYes bool
+
 var refreshCmd = &cobra.Command{
-	Use:   "refresh [id]",
+	Use:   "refresh [book]",
 	Short: "Refresh book metadata from Open Library",
-	Long: `Fetch updated metadata (description, genres) from Open Library for books.
+	Long: `Fetch updated metadata (description, genres) from Open Library, or the
+providers chosen with --provider, for books.
 
+Books are looked up by their Open Library key, falling back to their ISBN.
 If an ID is provided, refreshes only that book.
-If no ID is provided, refreshes all books that have an Open Library key.`,
+If no ID is provided, refreshes all books that have an Open Library key or ISBN,
+several at a time (--concurrency). Requests are rate limited and retried when
+the server is busy; Ctrl-C stops cleanly, keeping what was already refreshed.
+
+By default only missing pages, covers, descriptions and genres are filled in.
+Use --force to replace existing values too, and --fields to pick which fields
+to refresh. Page counts and covers come from the book's edition. Each change is
+shown as a diff, and replacing an existing value asks for confirmation unless
+--yes is given.`,
 	RunE: runRefresh,
 }
 
+func init() {
+	addProviderFlag(refreshCmd)
+	refreshCmd.Flags().IntVarP(&refreshConcurrency, "concurrency", "c", 4, "Number of books to look up at once")
+	refreshCmd.Flags().BoolVarP(&refreshForce, "force", "f", false, "Replace existing values, not only fill gaps")
+	refreshCmd.Flags().StringSliceVar(&refreshFieldList, "fields", refreshFields, "Fields to refresh: pages, cover, description, genres")
+	refreshCmd.Flags().BoolVarP(&refreshYes, "yes", "y", false, "Apply changes without asking for confirmation")
+}
+
+// refreshFields are the book fields refresh can update, in display order.
+var refreshFields = []string{"pages", "cover", "description", "genres"}
+
+// fieldChange is one field a refresh would change.
+type fieldChange struct {
+	field     string
+	old       string
+	new       string
+	overwrite bool
+}
+
+// metadataUpdate holds the fields a lookup found for a book. Nil fields are left alone.
+type metadataUpdate struct {
+	description *string
+	genres      *string
+	coverURL    *string
+	pages       *int
+	changes     []fieldChange
+}
+
+func (u metadataUpdate) empty() bool {
+	return len(u.changes) == 0
+}
+
+// overwrites reports whether the update replaces any existing value.
+func (u metadataUpdate) overwrites() bool {
+	for _, c := range u.changes {
+		if c.overwrite {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshOptions controls which fields are fetched and whether existing values are replaced.
+type refreshOptions struct {
+	fields map[string]bool
+	force  bool
+	yes    bool
+}
+
+func (o refreshOptions) wants(field string, current string) bool {
+	return o.fields[field] && (o.force || current == "")
+}
+
 func runRefresh(cmd *cobra.Command, args []string) error {
-	client := api.NewClient()
+	if refreshConcurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if refreshForce && !refreshYes && noInput {
+		return fmt.Errorf("--force asks before replacing values; use --yes with --no-input")
+	}
+
+	opts := refreshOptions{fields: make(map[string]bool), force: refreshForce, yes: refreshYes}
+	for _, field := range refreshFieldList {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !slices.Contains(refreshFields, field) {
+			return fmt.Errorf("unknown field: %s (use: %s)", field, strings.Join(refreshFields, ", "))
+		}
+		opts.fields[field] = true
+	}
+
+	client, err := metadataProvider()
+	if err != nil {
+		return err
+	}
 
+	ctx := cmd.Context()
 	if len(args) > 0 {
 		// Refresh single book
-		id, err := strconv.ParseInt(args[0], 10, 64)
+		book, err := resolveBook(ctx, args[0])
 		if err != nil {
-			return fmt.Errorf("invalid book ID: %s", args[0])
+			return err
 		}
-		return refreshBook(client, id)
+		return refreshBook(ctx, client, book.Book.ID, opts)
 	}
 
 	// Refresh all books
-	return refreshAllBooks(client)
+	return refreshAllBooks(ctx, client, refreshConcurrency, opts)
 }
 
-func refreshBook(client *api.Client, id int64) error {
+func refreshBook(ctx context.Context, client api.MetadataProvider, id int64, opts refreshOptions) error {
 	book, err := db.GetBook(id)
 	if err != nil {
 		return fmt.Errorf("book not found: %w", err)
 	}
 
-	if !book.Book.OpenLibraryKey.Valid || book.Book.OpenLibraryKey.String == "" {
-		return fmt.Errorf("book %d has no Open Library key, cannot refresh", id)
+	if !hasLookupKey(book) {
+		return fmt.Errorf("book %d has no Open Library key or ISBN, cannot refresh", id)
 	}
 
-	updated, err := fetchAndUpdateMetadata(client, book)
+	update, err := fetchMetadata(ctx, client, book, opts)
 	if err != nil {
 		return err
 	}
 
-	if updated {
-		fmt.Printf("Refreshed \"%s\"\n", book.Book.Title)
-	} else {
+	if update.empty() {
 		fmt.Printf("No new metadata for \"%s\"\n", book.Book.Title)
+		return nil
+	}
+
+	fmt.Printf("\"%s\":\n", book.Book.Title)
+	printChanges(update.changes)
+	if update.overwrites() && !opts.yes && !confirm(ctx, stdin, "Apply these changes?") {
+		fmt.Println("Skipped.")
+		return nil
+	}
+	if err := applyMetadata(book, update); err != nil {
+		return err
 	}
+	fmt.Printf("Refreshed \"%s\"\n", book.Book.Title)
 	return nil
 }
 
-func refreshAllBooks(client *api.Client) error {
-	books, err := db.GetBooksWithOpenLibraryKey()
+type refreshResult struct {
+	book   *models.BookWithEntry
+	update metadataUpdate
+	err    error
+}
+
+// refreshAllBooks looks books up with a pool of workers. Only the lookups run
+// concurrently; results are written to the database from this goroutine.
+func refreshAllBooks(ctx context.Context, client api.MetadataProvider, concurrency int, opts refreshOptions) error {
+	books, err := db.GetBooksWithLookupKey()
 	if err != nil {
 		return fmt.Errorf("failed to get books: %w", err)
 	}
 
 	if len(books) == 0 {
-		fmt.Println("No books with Open Library keys found.")
+		fmt.Println("No books with Open Library keys or ISBNs found.")
 		return nil
 	}
 
 	fmt.Printf("Refreshing %d books...\n\n", len(books))
 
-	var refreshed, skipped int
-	for _, book := range books {
-		updated, err := fetchAndUpdateMetadata(client, &book)
-		if err != nil {
-			fmt.Printf("  [!] %s: %v\n", book.Book.Title, err)
-			skipped++
-			continue
+	jobs := make(chan *models.BookWithEntry)
+	results := make(chan refreshResult)
+
+	go func() {
+		defer close(jobs)
+		for i := range books {
+			select {
+			case jobs <- &books[i]:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
 
-		if updated {
-			fmt.Printf("  [+] %s\n", book.Book.Title)
-			refreshed++
-		} else {
-			fmt.Printf("  [-] %s (no new data)\n", book.Book.Title)
-			skipped++
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for book := range jobs {
+				update, err := fetchMetadata(ctx, client, book, opts)
+				results <- refreshResult{book: book, update: update, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	bar := newProgressBar(os.Stderr, len(books))
+	var refreshed, unchanged, declined, failed int
+	for r := range results {
+		title := r.book.Book.Title
+		switch {
+		case r.err != nil && errors.Is(r.err, context.Canceled):
+			// Interrupted mid-lookup; counted as not checked
+			continue
+		case r.err != nil:
+			bar.Println("  [!] %s: %v", title, r.err)
+			failed++
+		case r.update.empty():
+			bar.Println("  [-] %s (no new data)", title)
+			unchanged++
+		case r.update.overwrites() && !opts.yes && !bar.Confirm(ctx, stdin, title, r.update.changes):
+			bar.Println("  [-] %s (skipped)", title)
+			declined++
+		default:
+			if err := applyMetadata(r.book, r.update); err != nil {
+				bar.Println("  [!] %s: %v", title, err)
+				failed++
+			} else {
+				bar.Println("  [+] %s", title)
+				if !r.update.overwrites() || opts.yes {
+					bar.Println("%s", formatChanges(r.update.changes))
+				}
+				refreshed++
+			}
 		}
+		bar.Increment()
+	}
+	bar.Finish()
+
+	if ctx.Err() != nil {
+		remaining := len(books) - refreshed - unchanged - declined - failed
+		fmt.Printf("\nInterrupted: %d refreshed, %d unchanged, %d skipped, %d failed, %d not checked\n", refreshed, unchanged, declined, failed, remaining)
+		return fmt.Errorf("refresh interrupted")
 	}
 
-	fmt.Printf("\nDone: %d refreshed, %d unchanged\n", refreshed, skipped)
+	fmt.Printf("\nDone: %d refreshed, %d unchanged, %d skipped, %d failed\n", refreshed, unchanged, declined, failed)
 	return nil
 }
 
-func fetchAndUpdateMetadata(client *api.Client, book *models.BookWithEntry) (bool, error) {
-	work, err := client.GetWorkDetails(book.Book.OpenLibraryKey.String)
+func hasLookupKey(book *models.BookWithEntry) bool {
+	return book.Book.OpenLibraryKey.String != "" || book.Book.ISBN.String != ""
+}
+
+// fetchWorkDetails looks a book up by its Open Library key, or by ISBN if it
+// has no key or the providers can't resolve it.
+func fetchWorkDetails(ctx context.Context, client api.MetadataProvider, book *models.BookWithEntry) (*api.WorkDetails, error) {
+	if key := book.Book.OpenLibraryKey.String; key != "" {
+		work, err := client.GetWorkDetails(ctx, key)
+		if err == nil || book.Book.ISBN.String == "" || ctx.Err() != nil {
+			return work, err
+		}
+	}
+
+	doc, err := client.LookupISBN(ctx, book.Book.ISBN.String)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("ISBN %s not found", book.Book.ISBN.String)
+	}
+	return client.GetWorkDetails(ctx, doc.Key)
+}
+
+// fetchMetadata looks a book up and works out which of the requested fields
+// would change. Editions are only fetched when pages or the cover are wanted.
+func fetchMetadata(ctx context.Context, client api.MetadataProvider, book *models.BookWithEntry, opts refreshOptions) (metadataUpdate, error) {
+	var update metadataUpdate
+	current := book.Book
+
+	work, err := fetchWorkDetails(ctx, client, book)
 	if err != nil {
-		return false, fmt.Errorf("failed to fetch from Open Library: %w", err)
+		return update, fmt.Errorf("failed to fetch from %s: %w", client.Name(), err)
 	}
 
-	var description, genres *string
-	var updated bool
+	if opts.wants("pages", formatOptionalInt(current.Pages)) || opts.wants("cover", current.CoverURL.String) {
+		key := work.Key
+		if key == "" {
+			key = current.OpenLibraryKey.String
+		}
+		editions, err := client.GetEditions(ctx, key)
+		if err != nil {
+			return update, fmt.Errorf("failed to fetch editions from %s: %w", client.Name(), err)
+		}
+		if edition := api.PickEdition(editions, current.EditionKey.String, current.ISBN.String); edition != nil {
+			if pages := edition.Pages(); pages != nil && opts.wants("pages", formatOptionalInt(current.Pages)) {
+				if update.record("pages", formatOptionalInt(current.Pages), strconv.Itoa(*pages)) {
+					update.pages = pages
+				}
+			}
+			if cover := edition.CoverURL(); cover != nil && opts.wants("cover", current.CoverURL.String) {
+				if update.record("cover", current.CoverURL.String, *cover) {
+					update.coverURL = cover
+				}
+			}
+		}
+	}
 
-	// Update description if missing
-	if !book.Book.Description.Valid || book.Book.Description.String == "" {
-		if desc := work.DescriptionText(); desc != nil {
-			description = desc
-			updated = true
+	if desc := work.DescriptionText(); desc != nil && opts.wants("description", current.Description.String) {
+		if update.record("description", current.Description.String, *desc) {
+			update.description = desc
 		}
 	}
 
-	// Update genres if missing
-	if !book.Book.Genres.Valid || book.Book.Genres.String == "" {
-		if subjects := work.TopSubjects(5); len(subjects) > 0 {
+	if subjects := work.TopSubjects(5); len(subjects) > 0 && opts.wants("genres", current.Genres.String) {
+		old := strings.Join(parseGenres(current.Genres), ", ")
+		if update.record("genres", old, strings.Join(subjects, ", ")) {
 			if jsonBytes, err := json.Marshal(subjects); err == nil {
 				jsonStr := string(jsonBytes)
-				genres = &jsonStr
-				updated = true
+				update.genres = &jsonStr
 			}
 		}
 	}
 
-	if updated {
-		if err := db.UpdateBookMetadata(book.Book.ID, description, genres); err != nil {
-			return false, fmt.Errorf("failed to update database: %w", err)
+	return update, nil
+}
+
+// record notes a change to field if the value differs, and reports whether it did.
+func (u *metadataUpdate) record(field, old, new string) bool {
+	if strings.TrimSpace(old) == strings.TrimSpace(new) {
+		return false
+	}
+	u.changes = append(u.changes, fieldChange{field: field, old: old, new: new, overwrite: old != ""})
+	return true
+}
+
+func applyMetadata(book *models.BookWithEntry, update metadataUpdate) error {
+	if err := db.UpdateBookMetadata(book.Book.ID, update.description, update.genres, update.coverURL, update.pages); err != nil {
+		return fmt.Errorf("failed to update database: %w", err)
+	}
+	return nil
+}
+
+// formatChanges renders a per-field diff:
+//
+//	pages: - 310
+//	       + 412
+func formatChanges(changes []fieldChange) string {
+	var b strings.Builder
+	for i, c := range changes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		indent := strings.Repeat(" ", len(c.field)+8)
+		if c.old != "" {
+			fmt.Fprintf(&b, "      %s: - %s\n%s+ %s", c.field, oneLine(c.old, 60), indent, oneLine(c.new, 60))
+		} else {
+			fmt.Fprintf(&b, "      %s: + %s", c.field, oneLine(c.new, 60))
 		}
 	}
+	return b.String()
+}
+
+// oneLine collapses whitespace so a long description fits on one line.
+func oneLine(s string, maxLen int) string {
+	return truncateString(strings.Join(strings.Fields(s), " "), maxLen)
+}
+
+func printChanges(changes []fieldChange) {
+	fmt.Println(formatChanges(changes))
+}
+
+func formatOptionalInt(n sql.NullInt64) string {
+	if !n.Valid || n.Int64 == 0 {
+		return ""
+	}
+	return strconv.FormatInt(n.Int64, 10)
+}
+
+// parseGenres decodes the JSON genre list stored on a book.
+func parseGenres(genres sql.NullString) []string {
+	var list []string
+	if genres.Valid && genres.String != "" {
+		json.Unmarshal([]byte(genres.String), &list)
+	}
+	return list
+}
 
-	return updated, nil
+// confirm asks a yes/no question, defaulting to no. An interrupt, or
+// --no-input, answers no.
+func confirm(ctx context.Context, reader *lineReader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	input, _ := readLine(ctx, reader)
+	input = strings.ToLower(input)
+	return input == "y" || input == "yes"
 }
@@ -2,10 +2,7 @@ package cmd
 
 import (
 	"bookshelf/internal/db"
-	"bufio"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -14,7 +11,7 @@ import (
 var forceRemove bool
 
 var removeCmd = &cobra.Command{
-	Use:   "remove [id]",
+	Use:   "remove [book]",
 	Short: "Remove a book from your shelf",
 	Long:  `Remove a book and its reading entry from your bookshelf.`,
 	Args:  cobra.ExactArgs(1),
@@ -26,21 +23,22 @@ func init() {
 }
 
 func runRemove(cmd *cobra.Command, args []string) error {
-	id, err := strconv.ParseInt(args[0], 10, 64)
+	book, err := resolveBook(cmd.Context(), args[0])
 	if err != nil {
-		return fmt.Errorf("invalid book ID: %s", args[0])
-	}
-
-	book, err := db.GetBook(id)
-	if err != nil {
-		return fmt.Errorf("book not found: %w", err)
+		return err
 	}
+	id := book.Book.ID
 
 	if !forceRemove {
+		if noInput {
+			return fmt.Errorf("removing asks for confirmation; use --force with --no-input")
+		}
 		fmt.Printf("Remove \"%s\" by %s? [y/N] ", book.Book.Title, book.Book.Author)
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
+		input, err := readLine(cmd.Context(), stdin)
+		if err != nil {
+			return err
+		}
+		input = strings.ToLower(input)
 
 		if input != "y" && input != "yes" {
 			fmt.Println("Cancelled.")
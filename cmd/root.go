@@ -2,12 +2,18 @@ package cmd
 
 import (
 	"bookshelf/internal/db"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
+var offline bool
+
 var rootCmd = &cobra.Command{
 	Use:   "bookshelf",
 	Short: "A personal reading tracker CLI",
@@ -21,13 +27,30 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the commands' context, aborting requests and prompts. A
+	// second Ctrl-C kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Interrupted.")
+		os.Exit(130)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Serve metadata only from the cache, never the network")
+	rootCmd.PersistentFlags().BoolVar(&noInput, "no-input", false, "Never prompt or open an editor; fail instead of asking")
+
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(showCmd)
@@ -43,4 +66,19 @@ func init() {
 	rootCmd.AddCommand(goalCmd)
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(logCmd)
+	rootCmd.AddCommand(sessionCmd)
+	rootCmd.AddCommand(readCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(datesCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(progressCmd)
+	rootCmd.AddCommand(authorCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(quoteCmd)
+	rootCmd.AddCommand(quotesCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(apiCmd)
+	rootCmd.AddCommand(uiCmd)
+	rootCmd.AddCommand(tuiCmd)
 }
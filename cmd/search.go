@@ -10,38 +10,94 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var searchLimit int
+var searchQuery api.SearchQuery
 
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
-	Short: "Search Open Library without adding",
-	Long:  `Search for books on Open Library without adding them to your shelf.`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runSearch,
+	Short: "Search for books without adding",
+	Long: `Search for books on Open Library, or the providers chosen with --provider,
+without adding them to your shelf.
+
+Narrow the search with --title, --author, --subject, --isbn, --year (first
+published) and --lang (a MARC code such as eng or fre); with those the query
+itself is optional. Results are sorted by relevance unless --sort is given.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !searchFieldsGiven(cmd) {
+			return fmt.Errorf("give a query or at least one of --%s", strings.Join(searchFieldFlags, ", --"))
+		}
+		return nil
+	},
+	RunE: runSearch,
 }
 
+// searchFieldFlags are the flags that narrow a search, shared with add.
+var searchFieldFlags = []string{"title", "author", "subject", "isbn", "year", "lang"}
+
 func init() {
-	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 10, "Maximum number of results")
+	searchCmd.Flags().IntVarP(&searchQuery.Limit, "limit", "l", 10, "Maximum number of results")
+	searchCmd.Flags().StringVar(&searchQuery.Title, "title", "", "Match the title")
+	searchCmd.Flags().StringVar(&searchQuery.Author, "author", "", "Match the author")
+	searchCmd.Flags().StringVar(&searchQuery.ISBN, "isbn", "", "Match an ISBN")
+	addSearchFieldFlags(searchCmd, &searchQuery)
+	searchCmd.Flags().IntVar(&searchQuery.Page, "page", 1, "Page of results to show")
+	searchCmd.Flags().StringVar(&searchQuery.Sort, "sort", "", "Sort order: new, old or rating (default: relevance)")
+	addProviderFlag(searchCmd)
+}
+
+// addSearchFieldFlags registers the search fields that add and search both
+// take. Title, author and ISBN are registered separately, as add also uses
+// them for --manual.
+func addSearchFieldFlags(cmd *cobra.Command, q *api.SearchQuery) {
+	cmd.Flags().StringVar(&q.Subject, "subject", "", "Match a subject, e.g. \"science fiction\"")
+	cmd.Flags().IntVar(&q.Year, "year", 0, "Match the year of first publication")
+	cmd.Flags().StringVar(&q.Language, "lang", "", "Match a language, as a MARC code (e.g. eng, fre)")
+}
+
+func searchFieldsGiven(cmd *cobra.Command) bool {
+	for _, name := range searchFieldFlags {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
-	query := strings.Join(args, " ")
-	client := api.NewClient()
+	query := searchQuery
+	query.Text = strings.Join(args, " ")
+	if query.Limit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+	if query.Page < 1 {
+		return fmt.Errorf("--page must be at least 1")
+	}
+	if err := query.Validate(); err != nil {
+		return err
+	}
+
+	client, err := metadataProvider()
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Searching for \"%s\"...\n\n", query)
+	fmt.Printf("Searching for %s...\n\n", query)
 
-	docs, err := client.Search(query, searchLimit)
+	docs, err := client.Search(cmd.Context(), query)
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
 
 	if len(docs) == 0 {
-		fmt.Println("No books found.")
+		if query.Page > 1 {
+			fmt.Printf("No books found on page %d.\n", query.Page)
+		} else {
+			fmt.Println("No books found.")
+		}
 		return nil
 	}
 
 	table := tablewriter.NewTable(os.Stdout)
-	table.Header("Title", "Author", "Year", "Pages")
+	table.Header("Title", "Author", "Year", "Pages", "Editions", "Subjects")
 
 	for _, doc := range docs {
 		year := "-"
@@ -52,6 +108,18 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		if doc.NumberOfPages > 0 {
 			pages = fmt.Sprintf("%d", doc.NumberOfPages)
 		}
+		editions := "-"
+		if doc.EditionCount > 0 {
+			editions = fmt.Sprintf("%d", doc.EditionCount)
+		}
+		subjects := "-"
+		if len(doc.Subject) > 0 {
+			top := doc.Subject
+			if len(top) > 3 {
+				top = top[:3]
+			}
+			subjects = truncateString(strings.Join(top, ", "), 35)
+		}
 
 		title := doc.Title
 		if len(title) > 45 {
@@ -63,10 +131,14 @@ func runSearch(cmd *cobra.Command, args []string) error {
 			author = author[:22] + "..."
 		}
 
-		table.Append(title, author, year, pages)
+		table.Append(title, author, year, pages, editions, subjects)
 	}
 
 	table.Render()
-	fmt.Printf("\nFound %d results. Use 'bookshelf add \"%s\"' to add a book.\n", len(docs), query)
+	fmt.Printf("\nFound %d results on page %d.", len(docs), query.Page)
+	if len(docs) == query.Limit {
+		fmt.Printf(" Use --page %d for more.", query.Page+1)
+	}
+	fmt.Println("\nUse 'bookshelf add' with the same query and filters to add a book.")
 	return nil
 }
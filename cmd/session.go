@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var sessionPages int
+var sessionMinutes int
+var sessionNote string
+var sessionDate string
+
+var sessionCmd = &cobra.Command{
+	Use:   "session [book]",
+	Short: "Log a reading session",
+	Long: `Log a day's reading on a book. Provide the pages read, the minutes spent,
+or both. Sessions count toward your reading streaks.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSession,
+}
+
+func init() {
+	sessionCmd.Flags().IntVarP(&sessionPages, "pages", "p", 0, "Pages read in this session")
+	sessionCmd.Flags().IntVarP(&sessionMinutes, "minutes", "m", 0, "Minutes spent reading")
+	sessionCmd.Flags().StringVarP(&sessionNote, "note", "n", "", "Optional note for the reading journal")
+	sessionCmd.Flags().StringVarP(&sessionDate, "date", "d", "", "Day of the session, exact or fuzzy (default: today)")
+}
+
+func runSession(cmd *cobra.Command, args []string) error {
+	if sessionPages < 0 || sessionMinutes < 0 {
+		return fmt.Errorf("pages and minutes must be positive")
+	}
+	if sessionPages == 0 && sessionMinutes == 0 {
+		return fmt.Errorf("provide --pages, --minutes or both")
+	}
+
+	date := time.Now()
+	if sessionDate != "" {
+		var err error
+		if date, err = parseDateFlag(sessionDate); err != nil {
+			return err
+		}
+	}
+
+	book, err := resolveBook(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	id := book.Book.ID
+
+	var pages, minutes *int
+	if sessionPages > 0 {
+		pages = &sessionPages
+	}
+	if sessionMinutes > 0 {
+		minutes = &sessionMinutes
+	}
+	var note *string
+	if n := strings.TrimSpace(sessionNote); n != "" {
+		note = &n
+	}
+
+	if _, err := db.AddSession(id, date, pages, minutes, note); err != nil {
+		return fmt.Errorf("failed to log session: %w", err)
+	}
+
+	fmt.Printf("Logged session for \"%s\" on %s: %s\n", book.Book.Title, date.Format("Jan 02, 2006"), describeSession(sessionPages, sessionMinutes))
+
+	current, _, err := db.GetReadingStreaks(time.Now())
+	if err == nil && current > 1 {
+		fmt.Printf("Current streak: %d days\n", current)
+	}
+	return nil
+}
+
+// describeSession renders pages and minutes as "30 pages, 45m".
+func describeSession(pages, minutes int) string {
+	var parts []string
+	if pages > 0 {
+		parts = append(parts, fmt.Sprintf("%d pages", pages))
+	}
+	if minutes > 0 {
+		parts = append(parts, models.FormatMinutes(minutes))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -2,14 +2,15 @@ package cmd
 
 import (
 	"bookshelf/internal/db"
+	"bookshelf/internal/models"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var showCmd = &cobra.Command{
-	Use:   "show [id]",
+	Use:   "show [book]",
 	Short: "Show book details",
 	Long:  `Show detailed information about a book including its review.`,
 	Args:  cobra.ExactArgs(1),
@@ -17,15 +18,11 @@ var showCmd = &cobra.Command{
 }
 
 func runShow(cmd *cobra.Command, args []string) error {
-	id, err := strconv.ParseInt(args[0], 10, 64)
+	book, err := resolveBook(cmd.Context(), args[0])
 	if err != nil {
-		return fmt.Errorf("invalid book ID: %s", args[0])
-	}
-
-	book, err := db.GetBook(id)
-	if err != nil {
-		return fmt.Errorf("book not found: %w", err)
+		return err
 	}
+	id := book.Book.ID
 
 	fmt.Printf("Title:  %s\n", book.Book.Title)
 	fmt.Printf("Author: %s\n", book.Book.Author)
@@ -39,6 +36,40 @@ func runShow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Pages:  %d\n", book.Book.Pages.Int64)
 	}
 
+	if book.Book.Format.Valid {
+		fmt.Printf("Format: %s\n", book.Book.Format.String)
+	}
+	if book.Book.Publisher.Valid {
+		fmt.Printf("Publisher: %s\n", book.Book.Publisher.String)
+	}
+	if book.Book.PublishDate.Valid {
+		fmt.Printf("Published: %s\n", book.Book.PublishDate.String)
+	}
+	if book.Book.Language.Valid {
+		fmt.Printf("Language:  %s\n", book.Book.Language.String)
+	}
+	if book.Book.EditionKey.Valid {
+		fmt.Printf("Edition:   %s\n", book.Book.EditionKey.String)
+	}
+
+	if series := book.Book.SeriesLabel(); series != "" {
+		fmt.Printf("Series:    %s\n", series)
+	}
+
+	if tags := book.Book.TagList(); len(tags) > 0 {
+		fmt.Printf("Tags:      %s\n", strings.Join(tags, ", "))
+	}
+
+	if book.Book.DurationMinutes.Valid {
+		fmt.Printf("Duration:  %s\n", models.FormatMinutes(int(book.Book.DurationMinutes.Int64)))
+	}
+
+	if book.Book.IsAudiobook() && book.ReadingEntry.CurrentMinutes.Valid {
+		fmt.Printf("Progress: %s\n", describeListeningProgress(book.ReadingEntry.CurrentMinutes.Int64, book.Book.DurationMinutes))
+	} else if book.ReadingEntry.CurrentPage.Valid {
+		fmt.Printf("Progress: %s\n", describePageProgress(book.ReadingEntry.CurrentPage.Int64, book.Book.Pages))
+	}
+
 	if book.ReadingEntry.StartedAt.Valid {
 		fmt.Printf("Started:  %s\n", book.ReadingEntry.StartedAt.Time.Format("Jan 02, 2006"))
 	}
@@ -48,7 +79,23 @@ func runShow(cmd *cobra.Command, args []string) error {
 	}
 
 	if book.ReadingEntry.Rating.Valid {
-		fmt.Printf("Rating: %d/5\n", book.ReadingEntry.Rating.Int64)
+		scale, err := db.GetRatingScale()
+		if err != nil {
+			return fmt.Errorf("failed to get rating scale: %w", err)
+		}
+		fmt.Printf("Rating: %s\n", scale.Format(book.ReadingEntry.Rating.Float64))
+	}
+
+	totals, err := db.GetSessionTotals(id)
+	if err != nil {
+		return fmt.Errorf("failed to get sessions: %w", err)
+	}
+	if totals.Sessions > 0 {
+		fmt.Printf("Sessions: %d", totals.Sessions)
+		if spent := describeSession(totals.Pages, totals.Minutes); spent != "" {
+			fmt.Printf(" (%s)", spent)
+		}
+		fmt.Println()
 	}
 
 	if book.Book.Description.Valid && book.Book.Description.String != "" {
@@ -59,6 +106,21 @@ func runShow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\nYour Review:\n%s\n", book.ReadingEntry.Review.String)
 	}
 
+	if totals.Sessions > 0 {
+		sessions, err := db.GetSessions(id)
+		if err != nil {
+			return fmt.Errorf("failed to get sessions: %w", err)
+		}
+		fmt.Println("\nReading Journal:")
+		for _, s := range sessions {
+			line := fmt.Sprintf("  %s  %s", s.Date.Format("Jan 02, 2006"), describeSession(int(s.Pages.Int64), int(s.Minutes.Int64)))
+			if s.Note.Valid && s.Note.String != "" {
+				line += " - " + s.Note.String
+			}
+			fmt.Println(line)
+		}
+	}
+
 	return nil
 }
 
@@ -4,44 +4,63 @@ import (
 	"bookshelf/internal/db"
 	"bookshelf/internal/models"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var startNoDate bool
+var startDate string
 
 var startCmd = &cobra.Command{
-	Use:   "start [id]",
+	Use:   "start [book]",
 	Short: "Mark a book as currently reading",
-	Long:  `Start reading a book. This will set its status to "reading" and record the start date.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runStart,
+	Long: `Start reading a book. This will set its status to "reading" and record the start date.
+
+Use --date to backdate the start, e.g. --date 2026-03-14 or --date "last tuesday".`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStart,
 }
 
 func init() {
 	startCmd.Flags().BoolVar(&startNoDate, "no-date", false, "Don't record the start date (for books started at an unknown time)")
+	startCmd.Flags().StringVarP(&startDate, "date", "d", "", "Start date, exact or fuzzy (e.g. 2026-03-14, \"last tuesday\")")
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
-	id, err := strconv.ParseInt(args[0], 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid book ID: %s", args[0])
+	if startNoDate && startDate != "" {
+		return fmt.Errorf("--date and --no-date cannot be used together")
 	}
 
-	exists, err := db.BookExists(id)
+	book, err := resolveBook(cmd.Context(), args[0])
 	if err != nil {
-		return fmt.Errorf("failed to check book: %w", err)
+		return err
 	}
-	if !exists {
-		return fmt.Errorf("book with ID %d not found", id)
+	id := book.Book.ID
+
+	var date *time.Time
+	if startDate != "" {
+		parsed, err := parseDateFlag(startDate)
+		if err != nil {
+			return err
+		}
+		if err := book.ReadingEntry.CheckStatusDate(models.StatusReading, parsed); err != nil {
+			return err
+		}
+		date = &parsed
+	} else if !startNoDate {
+		now := time.Now()
+		date = &now
 	}
 
-	if err := db.UpdateStatusWithDate(id, models.StatusReading, !startNoDate); err != nil {
+	if err := db.UpdateStatusOn(id, models.StatusReading, date); err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
 
-	book, _ := db.GetBook(id)
-	fmt.Printf("Started reading \"%s\"\n", book.Book.Title)
+	fmt.Printf("Started reading \"%s\"", book.Book.Title)
+	if startDate != "" {
+		fmt.Printf(" on %s", date.Format("Jan 02, 2006"))
+	}
+	fmt.Println()
 	return nil
 }
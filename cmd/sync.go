@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/importer"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Keep your shelf in step with other apps",
+}
+
+var syncCalibreCmd = &cobra.Command{
+	Use:   "calibre [library-dir]",
+	Short: "Add ebooks new to your Calibre library",
+	Long: `Add the ebooks added to your Calibre library since it was last imported or
+synced. Books already on your shelf are recognized by their Calibre ID and
+only have missing details filled in.
+
+The library folder defaults to the last one imported or synced (config key
+calibre.library).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSyncCalibre,
+}
+
+func init() {
+	syncCmd.AddCommand(syncCalibreCmd)
+}
+
+func runSyncCalibre(cmd *cobra.Command, args []string) error {
+	var dir string
+	if len(args) > 0 {
+		dir = args[0]
+	} else {
+		var err error
+		if dir, err = db.GetConfig("calibre.library"); err != nil {
+			return fmt.Errorf("failed to get config: %w", err)
+		}
+		if dir == "" {
+			return fmt.Errorf("no Calibre library to sync: run 'bookshelf import calibre <library-dir>' first, or give the folder")
+		}
+	}
+	return runCalibreImport(dir, "Synced")
+}
+
+// runCalibreImport imports a Calibre library and remembers it for sync.
+func runCalibreImport(dir, verb string) error {
+	records, err := importer.ParseCalibre(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read Calibre library: %w", err)
+	}
+
+	result, err := importer.Import(records)
+	if err != nil {
+		return err
+	}
+
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if err := db.SetConfig("calibre.library", dir); err != nil {
+		return fmt.Errorf("failed to remember the Calibre library: %w", err)
+	}
+
+	fmt.Printf("%s %d books from %s: %d created, %d updated, %d skipped.\n",
+		verb, len(records), dir, result.Created, result.Updated, result.Skipped)
+	return nil
+}
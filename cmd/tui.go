@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bookshelf/internal/tui"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and update your books in a full-screen terminal interface",
+	Long: `Browse your books in a full-screen list beside the selected book's
+details. Type / to filter by title or author and tab to switch shelves, then
+press s to start the book, f to finish it, r to rate it, w to review it, t to
+tag it and x to remove it. Press a to search for a book to add, and ? for
+every key.
+
+The list reloads every few seconds, so changes made with other commands show
+up too.`,
+	Args: cobra.NoArgs,
+	RunE: runTUI,
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	provider, err := metadataProvider()
+	if err != nil {
+		return err
+	}
+
+	model, err := tui.New(tui.WithProvider(provider))
+	if err != nil {
+		return err
+	}
+
+	program := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(cmd.Context()))
+	if _, err := program.Run(); err != nil {
+		return fmt.Errorf("terminal interface failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bookshelf/internal/server"
+	"bookshelf/internal/webui"
+	"fmt"
+	"maps"
+
+	"github.com/spf13/cobra"
+)
+
+var uiAddr string
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Manage your bookshelf in a web browser",
+	Long: `Start a local web app for your bookshelf, in the published site's style.
+Search for books to add, move them between shelves, rate them with the stars,
+write reviews, and set goals and config.
+
+It listens on localhost:8090 by default, and only answers requests to
+localhost. Every form carries a token, so other web pages can't change your
+books through it. It has no login, though, so think twice before listening on
+another address with --addr.
+
+Stop the server with Ctrl-C.`,
+	Args: cobra.NoArgs,
+	RunE: runUI,
+}
+
+func init() {
+	uiCmd.Flags().StringVar(&uiAddr, "addr", "localhost:8090", "Address to listen on")
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	provider, err := metadataProvider()
+	if err != nil {
+		return err
+	}
+
+	// The API token is a secret for other apps; don't show it in the browser.
+	configKeys := maps.Clone(validConfigKeys)
+	delete(configKeys, server.TokenConfigKey)
+
+	opts := []webui.Option{
+		webui.WithProvider(provider),
+		webui.WithConfigKeys(configKeys),
+		webui.WithConfigValidator(validateConfig),
+	}
+	if server.IsLoopbackHost(uiAddr) {
+		opts = append(opts, webui.WithLoopbackOnly())
+	}
+	handler, err := webui.New(opts...)
+	if err != nil {
+		return err
+	}
+
+	return serveUntilDone(cmd.Context(), uiAddr, handler, func(url string) {
+		fmt.Printf("Open %s in your browser\n", url)
+		if !server.IsLoopbackHost(uiAddr) {
+			fmt.Println("Warning: the web app has no login, so anyone who can reach this address can change your books.")
+		}
+	})
+}
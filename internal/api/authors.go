@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthorDoc is an author search result.
+type AuthorDoc struct {
+	Key       string `json:"key"` // Bare ID, e.g. "OL79034A"
+	Name      string `json:"name"`
+	BirthDate string `json:"birth_date"`
+	DeathDate string `json:"death_date"`
+	TopWork   string `json:"top_work"`
+	WorkCount int    `json:"work_count"`
+}
+
+type authorSearchResult struct {
+	NumFound int         `json:"numFound"`
+	Docs     []AuthorDoc `json:"docs"`
+}
+
+// Author is an Open Library author record and their works.
+type Author struct {
+	Key       string      `json:"key"`
+	Name      string      `json:"name"`
+	Bio       interface{} `json:"bio"`
+	BirthDate string      `json:"birth_date"`
+	DeathDate string      `json:"death_date"`
+	Photos    []int       `json:"photos"`
+
+	// Works is filled from the author's works listing, in Open Library's order.
+	Works []AuthorWork `json:"-"`
+}
+
+// AuthorWork is one entry in an author's works listing.
+type AuthorWork struct {
+	Key              string `json:"key"`
+	Title            string `json:"title"`
+	Covers           []int  `json:"covers"`
+	FirstPublishDate string `json:"first_publish_date"`
+}
+
+type authorWorksResult struct {
+	Size    int          `json:"size"`
+	Entries []AuthorWork `json:"entries"`
+}
+
+func (a *Author) BioText() *string {
+	return textValue(a.Bio)
+}
+
+// PhotoURL returns the author's first photo, or nil.
+func (a *Author) PhotoURL() *string {
+	// Open Library uses -1 for a removed photo
+	for _, id := range a.Photos {
+		if id > 0 {
+			url := fmt.Sprintf("%s/a/id/%d-M.jpg", coversURL, id)
+			return &url
+		}
+	}
+	return nil
+}
+
+// CoverID returns the work's first cover ID, or 0.
+func (w *AuthorWork) CoverID() int {
+	for _, id := range w.Covers {
+		if id > 0 {
+			return id
+		}
+	}
+	return 0
+}
+
+// SearchAuthors finds authors by name, best match first.
+func (c *Client) SearchAuthors(ctx context.Context, name string, limit int) ([]AuthorDoc, error) {
+	searchURL := fmt.Sprintf("%s/search/authors.json?q=%s&limit=%d", c.baseURL, url.QueryEscape(name), limit)
+
+	var result authorSearchResult
+	if err := c.getJSON(ctx, searchURL, "author search", &result); err != nil {
+		return nil, err
+	}
+	return result.Docs, nil
+}
+
+// GetAuthor fetches an author's details and the first 50 of their works. key
+// may be bare ("OL79034A") or a path ("/authors/OL79034A").
+func (c *Client) GetAuthor(ctx context.Context, key string) (*Author, error) {
+	if !strings.HasPrefix(key, "/authors/") {
+		key = "/authors/" + key
+	}
+
+	var author Author
+	if err := c.getJSON(ctx, fmt.Sprintf("%s%s.json", c.baseURL, key), "author", &author); err != nil {
+		return nil, err
+	}
+
+	var works authorWorksResult
+	if err := c.getJSON(ctx, fmt.Sprintf("%s%s/works.json?limit=50", c.baseURL, key), "author works", &works); err != nil {
+		return nil, err
+	}
+	author.Works = works.Entries
+
+	return &author, nil
+}
+
+// getJSON fetches url and decodes the response into v. what names the
+// resource in errors.
+func (c *Client) getJSON(ctx context.Context, url, what string, v any) error {
+	resp, err := getWithRetry(ctx, c.httpClient, url)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", what, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", what, err)
+	}
+	return nil
+}
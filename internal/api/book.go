@@ -0,0 +1,46 @@
+package api
+
+import (
+	"bookshelf/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// BookFromSearch builds the book to add for a search result, with the work's
+// description and top five subjects as genres when the provider has them.
+// Failing to fetch the work's details isn't an error; the book just has less
+// to it.
+func BookFromSearch(ctx context.Context, client MetadataProvider, doc SearchDoc) models.Book {
+	book := models.Book{
+		Title:          doc.Title,
+		Author:         doc.Author(),
+		ISBN:           nullString(doc.FirstISBN()),
+		CoverURL:       nullString(doc.CoverURL()),
+		OpenLibraryKey: nullString(doc.OpenLibraryKey()),
+	}
+	if pages := doc.Pages(); pages != nil {
+		book.Pages = sql.NullInt64{Int64: int64(*pages), Valid: true}
+	}
+
+	if doc.Key != "" {
+		if work, err := client.GetWorkDetails(ctx, doc.Key); err == nil {
+			book.Description = nullString(work.DescriptionText())
+			if subjects := work.TopSubjects(5); len(subjects) > 0 {
+				if jsonBytes, err := json.Marshal(subjects); err == nil {
+					book.Genres = sql.NullString{String: string(jsonBytes), Valid: true}
+				}
+			}
+		}
+	}
+	return book
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{}
+	}
+	trimmed := strings.TrimSpace(*s)
+	return sql.NullString{String: trimmed, Valid: trimmed != ""}
+}
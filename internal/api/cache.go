@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrOffline is returned for requests that miss the cache in offline mode.
+var ErrOffline = errors.New("not in the offline cache (run without --offline to fetch it)")
+
+// Cache is an http.RoundTripper that stores successful GET responses on disk,
+// keyed by URL without its API key (see cacheKey). Entries younger than TTL are served without a request; older
+// ones are revalidated with their ETag or Last-Modified date. In offline mode
+// every response comes from the cache, however old.
+type Cache struct {
+	Dir     string
+	TTL     time.Duration
+	Offline bool
+
+	// Transport makes the actual requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	now func() time.Time
+}
+
+// CacheStats describes the contents of the cache directory.
+type CacheStats struct {
+	Entries int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	Body         []byte    `json:"body"`
+}
+
+// NewCache returns a cache rooted at dir.
+func NewCache(dir string, ttl time.Duration, offline bool) *Cache {
+	return &Cache{Dir: dir, TTL: ttl, Offline: offline}
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		if c.Offline {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrOffline)
+		}
+		return c.transport().RoundTrip(req)
+	}
+
+	key := cacheKey(req.URL)
+	entry, err := c.load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Offline {
+		if entry == nil {
+			return nil, ErrOffline
+		}
+		return entry.response(req), nil
+	}
+	if entry != nil && c.clock().Sub(entry.StoredAt) < c.TTL {
+		return entry.response(req), nil
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := c.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		resp.Body.Close()
+		entry.StoredAt = c.clock()
+		if err := c.store(entry); err != nil {
+			return nil, err
+		}
+		return entry.response(req), nil
+
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		entry = &cacheEntry{
+			URL:          key,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ContentType:  resp.Header.Get("Content-Type"),
+			StoredAt:     c.clock(),
+			Body:         body,
+		}
+		if err := c.store(entry); err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
+
+// Stats counts the cached responses and their size on disk.
+func (c *Cache) Stats() (CacheStats, error) {
+	var stats CacheStats
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if mod := info.ModTime(); stats.Oldest.IsZero() || mod.Before(stats.Oldest) {
+			stats.Oldest = mod
+		}
+		if mod := info.ModTime(); mod.After(stats.Newest) {
+			stats.Newest = mod
+		}
+	}
+	return stats, nil
+}
+
+// Clear deletes every cached response and returns how many were removed.
+func (c *Cache) Clear() (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	for i, path := range files {
+		if err := os.Remove(path); err != nil {
+			return i, err
+		}
+	}
+	return len(files), nil
+}
+
+func (c *Cache) files() ([]string, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(c.Dir, e.Name()))
+		}
+	}
+	return files, nil
+}
+
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// secretParams are query parameters holding credentials, like Google Books'
+// API key. They're left out of cache keys so they aren't written to disk.
+var secretParams = []string{"key"}
+
+// cacheKey is the URL a response is cached under: u without secretParams.
+// The response doesn't depend on them, so a new API key still hits the cache.
+func cacheKey(u *url.URL) string {
+	query := u.Query()
+	found := false
+	for _, name := range secretParams {
+		if query.Has(name) {
+			query.Del(name)
+			found = true
+		}
+	}
+	if !found {
+		return u.String()
+	}
+	stripped := *u
+	stripped.RawQuery = query.Encode()
+	return stripped.String()
+}
+
+// load returns the cached entry for key, or nil if there isn't a usable one.
+func (c *Cache) load(key string) (*cacheEntry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != key {
+		// A corrupt or colliding entry is just a miss
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (c *Cache) store(entry *cacheEntry) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a half-written entry
+	path := c.path(entry.URL)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (c *Cache) transport() http.RoundTripper {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	header.Set("X-Bookshelf-Cache", "hit")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
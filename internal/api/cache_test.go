@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheServesFreshEntries(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(`{"title": "Dune"}`))
+	}))
+	defer server.Close()
+
+	cache := NewCache(t.TempDir(), time.Hour, false)
+	client := &http.Client{Transport: cache}
+
+	for i := 0; i < 3; i++ {
+		if body := get(t, client, server.URL+"/works/OL1W.json"); body != `{"title": "Dune"}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("expected 1 request to the server, got %d", hits)
+	}
+
+	// Different URLs are cached separately
+	get(t, client, server.URL+"/works/OL2W.json")
+	if hits != 2 {
+		t.Errorf("expected 2 requests to the server, got %d", hits)
+	}
+}
+
+func TestCacheLeavesOutAPIKeys(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(`{"items": []}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewCache(dir, time.Hour, false)}
+
+	get(t, client, server.URL+"/volumes?q=dune&key=secret-one")
+	get(t, client, server.URL+"/volumes?key=secret-two&q=dune")
+	if hits != 1 {
+		t.Errorf("expected the key not to change the cache entry, got %d requests", hits)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 cache file, got %d", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("API key written to the cache: %s", data)
+	}
+}
+
+func TestCacheRevalidatesStaleEntries(t *testing.T) {
+	hits, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("original"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := NewCache(t.TempDir(), time.Hour, false)
+	cache.now = func() time.Time { return now }
+	client := &http.Client{Transport: cache}
+
+	get(t, client, server.URL)
+	now = now.Add(2 * time.Hour)
+
+	if body := get(t, client, server.URL); body != "original" {
+		t.Errorf("expected cached body after 304, got %s", body)
+	}
+	if hits != 2 || notModified != 1 {
+		t.Errorf("expected one revalidation, got %d hits and %d 304s", hits, notModified)
+	}
+
+	// The 304 refreshed the entry, so it's fresh again
+	get(t, client, server.URL)
+	if hits != 2 {
+		t.Errorf("expected revalidated entry to be fresh, got %d hits", hits)
+	}
+}
+
+func TestCacheDoesNotStoreErrors(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cache := NewCache(t.TempDir(), time.Hour, false)
+	client := &http.Client{Transport: cache}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if hits != 2 {
+		t.Errorf("expected error responses not to be cached, got %d hits", hits)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte("cached"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	now := time.Now()
+	online := NewCache(dir, time.Hour, false)
+	get(t, &http.Client{Transport: online}, server.URL+"/a")
+
+	offline := NewCache(dir, time.Hour, true)
+	offline.now = func() time.Time { return now.Add(48 * time.Hour) }
+	client := &http.Client{Transport: offline}
+
+	if body := get(t, client, server.URL+"/a"); body != "cached" {
+		t.Errorf("expected stale entry served offline, got %s", body)
+	}
+
+	_, err := client.Get(server.URL + "/b")
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("expected ErrOffline for a cache miss, got %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("expected no requests while offline, got %d", hits-1)
+	}
+}
+
+func TestCacheStatsAndClear(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	cache := NewCache(t.TempDir(), time.Hour, false)
+	client := &http.Client{Transport: cache}
+
+	stats, err := cache.Stats()
+	if err != nil || stats.Entries != 0 {
+		t.Fatalf("expected empty cache, got %+v, %v", stats, err)
+	}
+
+	get(t, client, server.URL+"/a")
+	get(t, client, server.URL+"/b")
+
+	stats, err = cache.Stats()
+	if err != nil {
+		t.Fatalf("stats failed: %v", err)
+	}
+	if stats.Entries != 2 || stats.Bytes == 0 {
+		t.Errorf("expected 2 entries, got %+v", stats)
+	}
+
+	removed, err := cache.Clear()
+	if err != nil || removed != 2 {
+		t.Errorf("expected 2 entries removed, got %d, %v", removed, err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 0 {
+		t.Errorf("expected empty cache after clear, got %d entries", stats.Entries)
+	}
+}
+
+func TestNewProviderUsesCache(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(`{"numFound": 0, "docs": []}`))
+	}))
+	defer server.Close()
+
+	oldBaseURL := baseURL
+	baseURL = server.URL
+	defer func() { baseURL = oldBaseURL }()
+
+	provider, err := NewProvider(ProviderOpenLibrary, ProviderOptions{Cache: NewCache(t.TempDir(), time.Hour, false)})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	provider.Search(context.Background(), SearchQuery{Text: "dune", Limit: 5})
+	provider.Search(context.Background(), SearchQuery{Text: "dune", Limit: 5})
+	if hits != 1 {
+		t.Errorf("expected repeated search to be cached, got %d hits", hits)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	return string(body)
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var googleBooksURL = "https://www.googleapis.com/books/v1"
+
+// googleKeyPrefix marks keys issued by Google Books so they can't be mistaken
+// for Open Library keys.
+const googleKeyPrefix = ProviderGoogleBooks + ":"
+
+// googleBooksRate keeps well inside Google's per-user request quota.
+const googleBooksRate = 5
+
+// GoogleBooksClient looks up books in the Google Books API. An API key is
+// optional but raises the daily quota.
+type GoogleBooksClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+var _ MetadataProvider = (*GoogleBooksClient)(nil)
+
+func NewGoogleBooksClient(apiKey string, opts ...Option) *GoogleBooksClient {
+	config := newClientConfig(googleBooksURL, opts)
+	return &GoogleBooksClient{
+		httpClient: config.httpClient(NewRateLimiter(googleBooksRate, googleBooksRate)),
+		baseURL:    strings.TrimRight(config.baseURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+type googleVolumes struct {
+	TotalItems int            `json:"totalItems"`
+	Items      []googleVolume `json:"items"`
+}
+
+type googleVolume struct {
+	ID         string `json:"id"`
+	VolumeInfo struct {
+		Title               string   `json:"title"`
+		Subtitle            string   `json:"subtitle"`
+		Authors             []string `json:"authors"`
+		Publisher           string   `json:"publisher"`
+		Language            string   `json:"language"`
+		PublishedDate       string   `json:"publishedDate"`
+		Description         string   `json:"description"`
+		PageCount           int      `json:"pageCount"`
+		Categories          []string `json:"categories"`
+		IndustryIdentifiers []struct {
+			Type       string `json:"type"`
+			Identifier string `json:"identifier"`
+		} `json:"industryIdentifiers"`
+		ImageLinks struct {
+			Thumbnail string `json:"thumbnail"`
+		} `json:"imageLinks"`
+	} `json:"volumeInfo"`
+}
+
+// searchDoc converts a volume to the provider-neutral search result.
+func (v *googleVolume) searchDoc() SearchDoc {
+	info := v.VolumeInfo
+	doc := SearchDoc{
+		Key:           googleKeyPrefix + v.ID,
+		Title:         info.Title,
+		AuthorName:    info.Authors,
+		NumberOfPages: info.PageCount,
+		Subject:       googleSubjects(info.Categories),
+		// Google serves thumbnails over plain http by default
+		CoverImage: strings.Replace(info.ImageLinks.Thumbnail, "http://", "https://", 1),
+	}
+	if len(info.PublishedDate) >= 4 {
+		doc.FirstPublishYear, _ = strconv.Atoi(info.PublishedDate[:4])
+	}
+	// ISBN-13 first, it's what most shelves are keyed by
+	for _, kind := range []string{"ISBN_13", "ISBN_10"} {
+		for _, id := range info.IndustryIdentifiers {
+			if id.Type == kind {
+				doc.ISBN = append(doc.ISBN, id.Identifier)
+			}
+		}
+	}
+	return doc
+}
+
+func (g *GoogleBooksClient) Name() string {
+	return ProviderGoogleBooks
+}
+
+// Search maps the query's fields onto Google's intitle:, inauthor:, subject:
+// and isbn: keywords. Google can only sort by newest, and has no year filter,
+// so the year is applied to the results.
+func (g *GoogleBooksClient) Search(ctx context.Context, q SearchQuery) ([]SearchDoc, error) {
+	// Google caps maxResults at 40
+	limit := q.Limit
+	if limit > 40 {
+		limit = 40
+	}
+
+	terms := []string{strings.TrimSpace(q.Text)}
+	for _, f := range []struct{ keyword, value string }{
+		{"intitle:", q.Title}, {"inauthor:", q.Author}, {"subject:", q.Subject}, {"isbn:", q.ISBN},
+	} {
+		if f.value != "" {
+			terms = append(terms, f.keyword+f.value)
+		}
+	}
+	params := url.Values{
+		"q":          {strings.TrimSpace(strings.Join(terms, " "))},
+		"maxResults": {strconv.Itoa(limit)},
+		"startIndex": {strconv.Itoa((q.page() - 1) * limit)},
+	}
+	if lang := googleLanguage(q.Language); lang != "" {
+		params.Set("langRestrict", lang)
+	}
+	if q.Sort == SortNew {
+		params.Set("orderBy", "newest")
+	}
+
+	var volumes googleVolumes
+	if err := g.get(ctx, "/volumes", params, &volumes); err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	docs := make([]SearchDoc, 0, len(volumes.Items))
+	for _, v := range volumes.Items {
+		doc := v.searchDoc()
+		if q.Year > 0 && doc.FirstPublishYear != q.Year {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// marcToISO maps the commonest MARC language codes, which Open Library uses,
+// to the ISO 639-1 codes Google expects.
+var marcToISO = map[string]string{
+	"eng": "en", "fre": "fr", "ger": "de", "spa": "es", "ita": "it", "por": "pt",
+	"dut": "nl", "swe": "sv", "rus": "ru", "jpn": "ja", "chi": "zh", "pol": "pl",
+}
+
+// googleLanguage converts a MARC language code for langRestrict. Two-letter
+// codes are passed through; unknown ones are dropped.
+func googleLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	if len(lang) == 2 {
+		return lang
+	}
+	return marcToISO[lang]
+}
+
+func (g *GoogleBooksClient) LookupISBN(ctx context.Context, isbn string) (*SearchDoc, error) {
+	var volumes googleVolumes
+	if err := g.get(ctx, "/volumes", url.Values{"q": {"isbn:" + isbn}}, &volumes); err != nil {
+		return nil, fmt.Errorf("failed to look up ISBN: %w", err)
+	}
+	if len(volumes.Items) == 0 {
+		return nil, nil
+	}
+	doc := volumes.Items[0].searchDoc()
+	return &doc, nil
+}
+
+// GetWorkDetails fetches a volume by the key returned from Search or LookupISBN.
+func (g *GoogleBooksClient) GetWorkDetails(ctx context.Context, key string) (*WorkDetails, error) {
+	id, ok := strings.CutPrefix(key, googleKeyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("not a Google Books key: %s", key)
+	}
+
+	var volume googleVolume
+	if err := g.get(ctx, "/volumes/"+url.PathEscape(id), nil, &volume); err != nil {
+		return nil, fmt.Errorf("failed to get work details: %w", err)
+	}
+
+	work := &WorkDetails{
+		Key:      key,
+		Title:    volume.VolumeInfo.Title,
+		Subjects: googleSubjects(volume.VolumeInfo.Categories),
+	}
+	if volume.VolumeInfo.Description != "" {
+		work.Description = volume.VolumeInfo.Description
+	}
+	return work, nil
+}
+
+// GetEditions returns the volume itself: Google Books has no notion of works,
+// so each volume is a single edition.
+func (g *GoogleBooksClient) GetEditions(ctx context.Context, key string) ([]Edition, error) {
+	id, ok := strings.CutPrefix(key, googleKeyPrefix)
+	if !ok {
+		return nil, fmt.Errorf("not a Google Books key: %s", key)
+	}
+
+	var volume googleVolume
+	if err := g.get(ctx, "/volumes/"+url.PathEscape(id), nil, &volume); err != nil {
+		return nil, fmt.Errorf("failed to get editions: %w", err)
+	}
+	return []Edition{volume.edition()}, nil
+}
+
+func (v *googleVolume) edition() Edition {
+	info := v.VolumeInfo
+	doc := v.searchDoc()
+	edition := Edition{
+		Key:           doc.Key,
+		Title:         info.Title,
+		PublishDate:   info.PublishedDate,
+		NumberOfPages: info.PageCount,
+		CoverImage:    doc.CoverImage,
+	}
+	if info.Publisher != "" {
+		edition.Publishers = []string{info.Publisher}
+	}
+	if info.Language != "" {
+		edition.Languages = append(edition.Languages, struct {
+			Key string `json:"key"`
+		}{Key: info.Language})
+	}
+	for _, id := range info.IndustryIdentifiers {
+		switch id.Type {
+		case "ISBN_13":
+			edition.ISBN13 = append(edition.ISBN13, id.Identifier)
+		case "ISBN_10":
+			edition.ISBN10 = append(edition.ISBN10, id.Identifier)
+		}
+	}
+	return edition
+}
+
+// googleSubjects splits categories like "Fiction / Science Fiction / General"
+// into distinct subjects.
+func googleSubjects(categories []string) []string {
+	var subjects []string
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		for _, part := range strings.Split(category, "/") {
+			part = strings.TrimSpace(part)
+			if part == "" || part == "General" || seen[part] {
+				continue
+			}
+			seen[part] = true
+			subjects = append(subjects, part)
+		}
+	}
+	return subjects
+}
+
+func (g *GoogleBooksClient) get(ctx context.Context, path string, params url.Values, v interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if g.apiKey != "" {
+		params.Set("key", g.apiKey)
+	}
+	reqURL := g.baseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	resp, err := getWithRetry(ctx, g.httpClient, reqURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Google Books returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
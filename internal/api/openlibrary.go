@@ -1,25 +1,51 @@
 package api
 
 import (
+	"bookshelf/internal/models"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
-	"time"
 )
 
-var baseURL = "https://openlibrary.org"
+// baseURL and coversURL locate Open Library. BOOKSHELF_OPENLIBRARY_URL points
+// both at another server, such as the fake one in internal/testutil.
+var baseURL, coversURL = openLibraryURLs()
 
+func openLibraryURLs() (string, string) {
+	if url := strings.TrimRight(os.Getenv("BOOKSHELF_OPENLIBRARY_URL"), "/"); url != "" {
+		return url, url
+	}
+	return "https://openlibrary.org", "https://covers.openlibrary.org"
+}
+
+// CoverImageURL returns the medium-sized image for an Open Library cover ID.
+func CoverImageURL(id int) string {
+	return fmt.Sprintf("%s/b/id/%d-M.jpg", coversURL, id)
+}
+
+// Open Library asks clients to stay at or below a few requests per second.
+const openLibraryRate = 3
+
+// Client talks to the Open Library API. It implements MetadataProvider.
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 }
 
-func NewClient() *Client {
+var _ MetadataProvider = (*Client)(nil)
+
+// NewClient returns an Open Library client, rate limited to what Open Library
+// asks for, configured by opts.
+func NewClient(opts ...Option) *Client {
+	config := newClientConfig(baseURL, opts)
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpClient: config.httpClient(NewRateLimiter(openLibraryRate, openLibraryRate)),
+		baseURL:    strings.TrimRight(config.baseURL, "/"),
 	}
 }
 
@@ -36,6 +62,11 @@ type SearchDoc struct {
 	ISBN             []string `json:"isbn"`
 	NumberOfPages    int      `json:"number_of_pages_median"`
 	CoverI           int      `json:"cover_i"`
+	EditionCount     int      `json:"edition_count"`
+	Subject          []string `json:"subject"`
+
+	// CoverImage is a full cover URL for providers that don't use Open Library cover IDs.
+	CoverImage string `json:"-"`
 }
 
 func (d *SearchDoc) Author() string {
@@ -53,8 +84,11 @@ func (d *SearchDoc) FirstISBN() *string {
 }
 
 func (d *SearchDoc) CoverURL() *string {
+	if d.CoverImage != "" {
+		return &d.CoverImage
+	}
 	if d.CoverI > 0 {
-		url := fmt.Sprintf("https://covers.openlibrary.org/b/id/%d-M.jpg", d.CoverI)
+		url := CoverImageURL(d.CoverI)
 		return &url
 	}
 	return nil
@@ -67,11 +101,63 @@ func (d *SearchDoc) Pages() *int {
 	return nil
 }
 
-func (c *Client) Search(query string, limit int) ([]SearchDoc, error) {
-	encodedQuery := url.QueryEscape(query)
-	searchURL := fmt.Sprintf("%s/search.json?q=%s&limit=%d", baseURL, encodedQuery, limit)
+// OpenLibraryKey returns the work key if the result came from Open Library.
+func (d *SearchDoc) OpenLibraryKey() *string {
+	if strings.HasPrefix(d.Key, "/works/") {
+		return &d.Key
+	}
+	return nil
+}
+
+// Name identifies Open Library in the --provider flag and config.
+func (c *Client) Name() string {
+	return ProviderOpenLibrary
+}
+
+// searchFields are the fields requested from search.json: what SearchDoc reads.
+const searchFields = "key,title,author_name,first_publish_year,isbn,number_of_pages_median,cover_i,edition_count,subject"
+
+// Search maps the query's fields onto Open Library's search parameters. The
+// year has no parameter of its own, so it's added to q as a field query.
+func (c *Client) Search(ctx context.Context, q SearchQuery) ([]SearchDoc, error) {
+	params := url.Values{}
+	text := strings.TrimSpace(q.Text)
+	if q.Year > 0 {
+		text = strings.TrimSpace(fmt.Sprintf("%s first_publish_year:%d", text, q.Year))
+	}
+	if text != "" {
+		params.Set("q", text)
+	}
+	for name, value := range map[string]string{
+		"title": q.Title, "author": q.Author, "subject": q.Subject, "isbn": q.ISBN, "language": q.Language, "sort": q.Sort,
+	} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+	if q.page() > 1 {
+		params.Set("page", strconv.Itoa(q.page()))
+	}
+	params.Set("limit", strconv.Itoa(q.Limit))
+	params.Set("fields", searchFields)
 
-	resp, err := c.httpClient.Get(searchURL)
+	return c.search(ctx, c.baseURL+"/search.json?"+params.Encode())
+}
+
+// LookupISBN finds the work an ISBN belongs to. Returns nil if Open Library doesn't know it.
+func (c *Client) LookupISBN(ctx context.Context, isbn string) (*SearchDoc, error) {
+	docs, err := c.search(ctx, fmt.Sprintf("%s/search.json?isbn=%s&limit=1", c.baseURL, url.QueryEscape(isbn)))
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	return &docs[0], nil
+}
+
+func (c *Client) search(ctx context.Context, searchURL string) ([]SearchDoc, error) {
+	resp, err := getWithRetry(ctx, c.httpClient, searchURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search: %w", err)
 	}
@@ -97,7 +183,13 @@ type WorkDetails struct {
 }
 
 func (w *WorkDetails) DescriptionText() *string {
-	switch v := w.Description.(type) {
+	return textValue(w.Description)
+}
+
+// textValue reads Open Library's text fields, which are either a plain string
+// or a {"type": "/type/text", "value": "..."} object.
+func textValue(field interface{}) *string {
+	switch v := field.(type) {
 	case string:
 		return &v
 	case map[string]interface{}:
@@ -116,13 +208,13 @@ func (w *WorkDetails) TopSubjects(max int) []string {
 	return w.Subjects[:max]
 }
 
-func (c *Client) GetWorkDetails(key string) (*WorkDetails, error) {
+func (c *Client) GetWorkDetails(ctx context.Context, key string) (*WorkDetails, error) {
 	if !strings.HasPrefix(key, "/works/") {
 		key = "/works/" + key
 	}
-	workURL := fmt.Sprintf("%s%s.json", baseURL, key)
+	workURL := fmt.Sprintf("%s%s.json", c.baseURL, key)
 
-	resp, err := c.httpClient.Get(workURL)
+	resp, err := getWithRetry(ctx, c.httpClient, workURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get work details: %w", err)
 	}
@@ -139,3 +231,130 @@ func (c *Client) GetWorkDetails(key string) (*WorkDetails, error) {
 
 	return &work, nil
 }
+
+// Edition is one published edition of a work.
+type Edition struct {
+	Key            string   `json:"key"`
+	Title          string   `json:"title"`
+	Publishers     []string `json:"publishers"`
+	PublishDate    string   `json:"publish_date"`
+	NumberOfPages  int      `json:"number_of_pages"`
+	Covers         []int    `json:"covers"`
+	ISBN13         []string `json:"isbn_13"`
+	ISBN10         []string `json:"isbn_10"`
+	PhysicalFormat string   `json:"physical_format"`
+	Languages      []struct {
+		Key string `json:"key"`
+	} `json:"languages"`
+
+	// CoverImage is a full cover URL for providers that don't use Open Library cover IDs.
+	CoverImage string `json:"-"`
+}
+
+type editionsResult struct {
+	Size    int       `json:"size"`
+	Entries []Edition `json:"entries"`
+}
+
+// ISBNs returns the edition's ISBN-13s followed by its ISBN-10s.
+func (e *Edition) ISBNs() []string {
+	return append(append([]string{}, e.ISBN13...), e.ISBN10...)
+}
+
+// Publisher returns the first listed publisher, or "".
+func (e *Edition) Publisher() string {
+	if len(e.Publishers) > 0 {
+		return e.Publishers[0]
+	}
+	return ""
+}
+
+// Language returns the first language's MARC code, e.g. "eng", or "".
+func (e *Edition) Language() string {
+	if len(e.Languages) > 0 {
+		return strings.TrimPrefix(e.Languages[0].Key, "/languages/")
+	}
+	return ""
+}
+
+// Format maps the edition's physical format to a models.BookFormat value, or "".
+func (e *Edition) Format() string {
+	return string(models.ParseBookFormat(e.PhysicalFormat))
+}
+
+func (e *Edition) Pages() *int {
+	if e.NumberOfPages > 0 {
+		return &e.NumberOfPages
+	}
+	return nil
+}
+
+func (e *Edition) CoverURL() *string {
+	if e.CoverImage != "" {
+		return &e.CoverImage
+	}
+	// Open Library uses -1 for a removed cover
+	for _, id := range e.Covers {
+		if id > 0 {
+			url := CoverImageURL(id)
+			return &url
+		}
+	}
+	return nil
+}
+
+// PickEdition chooses the edition to take pages and covers from: the one with
+// editionKey, then the one matching isbn, otherwise the first with a page
+// count. Returns nil if there are no editions.
+func PickEdition(editions []Edition, editionKey, isbn string) *Edition {
+	if editionKey != "" {
+		for i := range editions {
+			if editions[i].Key == editionKey {
+				return &editions[i]
+			}
+		}
+	}
+	if want := NormalizeISBN(isbn); want != "" {
+		for i := range editions {
+			for _, have := range editions[i].ISBNs() {
+				if NormalizeISBN(have) == want {
+					return &editions[i]
+				}
+			}
+		}
+	}
+	for i := range editions {
+		if editions[i].NumberOfPages > 0 {
+			return &editions[i]
+		}
+	}
+	if len(editions) > 0 {
+		return &editions[0]
+	}
+	return nil
+}
+
+// GetEditions lists the editions of a work, as Open Library orders them.
+func (c *Client) GetEditions(ctx context.Context, key string) ([]Edition, error) {
+	if !strings.HasPrefix(key, "/works/") {
+		key = "/works/" + key
+	}
+	editionsURL := fmt.Sprintf("%s%s/editions.json?limit=50", c.baseURL, key)
+
+	resp, err := getWithRetry(ctx, c.httpClient, editionsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get editions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("editions returned status %d", resp.StatusCode)
+	}
+
+	var result editionsResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode editions: %w", err)
+	}
+
+	return result.Entries, nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -146,7 +147,7 @@ func TestClientSearch(t *testing.T) {
 	defer func() { baseURL = oldBaseURL }()
 
 	client := NewClient()
-	docs, err := client.Search("test query", 5)
+	docs, err := client.Search(context.Background(), SearchQuery{Text: "test query", Limit: 5})
 	if err != nil {
 		t.Fatalf("search failed: %v", err)
 	}
@@ -161,6 +162,8 @@ func TestClientSearch(t *testing.T) {
 }
 
 func TestClientSearchError(t *testing.T) {
+	fastRetries(t)
+
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}))
@@ -171,7 +174,7 @@ func TestClientSearchError(t *testing.T) {
 	defer func() { baseURL = oldBaseURL }()
 
 	client := NewClient()
-	_, err := client.Search("test", 5)
+	_, err := client.Search(context.Background(), SearchQuery{Text: "test", Limit: 5})
 	if err == nil {
 		t.Error("expected error for 500 response")
 	}
@@ -225,7 +228,7 @@ func TestClientGetWorkDetails(t *testing.T) {
 	client := NewClient()
 
 	// Test with /works/ prefix
-	work, err := client.GetWorkDetails("/works/OL123")
+	work, err := client.GetWorkDetails(context.Background(), "/works/OL123")
 	if err != nil {
 		t.Fatalf("failed to get work details: %v", err)
 	}
@@ -253,7 +256,7 @@ func TestClientGetWorkDetailsWithoutPrefix(t *testing.T) {
 	client := NewClient()
 
 	// Test without /works/ prefix
-	work, err := client.GetWorkDetails("OL456")
+	work, err := client.GetWorkDetails(context.Background(), "OL456")
 	if err != nil {
 		t.Fatalf("failed to get work details: %v", err)
 	}
@@ -263,6 +266,50 @@ func TestClientGetWorkDetailsWithoutPrefix(t *testing.T) {
 	}
 }
 
+func TestClientLookupISBN(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("isbn") != "9780441013593" {
+			json.NewEncoder(w).Encode(SearchResult{})
+			return
+		}
+		json.NewEncoder(w).Encode(SearchResult{
+			NumFound: 1,
+			Docs:     []SearchDoc{{Key: "/works/OL893415W", Title: "Dune", AuthorName: []string{"Frank Herbert"}}},
+		})
+	}))
+	defer server.Close()
+
+	oldBaseURL := baseURL
+	baseURL = server.URL
+	defer func() { baseURL = oldBaseURL }()
+
+	client := NewClient()
+	doc, err := client.LookupISBN(context.Background(), "9780441013593")
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if doc == nil || doc.Title != "Dune" {
+		t.Errorf("expected Dune, got %+v", doc)
+	}
+
+	doc, err = client.LookupISBN(context.Background(), "0000000000")
+	if err != nil || doc != nil {
+		t.Errorf("expected nil for unknown ISBN, got %+v, %v", doc, err)
+	}
+}
+
+func TestSearchDocOpenLibraryKey(t *testing.T) {
+	doc := SearchDoc{Key: "/works/OL1W"}
+	if key := doc.OpenLibraryKey(); key == nil || *key != "/works/OL1W" {
+		t.Errorf("expected Open Library key, got %v", key)
+	}
+
+	doc = SearchDoc{Key: "google:abc"}
+	if key := doc.OpenLibraryKey(); key != nil {
+		t.Errorf("expected nil for Google key, got %s", *key)
+	}
+}
+
 // Helper functions
 func strPtr(s string) *string {
 	return &s
@@ -271,3 +318,147 @@ func strPtr(s string) *string {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestClientGetEditions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works/OL1W/editions.json" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"size": 2, "entries": [
+			{"key": "/books/OL1M", "title": "Dune", "number_of_pages": 412, "covers": [-1, 42], "isbn_13": ["9780441013593"], "publishers": ["Ace"]},
+			{"key": "/books/OL2M", "title": "Dune", "isbn_10": ["0340960191"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	oldBaseURL := baseURL
+	baseURL = server.URL
+	defer func() { baseURL = oldBaseURL }()
+
+	editions, err := NewClient().GetEditions(context.Background(), "OL1W")
+	if err != nil {
+		t.Fatalf("failed to get editions: %v", err)
+	}
+	if len(editions) != 2 {
+		t.Fatalf("expected 2 editions, got %d", len(editions))
+	}
+	if pages := editions[0].Pages(); pages == nil || *pages != 412 {
+		t.Errorf("expected 412 pages, got %v", pages)
+	}
+	if cover := editions[0].CoverURL(); cover == nil || *cover != "https://covers.openlibrary.org/b/id/42-M.jpg" {
+		t.Errorf("expected removed cover skipped, got %v", cover)
+	}
+	if editions[1].CoverURL() != nil || editions[1].Pages() != nil {
+		t.Error("expected no cover or pages for second edition")
+	}
+}
+
+func TestPickEdition(t *testing.T) {
+	editions := []Edition{
+		{Key: "/books/OL1M"},
+		{Key: "/books/OL2M", NumberOfPages: 300},
+		{Key: "/books/OL3M", NumberOfPages: 412, ISBN10: []string{"0441013597"}},
+	}
+
+	if e := PickEdition(editions, "", "978-0-441-01359-3"); e == nil || e.Key != "/books/OL3M" {
+		t.Errorf("expected edition matching the ISBN, got %v", e)
+	}
+	if e := PickEdition(editions, "", ""); e == nil || e.Key != "/books/OL2M" {
+		t.Errorf("expected first edition with pages, got %v", e)
+	}
+	if e := PickEdition(editions[:1], "", "9999999999999"); e == nil || e.Key != "/books/OL1M" {
+		t.Errorf("expected fallback to first edition, got %v", e)
+	}
+	if e := PickEdition(editions, "/books/OL1M", "9780441013593"); e == nil || e.Key != "/books/OL1M" {
+		t.Errorf("expected the stored edition to win, got %v", e)
+	}
+	if e := PickEdition(nil, "", ""); e != nil {
+		t.Errorf("expected nil for no editions, got %v", e)
+	}
+}
+
+func TestClientGetAuthor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/authors.json":
+			if q := r.URL.Query().Get("q"); q != "frank herbert" {
+				t.Errorf("unexpected query: %s", q)
+			}
+			w.Write([]byte(`{"numFound": 1, "docs": [{"key": "OL79034A", "name": "Frank Herbert", "work_count": 120}]}`))
+		case "/authors/OL79034A.json":
+			w.Write([]byte(`{"key": "/authors/OL79034A", "name": "Frank Herbert",
+				"bio": {"type": "/type/text", "value": "American science fiction author."},
+				"birth_date": "8 October 1920", "death_date": "11 February 1986", "photos": [-1, 6257741]}`))
+		case "/authors/OL79034A/works.json":
+			w.Write([]byte(`{"size": 2, "entries": [
+				{"key": "/works/OL893415W", "title": "Dune", "covers": [11481354], "first_publish_date": "1965"},
+				{"key": "/works/OL893527W", "title": "Dune Messiah"}
+			]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldBaseURL := baseURL
+	baseURL = server.URL
+	defer func() { baseURL = oldBaseURL }()
+
+	client := NewClient()
+	docs, err := client.SearchAuthors(context.Background(), "frank herbert", 1)
+	if err != nil {
+		t.Fatalf("failed to search authors: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Key != "OL79034A" {
+		t.Fatalf("unexpected search results: %+v", docs)
+	}
+
+	author, err := client.GetAuthor(context.Background(), docs[0].Key)
+	if err != nil {
+		t.Fatalf("failed to get author: %v", err)
+	}
+	if author.Name != "Frank Herbert" || author.BirthDate != "8 October 1920" || author.DeathDate != "11 February 1986" {
+		t.Errorf("unexpected author: %+v", author)
+	}
+	if bio := author.BioText(); bio == nil || *bio != "American science fiction author." {
+		t.Errorf("unexpected bio: %v", bio)
+	}
+	if photo := author.PhotoURL(); photo == nil || *photo != "https://covers.openlibrary.org/a/id/6257741-M.jpg" {
+		t.Errorf("expected removed photo skipped, got %v", photo)
+	}
+	if len(author.Works) != 2 || author.Works[0].CoverID() != 11481354 || author.Works[1].CoverID() != 0 {
+		t.Errorf("unexpected works: %+v", author.Works)
+	}
+}
+
+func TestClientSearchFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		want := map[string]string{
+			"q": "dune first_publish_year:1965", "author": "herbert", "subject": "science fiction",
+			"language": "eng", "sort": "old", "page": "2", "limit": "10", "title": "",
+		}
+		for name, value := range want {
+			if got := r.URL.Query().Get(name); got != value {
+				t.Errorf("expected %s=%q, got %q", name, value, got)
+			}
+		}
+		w.Write([]byte(`{"numFound": 1, "docs": [
+			{"key": "/works/OL893415W", "title": "Dune", "edition_count": 98, "subject": ["Science fiction", "Arrakis"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	oldBaseURL := baseURL
+	baseURL = server.URL
+	defer func() { baseURL = oldBaseURL }()
+
+	query := SearchQuery{Text: "dune", Author: "herbert", Subject: "science fiction", Year: 1965, Language: "eng", Sort: SortOld, Page: 2, Limit: 10}
+	docs, err := NewClient().Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(docs) != 1 || docs[0].EditionCount != 98 || len(docs[0].Subject) != 2 {
+		t.Errorf("unexpected docs: %+v", docs)
+	}
+}
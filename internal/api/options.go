@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultTimeout bounds each request, including retries' individual attempts.
+const DefaultTimeout = 10 * time.Second
+
+// defaultUserAgent identifies bookshelf to the APIs, as Open Library asks
+// clients to.
+const defaultUserAgent = "bookshelf"
+
+// Option configures a Client or GoogleBooksClient.
+type Option func(*clientConfig)
+
+type clientConfig struct {
+	baseURL   string
+	timeout   time.Duration
+	userAgent string
+	proxy     *url.URL
+	transport http.RoundTripper
+}
+
+// WithBaseURL sends requests to another server, e.g. a mirror or a fake in tests.
+func WithBaseURL(url string) Option {
+	return func(c *clientConfig) { c.baseURL = url }
+}
+
+// WithTimeout replaces DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *clientConfig) { c.timeout = timeout }
+}
+
+// WithUserAgent sets the User-Agent header. See UserAgent.
+func WithUserAgent(userAgent string) Option {
+	return func(c *clientConfig) { c.userAgent = userAgent }
+}
+
+// WithProxy sends requests through an HTTP proxy. It's ignored when
+// WithTransport is also given.
+func WithProxy(proxy *url.URL) Option {
+	return func(c *clientConfig) { c.proxy = proxy }
+}
+
+// WithTransport makes requests with transport instead of http.DefaultTransport.
+// Rate limiting still applies on top of it.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *clientConfig) { c.transport = transport }
+}
+
+// UserAgent builds a User-Agent that lets API operators contact you about
+// your traffic, e.g. "bookshelf (me@example.com)". Without an email it's just
+// "bookshelf".
+func UserAgent(contactEmail string) string {
+	if contactEmail == "" {
+		return defaultUserAgent
+	}
+	return defaultUserAgent + " (" + contactEmail + ")"
+}
+
+func newClientConfig(baseURL string, opts []Option) clientConfig {
+	config := clientConfig{baseURL: baseURL, timeout: DefaultTimeout, userAgent: defaultUserAgent}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// httpClient builds the client's transport chain: User-Agent, then rate
+// limiting, then the proxy or custom transport.
+func (c clientConfig) httpClient(limiter *RateLimiter) *http.Client {
+	base := c.transport
+	if base == nil {
+		base = http.DefaultTransport
+		if c.proxy != nil {
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.Proxy = http.ProxyURL(c.proxy)
+			base = transport
+		}
+	}
+
+	return &http.Client{
+		Timeout: c.timeout,
+		Transport: &userAgentTransport{
+			base:      &rateLimitedTransport{base: base, limiter: limiter},
+			userAgent: c.userAgent,
+		},
+	}
+}
+
+// userAgentTransport sets the User-Agent header on every request.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.userAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestUserAgent(t *testing.T) {
+	if got := UserAgent(""); got != "bookshelf" {
+		t.Errorf("expected 'bookshelf', got %s", got)
+	}
+	if got := UserAgent("me@example.com"); got != "bookshelf (me@example.com)" {
+		t.Errorf("expected 'bookshelf (me@example.com)', got %s", got)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Write([]byte(`{"numFound": 0, "docs": []}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithBaseURL(server.URL), WithUserAgent(UserAgent("me@example.com")))
+	if _, err := client.Search(context.Background(), SearchQuery{Text: "dune", Limit: 5}); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if userAgent != "bookshelf (me@example.com)" {
+		t.Errorf("expected contact User-Agent, got %q", userAgent)
+	}
+
+	google := NewGoogleBooksClient("", WithBaseURL(server.URL))
+	if _, err := google.Search(context.Background(), SearchQuery{Text: "dune", Limit: 5}); err != nil {
+		t.Fatalf("google search failed: %v", err)
+	}
+	if userAgent != "bookshelf" {
+		t.Errorf("expected default User-Agent, got %q", userAgent)
+	}
+}
+
+func TestClientWithTransport(t *testing.T) {
+	var requested string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		return nil, errors.New("offline")
+	})
+
+	client := NewClient(WithBaseURL("http://books.test"), WithTransport(transport))
+	if _, err := client.GetWorkDetails(context.Background(), "/works/OL1W"); err == nil {
+		t.Error("expected the transport's error")
+	}
+	if requested != "http://books.test/works/OL1W.json" {
+		t.Errorf("expected request through the transport, got %q", requested)
+	}
+}
+
+func TestClientWithTimeout(t *testing.T) {
+	fastRetries(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	client := NewClient(WithBaseURL(server.URL), WithTimeout(20*time.Millisecond))
+	if _, err := client.GetWorkDetails(context.Background(), "/works/OL1W"); err == nil {
+		t.Error("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("expected the timeout to cut requests short, took %v", elapsed)
+	}
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Provider names accepted by NewProvider.
+const (
+	ProviderOpenLibrary = "openlibrary"
+	ProviderGoogleBooks = "google"
+)
+
+// ProviderNames lists the available metadata providers.
+var ProviderNames = []string{ProviderOpenLibrary, ProviderGoogleBooks}
+
+// MetadataProvider looks up book metadata in an online catalog.
+//
+// Keys returned in SearchDoc.Key are only meaningful to the provider that
+// returned them, except that Open Library work keys ("/works/...") are stored
+// on books and understood by every provider set that includes Open Library.
+type MetadataProvider interface {
+	Name() string
+	// Search finds books matching q. Providers that can't apply one of q's
+	// fields or sort orders ignore it.
+	Search(ctx context.Context, q SearchQuery) ([]SearchDoc, error)
+	// LookupISBN returns nil, nil when the ISBN is unknown.
+	LookupISBN(ctx context.Context, isbn string) (*SearchDoc, error)
+	GetWorkDetails(ctx context.Context, key string) (*WorkDetails, error)
+	GetEditions(ctx context.Context, key string) ([]Edition, error)
+}
+
+// ProviderOptions carries the settings individual providers need.
+type ProviderOptions struct {
+	GoogleBooksAPIKey string
+	// Cache, if set, serves and stores the providers' responses.
+	Cache *Cache
+	// ClientOptions configure every provider's HTTP client, e.g. WithUserAgent.
+	ClientOptions []Option
+}
+
+// NewProvider builds the named provider.
+func NewProvider(name string, opts ProviderOptions) (MetadataProvider, error) {
+	switch name {
+	case ProviderOpenLibrary:
+		client := NewClient(opts.ClientOptions...)
+		opts.useCache(client.httpClient)
+		return client, nil
+	case ProviderGoogleBooks:
+		client := NewGoogleBooksClient(opts.GoogleBooksAPIKey, opts.ClientOptions...)
+		opts.useCache(client.httpClient)
+		return client, nil
+	}
+	return nil, fmt.Errorf("unknown metadata provider: %s (use: %s)", name, strings.Join(ProviderNames, ", "))
+}
+
+// useCache puts the cache in front of client's transport, so only cache
+// misses are rate limited.
+func (o ProviderOptions) useCache(client *http.Client) {
+	if o.Cache != nil {
+		cache := *o.Cache
+		cache.Transport = client.Transport
+		client.Transport = &cache
+	}
+}
+
+// NewProviders builds a provider from a comma-separated list of names. With
+// more than one name the providers are queried together and their results
+// merged by ISBN.
+func NewProviders(names string, opts ProviderOptions) (MetadataProvider, error) {
+	var providers []MetadataProvider
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		p, err := NewProvider(name, opts)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	switch len(providers) {
+	case 0:
+		return nil, fmt.Errorf("no metadata provider given (use: %s)", strings.Join(ProviderNames, ", "))
+	case 1:
+		return providers[0], nil
+	}
+	return &MultiProvider{providers: providers}, nil
+}
+
+// MultiProvider queries several providers and merges results that share an ISBN.
+type MultiProvider struct {
+	providers []MetadataProvider
+}
+
+var _ MetadataProvider = (*MultiProvider)(nil)
+
+// NewMultiProvider combines providers, in order of preference.
+func NewMultiProvider(providers ...MetadataProvider) *MultiProvider {
+	return &MultiProvider{providers: providers}
+}
+
+func (m *MultiProvider) Name() string {
+	names := make([]string, len(m.providers))
+	for i, p := range m.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Search queries every provider and interleaves their results, folding books
+// found by more than one provider into the first result for that book. It only
+// fails if every provider does.
+func (m *MultiProvider) Search(ctx context.Context, q SearchQuery) ([]SearchDoc, error) {
+	var lists [][]SearchDoc
+	var errs []error
+	for _, p := range m.providers {
+		docs, err := p.Search(ctx, q)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		lists = append(lists, docs)
+	}
+	if len(lists) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	merged := mergeByISBN(lists)
+	if len(merged) > q.Limit {
+		merged = merged[:q.Limit]
+	}
+	return merged, nil
+}
+
+// LookupISBN asks each provider in turn and merges what they know about the ISBN.
+func (m *MultiProvider) LookupISBN(ctx context.Context, isbn string) (*SearchDoc, error) {
+	var found *SearchDoc
+	var errs []error
+	for _, p := range m.providers {
+		doc, err := p.LookupISBN(ctx, isbn)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		if doc == nil {
+			continue
+		}
+		if found == nil {
+			found = doc
+		} else {
+			mergeDoc(found, doc)
+		}
+	}
+	if found == nil && len(errs) == len(m.providers) {
+		return nil, errors.Join(errs...)
+	}
+	return found, nil
+}
+
+// GetWorkDetails routes the key to the provider that issued it. Keys of the
+// form "<provider>:<id>" name their provider; anything else is taken to be an
+// Open Library key.
+func (m *MultiProvider) GetWorkDetails(ctx context.Context, key string) (*WorkDetails, error) {
+	p, err := m.providerFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetWorkDetails(ctx, key)
+}
+
+// GetEditions routes the key like GetWorkDetails.
+func (m *MultiProvider) GetEditions(ctx context.Context, key string) ([]Edition, error) {
+	p, err := m.providerFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetEditions(ctx, key)
+}
+
+func (m *MultiProvider) providerFor(key string) (MetadataProvider, error) {
+	name := ProviderOpenLibrary
+	if prefix, _, ok := strings.Cut(key, ":"); ok {
+		name = prefix
+	}
+	for _, p := range m.providers {
+		if p.Name() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s provider enabled to look up %s", name, key)
+}
+
+// mergeByISBN interleaves the result lists, one from each in turn, and folds
+// later results into an earlier one when they share an ISBN.
+func mergeByISBN(lists [][]SearchDoc) []SearchDoc {
+	var merged []SearchDoc
+	index := make(map[string]int)
+
+	for i := 0; ; i++ {
+		more := false
+		for _, docs := range lists {
+			if i >= len(docs) {
+				continue
+			}
+			more = true
+			doc := docs[i]
+
+			pos := -1
+			for _, isbn := range doc.ISBN {
+				if p, ok := index[NormalizeISBN(isbn)]; ok {
+					pos = p
+					break
+				}
+			}
+			if pos >= 0 {
+				mergeDoc(&merged[pos], &doc)
+			} else {
+				pos = len(merged)
+				merged = append(merged, doc)
+			}
+			for _, isbn := range merged[pos].ISBN {
+				if n := NormalizeISBN(isbn); n != "" {
+					index[n] = pos
+				}
+			}
+		}
+		if !more {
+			return merged
+		}
+	}
+}
+
+// mergeDoc fills the gaps in dst from src. dst keeps its key, title and author.
+func mergeDoc(dst, src *SearchDoc) {
+	if len(dst.AuthorName) == 0 {
+		dst.AuthorName = src.AuthorName
+	}
+	if src.FirstPublishYear > 0 && (dst.FirstPublishYear == 0 || src.FirstPublishYear < dst.FirstPublishYear) {
+		dst.FirstPublishYear = src.FirstPublishYear
+	}
+	if dst.NumberOfPages == 0 {
+		dst.NumberOfPages = src.NumberOfPages
+	}
+	if src.EditionCount > dst.EditionCount {
+		dst.EditionCount = src.EditionCount
+	}
+	if len(dst.Subject) == 0 {
+		dst.Subject = src.Subject
+	}
+	if dst.CoverI == 0 && dst.CoverImage == "" {
+		dst.CoverI = src.CoverI
+		dst.CoverImage = src.CoverImage
+	}
+
+	have := make(map[string]bool)
+	for _, isbn := range dst.ISBN {
+		have[NormalizeISBN(isbn)] = true
+	}
+	for _, isbn := range src.ISBN {
+		if n := NormalizeISBN(isbn); !have[n] {
+			dst.ISBN = append(dst.ISBN, isbn)
+			have[n] = true
+		}
+	}
+}
+
+// NormalizeISBN strips separators and converts ISBN-10 to ISBN-13 so the two
+// forms of the same book compare equal. Returns "" for anything that isn't an ISBN.
+func NormalizeISBN(isbn string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(isbn) {
+		if (r >= '0' && r <= '9') || r == 'X' {
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+
+	switch len(s) {
+	case 13:
+		if strings.ContainsRune(s, 'X') {
+			return ""
+		}
+		return s
+	case 10:
+		if strings.ContainsRune(s[:9], 'X') {
+			return ""
+		}
+		s = "978" + s[:9]
+		sum := 0
+		for i, r := range s {
+			d := int(r - '0')
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		return s + fmt.Sprint((10-sum%10)%10)
+	}
+	return ""
+}
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeProvider serves canned results for MultiProvider tests.
+type fakeProvider struct {
+	name    string
+	docs    []SearchDoc
+	err     error
+	details map[string]*WorkDetails
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) Search(ctx context.Context, q SearchQuery) ([]SearchDoc, error) {
+	return f.docs, f.err
+}
+
+func (f *fakeProvider) LookupISBN(ctx context.Context, isbn string) (*SearchDoc, error) {
+	for _, d := range f.docs {
+		for _, i := range d.ISBN {
+			if NormalizeISBN(i) == NormalizeISBN(isbn) {
+				return &d, nil
+			}
+		}
+	}
+	return nil, f.err
+}
+
+func (f *fakeProvider) GetWorkDetails(ctx context.Context, key string) (*WorkDetails, error) {
+	if w, ok := f.details[key]; ok {
+		return w, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (f *fakeProvider) GetEditions(ctx context.Context, key string) ([]Edition, error) {
+	return nil, errors.New("not found")
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"978-0-441-01359-3", "9780441013593"},
+		{"0441013597", "9780441013593"},
+		{"0-8044-2957-X", "9780804429573"},
+		{"97804410X3593", ""},
+		{"04410X3597", ""},
+		{"12345", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeISBN(tt.input); got != tt.expected {
+			t.Errorf("NormalizeISBN(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNewProviders(t *testing.T) {
+	p, err := NewProviders("openlibrary", ProviderOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := p.(*Client); !ok {
+		t.Errorf("expected a single Open Library client, got %T", p)
+	}
+
+	p, err = NewProviders("openlibrary, google", ProviderOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name() != "openlibrary,google" {
+		t.Errorf("expected merged provider, got %s", p.Name())
+	}
+
+	if _, err := NewProviders("amazon", ProviderOptions{}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if _, err := NewProviders(" , ", ProviderOptions{}); err == nil {
+		t.Error("expected error for empty provider list")
+	}
+}
+
+func TestMultiProviderSearchMergesByISBN(t *testing.T) {
+	ol := &fakeProvider{name: ProviderOpenLibrary, docs: []SearchDoc{
+		{Key: "/works/OL1W", Title: "Dune", ISBN: []string{"9780441013593"}, FirstPublishYear: 1965},
+		{Key: "/works/OL2W", Title: "Dune Messiah"},
+	}}
+	google := &fakeProvider{name: ProviderGoogleBooks, docs: []SearchDoc{
+		{Key: "google:a", Title: "Dune (Deluxe)", ISBN: []string{"0441013597"}, NumberOfPages: 604, CoverImage: "https://img/dune"},
+		{Key: "google:b", Title: "Children of Dune", ISBN: []string{"9780593098240"}},
+	}}
+
+	docs, err := NewMultiProvider(ol, google).Search(context.Background(), SearchQuery{Text: "dune", Limit: 10})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 merged results, got %d: %+v", len(docs), docs)
+	}
+
+	dune := docs[0]
+	if dune.Key != "/works/OL1W" || dune.Title != "Dune" {
+		t.Errorf("expected first provider's key and title to win, got %+v", dune)
+	}
+	if dune.NumberOfPages != 604 || dune.CoverImage != "https://img/dune" {
+		t.Errorf("expected gaps filled from Google, got %+v", dune)
+	}
+	if len(dune.ISBN) != 1 {
+		t.Errorf("expected ISBN-10 and ISBN-13 of the same book to merge, got %v", dune.ISBN)
+	}
+
+	// Unmatched results are interleaved
+	if docs[1].Key != "/works/OL2W" || docs[2].Key != "google:b" {
+		t.Errorf("unexpected order: %s, %s", docs[1].Key, docs[2].Key)
+	}
+
+	docs, _ = NewMultiProvider(ol, google).Search(context.Background(), SearchQuery{Text: "dune", Limit: 2})
+	if len(docs) != 2 {
+		t.Errorf("expected results capped at limit, got %d", len(docs))
+	}
+}
+
+func TestMultiProviderSearchPartialFailure(t *testing.T) {
+	ol := &fakeProvider{name: ProviderOpenLibrary, err: errors.New("down")}
+	google := &fakeProvider{name: ProviderGoogleBooks, docs: []SearchDoc{{Key: "google:a", Title: "Dune"}}}
+
+	docs, err := NewMultiProvider(ol, google).Search(context.Background(), SearchQuery{Text: "dune", Limit: 5})
+	if err != nil || len(docs) != 1 {
+		t.Errorf("expected Google results despite Open Library failing, got %v, %v", docs, err)
+	}
+
+	google.err, google.docs = errors.New("quota"), nil
+	if _, err := NewMultiProvider(ol, google).Search(context.Background(), SearchQuery{Text: "dune", Limit: 5}); err == nil {
+		t.Error("expected error when every provider fails")
+	}
+}
+
+func TestMultiProviderGetWorkDetailsRoutesByKey(t *testing.T) {
+	ol := &fakeProvider{name: ProviderOpenLibrary, details: map[string]*WorkDetails{"/works/OL1W": {Title: "from ol"}}}
+	google := &fakeProvider{name: ProviderGoogleBooks, details: map[string]*WorkDetails{"google:a": {Title: "from google"}}}
+	multi := NewMultiProvider(google, ol)
+
+	if w, err := multi.GetWorkDetails(context.Background(), "/works/OL1W"); err != nil || w.Title != "from ol" {
+		t.Errorf("expected Open Library key routed to Open Library, got %v, %v", w, err)
+	}
+	if w, err := multi.GetWorkDetails(context.Background(), "google:a"); err != nil || w.Title != "from google" {
+		t.Errorf("expected Google key routed to Google, got %v, %v", w, err)
+	}
+	if _, err := NewMultiProvider(ol).GetWorkDetails(context.Background(), "google:a"); err == nil {
+		t.Error("expected error for a key from a disabled provider")
+	}
+}
+
+func TestGoogleBooksClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "secret" {
+			t.Errorf("expected API key to be sent, got %q", r.URL.Query().Get("key"))
+		}
+		switch r.URL.Path {
+		case "/volumes":
+			w.Write([]byte(`{"totalItems": 1, "items": [{
+				"id": "B1",
+				"volumeInfo": {
+					"title": "Dune",
+					"authors": ["Frank Herbert"],
+					"publishedDate": "1990-09-01",
+					"pageCount": 535,
+					"industryIdentifiers": [
+						{"type": "ISBN_10", "identifier": "0441172717"},
+						{"type": "ISBN_13", "identifier": "9780441172719"}
+					],
+					"imageLinks": {"thumbnail": "http://books.google.com/b1.jpg"}
+				}
+			}]}`))
+		case "/volumes/B1":
+			w.Write([]byte(`{"id": "B1", "volumeInfo": {
+				"title": "Dune",
+				"description": "Desert planet.",
+				"categories": ["Fiction / Science Fiction / General", "Fiction / Science Fiction / Space Opera"]
+			}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oldURL := googleBooksURL
+	googleBooksURL = server.URL
+	defer func() { googleBooksURL = oldURL }()
+
+	client := NewGoogleBooksClient("secret")
+
+	docs, err := client.Search(context.Background(), SearchQuery{Text: "dune", Limit: 5})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(docs))
+	}
+	doc := docs[0]
+	if doc.Key != "google:B1" || doc.Author() != "Frank Herbert" || doc.FirstPublishYear != 1990 || doc.NumberOfPages != 535 {
+		t.Errorf("unexpected doc: %+v", doc)
+	}
+	if isbn := doc.FirstISBN(); isbn == nil || *isbn != "9780441172719" {
+		t.Errorf("expected ISBN-13 first, got %v", doc.ISBN)
+	}
+	if cover := doc.CoverURL(); cover == nil || *cover != "https://books.google.com/b1.jpg" {
+		t.Errorf("expected https cover URL, got %v", cover)
+	}
+	if doc.OpenLibraryKey() != nil {
+		t.Error("expected no Open Library key for Google results")
+	}
+
+	found, err := client.LookupISBN(context.Background(), "9780441172719")
+	if err != nil || found == nil || found.Key != "google:B1" {
+		t.Errorf("expected ISBN lookup to find B1, got %v, %v", found, err)
+	}
+
+	work, err := client.GetWorkDetails(context.Background(), "google:B1")
+	if err != nil {
+		t.Fatalf("get work details failed: %v", err)
+	}
+	if desc := work.DescriptionText(); desc == nil || *desc != "Desert planet." {
+		t.Errorf("unexpected description: %v", desc)
+	}
+	expected := []string{"Fiction", "Science Fiction", "Space Opera"}
+	if len(work.Subjects) != len(expected) {
+		t.Fatalf("expected subjects %v, got %v", expected, work.Subjects)
+	}
+	for i := range expected {
+		if work.Subjects[i] != expected[i] {
+			t.Errorf("expected subjects %v, got %v", expected, work.Subjects)
+		}
+	}
+
+	if _, err := client.GetWorkDetails(context.Background(), "/works/OL1W"); err == nil {
+		t.Error("expected error for an Open Library key")
+	}
+}
+
+func TestGoogleBooksSearchFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if q := query.Get("q"); q != "dune inauthor:herbert subject:fiction" {
+			t.Errorf("unexpected q: %s", q)
+		}
+		if query.Get("langRestrict") != "en" || query.Get("orderBy") != "newest" || query.Get("startIndex") != "5" {
+			t.Errorf("unexpected params: %v", query)
+		}
+		w.Write([]byte(`{"totalItems": 2, "items": [
+			{"id": "B1", "volumeInfo": {"title": "Dune", "publishedDate": "1965", "categories": ["Fiction / Classics"]}},
+			{"id": "B2", "volumeInfo": {"title": "Dune", "publishedDate": "1990-09-01"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	oldURL := googleBooksURL
+	googleBooksURL = server.URL
+	defer func() { googleBooksURL = oldURL }()
+
+	query := SearchQuery{Text: "dune", Author: "herbert", Subject: "fiction", Language: "eng", Year: 1965, Sort: SortNew, Page: 2, Limit: 5}
+	docs, err := NewGoogleBooksClient("").Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(docs) != 1 || docs[0].Key != "google:B1" {
+		t.Fatalf("expected only the 1965 volume, got %+v", docs)
+	}
+	if len(docs[0].Subject) != 2 || docs[0].Subject[1] != "Classics" {
+		t.Errorf("unexpected subjects: %v", docs[0].Subject)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry tuning. Variables so tests can shrink the waits.
+var (
+	maxRetries  = 4
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 30 * time.Second
+)
+
+// getWithRetry GETs url, retrying network errors, 429s and 5xx responses with
+// jittered exponential backoff. A Retry-After header overrides the backoff.
+// The caller must close the returned response's body.
+func getWithRetry(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if attempt == maxRetries || !retryable(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait := backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = after
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrOffline)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns a random wait in [d/2, d) where d doubles with each attempt.
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	if d > maxBackoff || d <= 0 {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter parses a Retry-After header, either seconds or an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if secs, err := strconv.Atoi(value); err == nil {
+		d = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(value); err == nil {
+		d = time.Until(t)
+	} else {
+		return 0, false
+	}
+
+	if d < 0 {
+		d = 0
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d, true
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fastRetries shrinks the backoff so retry tests don't sleep.
+func fastRetries(t *testing.T) {
+	t.Helper()
+	oldBase, oldMax := baseBackoff, maxBackoff
+	baseBackoff, maxBackoff = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { baseBackoff, maxBackoff = oldBase, oldMax })
+}
+
+func TestGetWithRetryRecovers(t *testing.T) {
+	fastRetries(t)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	resp, err := getWithRetry(context.Background(), http.DefaultClient, server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Errorf("expected success on the third attempt, got status %d after %d", resp.StatusCode, attempts)
+	}
+}
+
+func TestGetWithRetryGivesUp(t *testing.T) {
+	fastRetries(t)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	resp, err := getWithRetry(context.Background(), http.DefaultClient, server.URL)
+	if err != nil {
+		t.Fatalf("expected the last response, got error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts != maxRetries+1 {
+		t.Errorf("expected %d attempts ending in 503, got %d ending in %d", maxRetries+1, attempts, resp.StatusCode)
+	}
+}
+
+func TestGetWithRetryDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	resp, err := getWithRetry(context.Background(), http.DefaultClient, server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if attempts != 1 {
+		t.Errorf("expected 404 not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestGetWithRetryCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := getWithRetry(ctx, http.DefaultClient, server.URL)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected cancellation to cut the Retry-After wait short, took %s", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("5"); !ok || d != 5*time.Second {
+		t.Errorf("expected 5s, got %s", d)
+	}
+	if d, ok := retryAfter(time.Now().Add(3 * time.Second).UTC().Format(http.TimeFormat)); !ok || d <= 0 || d > 3*time.Second {
+		t.Errorf("expected up to 3s from an HTTP date, got %s", d)
+	}
+	if d, ok := retryAfter("3600"); !ok || d != maxBackoff {
+		t.Errorf("expected wait capped at %s, got %s", maxBackoff, d)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("expected unparseable Retry-After to be ignored")
+	}
+}
+
+func TestBackoffIsJittered(t *testing.T) {
+	for attempt := 0; attempt < 3; attempt++ {
+		d := baseBackoff << attempt
+		for i := 0; i < 20; i++ {
+			if got := backoff(attempt); got < d/2 || got > d {
+				t.Errorf("backoff(%d) = %s, expected between %s and %s", attempt, got, d/2, d)
+			}
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(50, 2)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+	}
+	// Two tokens from the burst, then two more at 50/s
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected the limiter to throttle after the burst, took %s", elapsed)
+	}
+
+	slow := NewRateLimiter(0.1, 1)
+	slow.Wait(ctx)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := slow.Wait(cancelled); err != context.Canceled {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sort orders for SearchQuery.Sort. The zero value sorts by relevance.
+const (
+	SortNew    = "new"
+	SortOld    = "old"
+	SortRating = "rating"
+)
+
+// SearchSorts lists the accepted SearchQuery.Sort values.
+var SearchSorts = []string{SortNew, SortOld, SortRating}
+
+// SearchQuery is a book search: free text narrowed by any of the fields.
+type SearchQuery struct {
+	Text     string
+	Title    string
+	Author   string
+	Subject  string
+	ISBN     string
+	Year     int    // First published
+	Language string // MARC code, e.g. "eng"
+	Sort     string // One of SearchSorts, or "" for relevance
+	Page     int    // 1-based; 0 is the first page
+	Limit    int
+}
+
+// IsEmpty reports whether the query has nothing to search for.
+func (q SearchQuery) IsEmpty() bool {
+	return strings.TrimSpace(q.Text) == "" && q.Title == "" && q.Author == "" && q.Subject == "" &&
+		q.ISBN == "" && q.Year == 0 && q.Language == ""
+}
+
+// Validate checks the sort order and paging.
+func (q SearchQuery) Validate() error {
+	if q.IsEmpty() {
+		return fmt.Errorf("nothing to search for")
+	}
+	if q.Sort != "" && !slices.Contains(SearchSorts, q.Sort) {
+		return fmt.Errorf("invalid sort: %s (use: %s)", q.Sort, strings.Join(SearchSorts, ", "))
+	}
+	if q.Page < 0 {
+		return fmt.Errorf("page must be at least 1")
+	}
+	if q.Year < 0 {
+		return fmt.Errorf("year must be positive")
+	}
+	return nil
+}
+
+// String describes the query for display, e.g. `"dune" author:herbert year:1965`.
+func (q SearchQuery) String() string {
+	var parts []string
+	if text := strings.TrimSpace(q.Text); text != "" {
+		parts = append(parts, strconv.Quote(text))
+	}
+	for _, f := range []struct{ name, value string }{
+		{"title", q.Title}, {"author", q.Author}, {"subject", q.Subject}, {"isbn", q.ISBN}, {"lang", q.Language},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.name+":"+f.value)
+		}
+	}
+	if q.Year > 0 {
+		parts = append(parts, fmt.Sprintf("year:%d", q.Year))
+	}
+	return strings.Join(parts, " ")
+}
+
+// page returns the 1-based page number.
+func (q SearchQuery) page() int {
+	if q.Page < 1 {
+		return 1
+	}
+	return q.Page
+}
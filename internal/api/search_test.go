@@ -0,0 +1,43 @@
+package api
+
+import "testing"
+
+func TestSearchQueryString(t *testing.T) {
+	tests := []struct {
+		query    SearchQuery
+		expected string
+	}{
+		{SearchQuery{Text: "dune"}, `"dune"`},
+		{SearchQuery{Text: "dune", Author: "herbert", Year: 1965}, `"dune" author:herbert year:1965`},
+		{SearchQuery{Subject: "whales", Language: "eng"}, "subject:whales lang:eng"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.query.String(); got != tt.expected {
+			t.Errorf("String() = %q, expected %q", got, tt.expected)
+		}
+	}
+}
+
+func TestSearchQueryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   SearchQuery
+		wantErr bool
+	}{
+		{"text", SearchQuery{Text: "dune"}, false},
+		{"field only", SearchQuery{Author: "herbert", Sort: SortNew}, false},
+		{"empty", SearchQuery{Text: "  "}, true},
+		{"bad sort", SearchQuery{Text: "dune", Sort: "random"}, true},
+		{"negative page", SearchQuery{Text: "dune", Page: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
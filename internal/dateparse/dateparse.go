@@ -0,0 +1,128 @@
+// Package dateparse turns exact and fuzzy date expressions such as
+// "2026-03-14", "2025-06" or "last tuesday" into calendar days.
+package dateparse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts accepted for absolute dates. Partial dates resolve to the first
+// day of the month or year.
+var layouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"2006-01",
+	"2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2006",
+	"Jan 2006",
+}
+
+// Layouts without a year resolve to the most recent such day. Feb 29 is an
+// error when that falls in a year without one.
+var yearlessLayouts = []string{
+	"January 2",
+	"Jan 2",
+	"2 January",
+	"2 Jan",
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// Parse resolves input relative to now and returns midnight of that day in
+// now's location. Relative expressions never resolve to a future day.
+func Parse(input string, now time.Time) (time.Time, error) {
+	input = strings.Join(strings.Fields(input), " ")
+	s := strings.ToLower(input)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch s {
+	case "":
+		return time.Time{}, fmt.Errorf("empty date")
+	case "today", "now":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "last week":
+		return today.AddDate(0, 0, -7), nil
+	case "last month":
+		return today.AddDate(0, -1, 0), nil
+	case "last year":
+		return today.AddDate(-1, 0, 0), nil
+	}
+
+	// "tuesday" or "last tuesday": the most recent one before today
+	if day, ok := weekdays[strings.TrimPrefix(s, "last ")]; ok {
+		diff := int(today.Weekday()-day+7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		return today.AddDate(0, 0, -diff), nil
+	}
+
+	// "3 days ago", "2 weeks ago", "a month ago"
+	if fields := strings.Fields(s); len(fields) == 3 && fields[2] == "ago" {
+		return parseAgo(fields[0], fields[1], today, input)
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, input, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	for _, layout := range yearlessLayouts {
+		if t, err := time.ParseInLocation(layout, input, now.Location()); err == nil {
+			year := today.Year()
+			if t.Month() > today.Month() || (t.Month() == today.Month() && t.Day() > today.Day()) {
+				year--
+			}
+			date := time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+			if date.Day() != t.Day() {
+				return time.Time{}, fmt.Errorf("%q: %d has no February 29 (give the year)", input, year)
+			}
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised date: %q (try 2026-03-14, 2025-06, yesterday or \"last tuesday\")", input)
+}
+
+func parseAgo(amount, unit string, today time.Time, input string) (time.Time, error) {
+	n := 1
+	if amount != "a" && amount != "an" && amount != "one" {
+		var err error
+		n, err = strconv.Atoi(amount)
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("unrecognised date: %q", input)
+		}
+	}
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "day":
+		return today.AddDate(0, 0, -n), nil
+	case "week":
+		return today.AddDate(0, 0, -7*n), nil
+	case "month":
+		return today.AddDate(0, -n, 0), nil
+	case "year":
+		return today.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date: %q", input)
+}
@@ -0,0 +1,75 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 3, 18, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2026-03-14", "2026-03-14"},
+		{"2026/03/14", "2026-03-14"},
+		{"2025-06", "2025-06-01"},
+		{"2024", "2024-01-01"},
+		{"today", "2026-03-18"},
+		{"Yesterday", "2026-03-17"},
+		{"last tuesday", "2026-03-17"},
+		{"tuesday", "2026-03-17"},
+		{"last wednesday", "2026-03-11"},
+		{"last thu", "2026-03-12"},
+		{"3 days ago", "2026-03-15"},
+		{"2 weeks ago", "2026-03-04"},
+		{"a month ago", "2026-02-18"},
+		{"1 year ago", "2025-03-18"},
+		{"last week", "2026-03-11"},
+		{"March 14, 2025", "2025-03-14"},
+		{"Mar 2025", "2025-03-01"},
+		{"14 March 2025", "2025-03-14"},
+		{"March 1", "2026-03-01"},
+		{"Dec 25", "2025-12-25"},
+		{"  last   tuesday ", "2026-03-17"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input, now)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.input, err)
+			}
+			if got.Format("2006-01-02") != tt.expected {
+				t.Errorf("Parse(%q) = %s, expected %s", tt.input, got.Format("2006-01-02"), tt.expected)
+			}
+			if got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("Parse(%q) should return midnight, got %s", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestParseLeapDay(t *testing.T) {
+	got, err := Parse("Feb 29", time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC))
+	if err != nil || got.Format("2006-01-02") != "2024-02-29" {
+		t.Errorf("Parse(Feb 29) in 2024 = %s, %v, expected 2024-02-29", got.Format("2006-01-02"), err)
+	}
+
+	// 2026 has no Feb 29; it mustn't become Mar 1
+	if got, err := Parse("Feb 29", time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("Parse(Feb 29) in 2026 = %s, expected error", got.Format("2006-01-02"))
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	now := time.Date(2026, 3, 18, 0, 0, 0, 0, time.UTC)
+
+	for _, input := range []string{"", "soon", "2026-13-01", "next tuesday", "x days ago", "3 fortnights ago"} {
+		if _, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"bookshelf/internal/models"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// SaveAuthor inserts an author, or updates the one with the same Open Library
+// key, and returns its ID.
+func SaveAuthor(author *models.Author) (int64, error) {
+	_, err := DB.Exec(`
+		INSERT INTO authors (open_library_key, name, bio, birth_date, death_date, photo_url, works, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(open_library_key) DO UPDATE SET
+			name = excluded.name, bio = excluded.bio, birth_date = excluded.birth_date,
+			death_date = excluded.death_date, photo_url = excluded.photo_url, works = excluded.works,
+			updated_at = excluded.updated_at
+	`, author.OpenLibraryKey, author.Name, author.Bio, author.BirthDate, author.DeathDate, author.PhotoURL, author.Works,
+		time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = DB.QueryRow(`SELECT id FROM authors WHERE open_library_key = ?`, author.OpenLibraryKey).Scan(&id)
+	return id, err
+}
+
+// GetAuthorByName finds a saved author by name, preferring an exact
+// (case-insensitive) match over a partial one. Returns nil if none matches.
+func GetAuthorByName(name string) (*models.Author, error) {
+	var author models.Author
+	err := DB.QueryRow(`
+		SELECT id, open_library_key, name, bio, birth_date, death_date, photo_url, works, updated_at
+		FROM authors
+		WHERE LOWER(name) LIKE ?
+		ORDER BY LOWER(name) = ? DESC, updated_at DESC
+		LIMIT 1
+	`, "%"+strings.ToLower(name)+"%", strings.ToLower(name)).Scan(
+		&author.ID, &author.OpenLibraryKey, &author.Name, &author.Bio, &author.BirthDate, &author.DeathDate,
+		&author.PhotoURL, &author.Works, &author.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// GetBooksByAuthor returns the books whose author field contains name, case-insensitively, oldest first.
+func GetBooksByAuthor(name string) ([]models.BookWithEntry, error) {
+	rows, err := DB.Query(bookWithEntrySelect+`
+		WHERE LOWER(b.author) LIKE ?
+		ORDER BY b.created_at ASC, b.id ASC
+	`, "%"+strings.ToLower(name)+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var books []models.BookWithEntry
+	for rows.Next() {
+		book, err := scanBookWithEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
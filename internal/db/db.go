@@ -2,31 +2,38 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
-func Init() error {
-	dbPath := os.Getenv("BOOKSHELF_DB_PATH")
-	if dbPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-
-		dbDir := filepath.Join(homeDir, ".bookshelf")
-		if err := os.MkdirAll(dbDir, 0755); err != nil {
-			return err
-		}
+// Path returns the database file location: $BOOKSHELF_DB_PATH, or
+// ~/.bookshelf/bookshelf.db by default.
+func Path() (string, error) {
+	if dbPath := os.Getenv("BOOKSHELF_DB_PATH"); dbPath != "" {
+		return dbPath, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".bookshelf", "bookshelf.db"), nil
+}
 
-		dbPath = filepath.Join(dbDir, "bookshelf.db")
+func Init() error {
+	dbPath, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return err
 	}
 
-	var err error
 	DB, err = sql.Open("sqlite", dbPath)
 	if err != nil {
 		return err
@@ -48,7 +55,17 @@ func Migrate() error {
 		description TEXT,
 		open_library_key TEXT,
 		genres TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		edition_key TEXT,
+		publisher TEXT,
+		publish_date TEXT,
+		language TEXT,
+		format TEXT,
+		duration_minutes INTEGER CHECK(duration_minutes > 0),
+		tags TEXT,
+		series TEXT,
+		series_index REAL,
+		calibre_id INTEGER
 	);
 
 	CREATE TABLE IF NOT EXISTS reading_entries (
@@ -57,9 +74,11 @@ func Migrate() error {
 		status TEXT NOT NULL DEFAULT 'want-to-read',
 		started_at DATETIME,
 		finished_at DATETIME,
-		rating INTEGER CHECK(rating >= 1 AND rating <= 5),
+		rating REAL CHECK(rating >= 0.5 AND rating <= 5),
 		review TEXT,
+		current_page INTEGER,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		current_minutes INTEGER,
 		FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
 	);
 
@@ -80,16 +99,114 @@ func Migrate() error {
 		value TEXT NOT NULL,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
+
+	CREATE TABLE IF NOT EXISTS reading_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		book_id INTEGER NOT NULL,
+		date DATE NOT NULL,
+		pages INTEGER CHECK(pages > 0),
+		minutes INTEGER CHECK(minutes > 0),
+		note TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_reading_sessions_book_id ON reading_sessions(book_id);
+	CREATE INDEX IF NOT EXISTS idx_reading_sessions_date ON reading_sessions(date);
+
+	CREATE TABLE IF NOT EXISTS authors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		open_library_key TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		bio TEXT,
+		birth_date TEXT,
+		death_date TEXT,
+		photo_url TEXT,
+		works TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS quotes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		book_id INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		page INTEGER CHECK(page > 0),
+		location TEXT,
+		note TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_quotes_book_id ON quotes(book_id);
+
+	CREATE TABLE IF NOT EXISTS reading_timer (
+		id INTEGER PRIMARY KEY CHECK(id = 1),
+		book_id INTEGER NOT NULL,
+		started_at DATETIME NOT NULL,
+		start_page INTEGER,
+		FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+	);
 	`
 
 	if _, err := DB.Exec(schema); err != nil {
 		return err
 	}
 
-	// Add genres column to existing databases (ignore error if column already exists)
+	// Add columns to existing databases (ignore error if column already exists)
 	DB.Exec("ALTER TABLE books ADD COLUMN genres TEXT")
+	DB.Exec("ALTER TABLE reading_entries ADD COLUMN current_page INTEGER")
+	for _, column := range []string{"edition_key", "publisher", "publish_date", "language", "format"} {
+		DB.Exec("ALTER TABLE books ADD COLUMN " + column + " TEXT")
+	}
+	DB.Exec("ALTER TABLE books ADD COLUMN duration_minutes INTEGER CHECK(duration_minutes > 0)")
+	DB.Exec("ALTER TABLE reading_entries ADD COLUMN current_minutes INTEGER")
+	DB.Exec("ALTER TABLE books ADD COLUMN tags TEXT")
+	DB.Exec("ALTER TABLE books ADD COLUMN series TEXT")
+	DB.Exec("ALTER TABLE books ADD COLUMN series_index REAL")
+	DB.Exec("ALTER TABLE books ADD COLUMN calibre_id INTEGER")
+	DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_books_calibre_id ON books(calibre_id)")
+
+	return migrateRatingPrecision()
+}
 
-	return nil
+// migrateRatingPrecision rebuilds reading_entries from databases created when
+// ratings were whole stars, so half stars pass the CHECK constraint. Existing
+// ratings keep their value. A rating column it doesn't recognize is an error
+// rather than left as it is.
+func migrateRatingPrecision() error {
+	var tableSQL string
+	err := DB.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'reading_entries'`).Scan(&tableSQL)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(tableSQL, "rating INTEGER") {
+		return nil
+	}
+
+	const oldRating = "rating INTEGER CHECK(rating >= 1 AND rating <= 5)"
+	if !strings.Contains(tableSQL, oldRating) {
+		return fmt.Errorf("failed to migrate ratings: unrecognized reading_entries table: %s", tableSQL)
+	}
+	newSQL := strings.Replace(tableSQL, "reading_entries", "reading_entries_new", 1)
+	newSQL = strings.Replace(newSQL, oldRating, "rating REAL CHECK(rating >= 0.5 AND rating <= 5)", 1)
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		newSQL,
+		`INSERT INTO reading_entries_new SELECT * FROM reading_entries`,
+		`DROP TABLE reading_entries`,
+		`ALTER TABLE reading_entries_new RENAME TO reading_entries`,
+		`CREATE INDEX IF NOT EXISTS idx_reading_entries_book_id ON reading_entries(book_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_reading_entries_status ON reading_entries(status)`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to migrate ratings: %w", err)
+		}
+	}
+	return tx.Commit()
 }
 
 func Close() error {
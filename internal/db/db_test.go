@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func setupTestDB(t *testing.T) func() {
@@ -71,6 +73,41 @@ func TestAddBookMinimalFields(t *testing.T) {
 	}
 }
 
+func TestInsertAndUpdateBook(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	book := models.Book{
+		Title:  "Zine",
+		Author: "Someone",
+		Pages:  sql.NullInt64{Int64: 20, Valid: true},
+		ISBN:   sql.NullString{String: "123", Valid: true},
+	}
+	id, err := InsertBook(&book)
+	if err != nil {
+		t.Fatalf("failed to insert book: %v", err)
+	}
+	CreateReadingEntry(id, models.StatusWantToRead)
+
+	book.ID = id
+	book.Title = "Zine #2"
+	book.ISBN = sql.NullString{}
+	if err := UpdateBook(&book); err != nil {
+		t.Fatalf("failed to update book: %v", err)
+	}
+
+	got, _ := GetBook(id)
+	if got.Book.Title != "Zine #2" {
+		t.Errorf("expected title 'Zine #2', got %s", got.Book.Title)
+	}
+	if got.Book.ISBN.Valid {
+		t.Errorf("expected ISBN to be cleared, got %s", got.Book.ISBN.String)
+	}
+	if got.Book.Pages.Int64 != 20 {
+		t.Errorf("expected 20 pages, got %d", got.Book.Pages.Int64)
+	}
+}
+
 func TestCreateReadingEntry(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -210,6 +247,71 @@ func TestUpdateStatus(t *testing.T) {
 	}
 }
 
+func TestUpdateStatusOn(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusWantToRead)
+
+	started := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	finished := time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC)
+	UpdateStatusOn(id, models.StatusReading, &started)
+	UpdateStatusOn(id, models.StatusFinished, &finished)
+
+	book, _ := GetBook(id)
+	if !book.ReadingEntry.StartedAt.Time.Equal(started) {
+		t.Errorf("expected started_at %v, got %v", started, book.ReadingEntry.StartedAt.Time)
+	}
+	if !book.ReadingEntry.FinishedAt.Time.Equal(finished) {
+		t.Errorf("expected finished_at %v, got %v", finished, book.ReadingEntry.FinishedAt.Time)
+	}
+
+	count, _ := GetBooksFinishedInYear(2025)
+	if count != 1 {
+		t.Errorf("expected backdated book to count toward 2025, got %d", count)
+	}
+
+	// A nil date keeps the existing one
+	UpdateStatusOn(id, models.StatusFinished, nil)
+	book, _ = GetBook(id)
+	if !book.ReadingEntry.FinishedAt.Time.Equal(finished) {
+		t.Errorf("expected finished_at to be kept, got %v", book.ReadingEntry.FinishedAt.Time)
+	}
+}
+
+func TestSetReadingDates(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusFinished)
+
+	started := sql.NullTime{Time: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), Valid: true}
+	finished := sql.NullTime{Time: time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC), Valid: true}
+
+	if err := SetReadingDates(id, finished, started); err == nil {
+		t.Error("expected error when start is after finish")
+	}
+
+	if err := SetReadingDates(id, started, finished); err != nil {
+		t.Fatalf("failed to set dates: %v", err)
+	}
+	book, _ := GetBook(id)
+	if !book.ReadingEntry.StartedAt.Time.Equal(started.Time) || !book.ReadingEntry.FinishedAt.Time.Equal(finished.Time) {
+		t.Errorf("unexpected dates: %v - %v", book.ReadingEntry.StartedAt, book.ReadingEntry.FinishedAt)
+	}
+
+	// Clearing the start date
+	if err := SetReadingDates(id, sql.NullTime{}, finished); err != nil {
+		t.Fatalf("failed to clear start date: %v", err)
+	}
+	book, _ = GetBook(id)
+	if book.ReadingEntry.StartedAt.Valid {
+		t.Error("expected start date to be cleared")
+	}
+}
+
 func TestUpdateRating(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -223,11 +325,214 @@ func TestUpdateRating(t *testing.T) {
 	}
 
 	book, _ := GetBook(id)
-	if !book.ReadingEntry.Rating.Valid || book.ReadingEntry.Rating.Int64 != 5 {
+	if !book.ReadingEntry.Rating.Valid || book.ReadingEntry.Rating.Float64 != 5 {
 		t.Errorf("expected rating 5, got %v", book.ReadingEntry.Rating)
 	}
 }
 
+func TestUpdateRatingHalfStars(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusFinished)
+
+	if err := UpdateRating(id, 3.5); err != nil {
+		t.Fatalf("failed to update rating: %v", err)
+	}
+	book, _ := GetBook(id)
+	if book.ReadingEntry.Rating.Float64 != 3.5 {
+		t.Errorf("expected rating 3.5, got %v", book.ReadingEntry.Rating.Float64)
+	}
+
+	if err := UpdateRating(id, 0); err == nil {
+		t.Error("expected error for rating below 0.5")
+	}
+	if err := UpdateRating(id, 5.5); err == nil {
+		t.Error("expected error for rating above 5")
+	}
+}
+
+func TestMigrateRatingPrecision(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Recreate reading_entries as it was when ratings were whole stars
+	DB.Exec(`DROP TABLE reading_entries`)
+	_, err := DB.Exec(`
+		CREATE TABLE reading_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			book_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'want-to-read',
+			started_at DATETIME,
+			finished_at DATETIME,
+			rating INTEGER CHECK(rating >= 1 AND rating <= 5),
+			review TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	DB.Exec(`INSERT INTO reading_entries (book_id, status, rating, review) VALUES (?, 'finished', 4, 'Good')`, id)
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	book, err := GetBook(id)
+	if err != nil {
+		t.Fatalf("failed to get book: %v", err)
+	}
+	if book.ReadingEntry.Rating.Float64 != 4 || book.ReadingEntry.Review.String != "Good" {
+		t.Errorf("expected rating and review to survive migration, got %v %q", book.ReadingEntry.Rating, book.ReadingEntry.Review.String)
+	}
+
+	if err := UpdateRating(id, 4.5); err != nil {
+		t.Errorf("expected half stars after migration: %v", err)
+	}
+
+	// Migrating again is a no-op
+	if err := Migrate(); err != nil {
+		t.Fatalf("failed to re-run migration: %v", err)
+	}
+}
+
+// baselineSchema is the schema of the first release, before any migration.
+const baselineSchema = `
+	CREATE TABLE books (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		author TEXT NOT NULL,
+		isbn TEXT,
+		pages INTEGER,
+		cover_url TEXT,
+		description TEXT,
+		open_library_key TEXT,
+		genres TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE reading_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		book_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'want-to-read',
+		started_at DATETIME,
+		finished_at DATETIME,
+		rating INTEGER CHECK(rating >= 1 AND rating <= 5),
+		review TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX idx_reading_entries_book_id ON reading_entries(book_id);
+	CREATE INDEX idx_reading_entries_status ON reading_entries(status);
+
+	CREATE TABLE reading_goals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		year INTEGER NOT NULL UNIQUE,
+		target INTEGER NOT NULL CHECK(target > 0),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE site_config (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+`
+
+// openSchemaDB opens a new database with the given schema, not yet migrated.
+func openSchemaDB(t *testing.T, schema string) {
+	t.Helper()
+	var err error
+	DB, err = sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { DB.Close() })
+	if _, err := DB.Exec(schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	openSchemaDB(t, baselineSchema)
+
+	DB.Exec(`INSERT INTO books (title, author) VALUES ('Dune', 'Frank Herbert')`)
+	DB.Exec(`INSERT INTO reading_entries (book_id, status, rating, review) VALUES (1, 'finished', 4, 'Good')`)
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	book, err := GetBook(1)
+	if err != nil {
+		t.Fatalf("failed to get book: %v", err)
+	}
+	if book.Book.Title != "Dune" || book.ReadingEntry.Rating.Float64 != 4 || book.ReadingEntry.Review.String != "Good" {
+		t.Errorf("expected the book to survive migration, got %+v", book)
+	}
+	if err := UpdateRating(1, 4.5); err != nil {
+		t.Errorf("expected half stars after migration: %v", err)
+	}
+	if err := UpdateProgress(1, 100); err != nil {
+		t.Errorf("expected progress after migration: %v", err)
+	}
+
+	book.Book.Series = sql.NullString{String: "Dune", Valid: true}
+	book.Book.Tags = sql.NullString{String: "sf", Valid: true}
+	if err := UpdateBook(&book.Book); err != nil {
+		t.Errorf("expected the new book columns after migration: %v", err)
+	}
+	pages := 10
+	if _, err := AddSession(1, time.Now(), &pages, nil, nil); err != nil {
+		t.Errorf("expected reading sessions after migration: %v", err)
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("failed to re-run migration: %v", err)
+	}
+}
+
+func TestMigrateUnrecognizedRatings(t *testing.T) {
+	openSchemaDB(t, strings.Replace(baselineSchema, "rating INTEGER CHECK(rating >= 1 AND rating <= 5)", "rating INTEGER CHECK (rating BETWEEN 1 AND 5)", 1))
+
+	err := Migrate()
+	if err == nil || !strings.Contains(err.Error(), "unrecognized reading_entries table") {
+		t.Errorf("expected an unrecognized table error, got %v", err)
+	}
+}
+
+func TestGetRatingScale(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	scale, err := GetRatingScale()
+	if err != nil {
+		t.Fatalf("failed to get rating scale: %v", err)
+	}
+	if scale != models.RatingScaleStars {
+		t.Errorf("expected default scale 'stars', got %s", scale)
+	}
+
+	SetConfig("rating.scale", "10-point")
+	scale, _ = GetRatingScale()
+	if scale != models.RatingScaleTenPoint {
+		t.Errorf("expected '10-point', got %s", scale)
+	}
+
+	SetConfig("rating.scale", "bogus")
+	scale, _ = GetRatingScale()
+	if scale != models.RatingScaleStars {
+		t.Errorf("expected unknown scale to fall back to 'stars', got %s", scale)
+	}
+}
+
 func TestUpdateReview(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -345,6 +650,95 @@ func TestGetStats(t *testing.T) {
 
 // Goal tests
 
+func TestInsertBookWithEdition(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	book := models.Book{
+		Title:       "Dune",
+		Author:      "Frank Herbert",
+		EditionKey:  sql.NullString{String: "/books/OL1M", Valid: true},
+		Publisher:   sql.NullString{String: "Ace", Valid: true},
+		PublishDate: sql.NullString{String: "October 1, 2005", Valid: true},
+		Language:    sql.NullString{String: "eng", Valid: true},
+		Format:      sql.NullString{String: "paperback", Valid: true},
+	}
+	id, err := InsertBook(&book)
+	if err != nil {
+		t.Fatalf("failed to insert book: %v", err)
+	}
+	CreateReadingEntry(id, models.StatusWantToRead)
+
+	got, err := GetBook(id)
+	if err != nil {
+		t.Fatalf("failed to get book: %v", err)
+	}
+	if got.Book.EditionKey.String != "/books/OL1M" || got.Book.Publisher.String != "Ace" ||
+		got.Book.PublishDate.String != "October 1, 2005" || got.Book.Language.String != "eng" ||
+		got.Book.Format.String != "paperback" {
+		t.Errorf("edition details not stored: %+v", got.Book)
+	}
+}
+
+func TestUpdateBookMetadata(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	desc := "Old description"
+	pages := 310
+	id, _ := AddBook("Dune", "Frank Herbert", nil, nil, &desc, nil, nil, &pages)
+	CreateReadingEntry(id, models.StatusWantToRead)
+
+	newPages := 412
+	cover := "https://covers.openlibrary.org/b/id/42-M.jpg"
+	if err := UpdateBookMetadata(id, nil, nil, &cover, &newPages); err != nil {
+		t.Fatalf("failed to update metadata: %v", err)
+	}
+
+	book, _ := GetBook(id)
+	if book.Book.Pages.Int64 != 412 || book.Book.CoverURL.String != cover {
+		t.Errorf("expected pages and cover updated, got %v, %v", book.Book.Pages, book.Book.CoverURL)
+	}
+	if book.Book.Description.String != desc {
+		t.Errorf("expected nil description to leave it unchanged, got %q", book.Book.Description.String)
+	}
+}
+
+func TestGetBooksWithLookupKey(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	key := "/works/OL1W"
+	isbn := "9780441013593"
+	for _, id := range []int64{
+		mustAddBook(t, "Keyed", nil, &key),
+		mustAddBook(t, "With ISBN", &isbn, nil),
+		mustAddBook(t, "Neither", nil, nil),
+	} {
+		CreateReadingEntry(id, models.StatusWantToRead)
+	}
+
+	books, err := GetBooksWithLookupKey()
+	if err != nil {
+		t.Fatalf("failed to get books: %v", err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("expected 2 books, got %d", len(books))
+	}
+	if books[0].Book.Title != "Keyed" || books[1].Book.Title != "With ISBN" {
+		t.Errorf("unexpected books: %s, %s", books[0].Book.Title, books[1].Book.Title)
+	}
+}
+
+func mustAddBook(t *testing.T, title string, isbn, key *string) int64 {
+	t.Helper()
+	id, err := AddBook(title, "Author", isbn, nil, nil, key, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to add book: %v", err)
+	}
+	return id
+}
+
 func TestSetGoal(t *testing.T) {
 	cleanup := setupTestDB(t)
 	defer cleanup()
@@ -753,3 +1147,364 @@ func TestGetSiteConfig(t *testing.T) {
 		t.Errorf("expected default subtitle, got '%s'", config.Subtitle)
 	}
 }
+
+// Reading session tests
+
+func TestAddSession(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusReading)
+
+	pages, minutes := 30, 45
+	note := "Great chapter"
+	day := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	if _, err := AddSession(id, day, &pages, &minutes, &note); err != nil {
+		t.Fatalf("failed to add session: %v", err)
+	}
+	if _, err := AddSession(id, day.AddDate(0, 0, 1), nil, &minutes, nil); err != nil {
+		t.Fatalf("failed to add session: %v", err)
+	}
+
+	sessions, err := GetSessions(id)
+	if err != nil {
+		t.Fatalf("failed to get sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	if !sessions[0].Date.Equal(day) {
+		t.Errorf("expected date %v, got %v", day, sessions[0].Date)
+	}
+	if sessions[0].Note.String != "Great chapter" {
+		t.Errorf("expected note 'Great chapter', got %q", sessions[0].Note.String)
+	}
+	if sessions[1].Pages.Valid {
+		t.Error("expected second session to have no pages")
+	}
+
+	totals, err := GetSessionTotals(id)
+	if err != nil {
+		t.Fatalf("failed to get totals: %v", err)
+	}
+	if totals.Sessions != 2 || totals.Pages != 30 || totals.Minutes != 90 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+}
+
+func TestAddSessionRejectsNonPositive(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	pages := 0
+	if _, err := AddSession(id, time.Now(), &pages, nil, nil); err == nil {
+		t.Error("expected error for zero pages")
+	}
+}
+
+func TestDeleteBookRemovesSessions(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusReading)
+	pages := 10
+	AddSession(id, time.Now(), &pages, nil, nil)
+
+	if err := DeleteBook(id); err != nil {
+		t.Fatalf("failed to delete book: %v", err)
+	}
+
+	totals, _ := GetSessionTotals(id)
+	if totals.Sessions != 0 {
+		t.Errorf("expected sessions to be deleted, got %d", totals.Sessions)
+	}
+}
+
+func TestGetReadingStreaks(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	pages := 10
+	today := time.Date(2026, 5, 20, 21, 0, 0, 0, time.UTC)
+
+	// A 4-day run in April, then a 2-day run ending yesterday
+	for _, day := range []time.Time{
+		time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 4, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 18, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 19, 0, 0, 0, 0, time.UTC), // same day twice
+	} {
+		AddSession(id, day, &pages, nil, nil)
+	}
+
+	current, longest, err := GetReadingStreaks(today)
+	if err != nil {
+		t.Fatalf("failed to get streaks: %v", err)
+	}
+	if current != 2 {
+		t.Errorf("expected current streak 2, got %d", current)
+	}
+	if longest != 4 {
+		t.Errorf("expected longest streak 4, got %d", longest)
+	}
+
+	// Two days later the current streak is broken
+	current, _, _ = GetReadingStreaks(today.AddDate(0, 0, 2))
+	if current != 0 {
+		t.Errorf("expected broken streak, got %d", current)
+	}
+
+	// A session logged in advance doesn't keep the streak alive
+	AddSession(id, time.Date(2026, 5, 25, 0, 0, 0, 0, time.UTC), &pages, nil, nil)
+	current, longest, _ = GetReadingStreaks(today.AddDate(0, 0, 2))
+	if current != 0 || longest != 4 {
+		t.Errorf("expected a future session not to count, got %d/%d", current, longest)
+	}
+}
+
+func TestGetReadingStreaksEmpty(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	current, longest, err := GetReadingStreaks(time.Now())
+	if err != nil {
+		t.Fatalf("failed to get streaks: %v", err)
+	}
+	if current != 0 || longest != 0 {
+		t.Errorf("expected no streaks, got %d/%d", current, longest)
+	}
+}
+
+func TestUpdateProgress(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusReading)
+
+	if err := UpdateProgress(id, 120); err != nil {
+		t.Fatalf("failed to update progress: %v", err)
+	}
+
+	book, _ := GetBook(id)
+	if !book.ReadingEntry.CurrentPage.Valid || book.ReadingEntry.CurrentPage.Int64 != 120 {
+		t.Errorf("expected current page 120, got %v", book.ReadingEntry.CurrentPage)
+	}
+}
+
+func TestUpdateListeningProgress(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := InsertBook(&models.Book{
+		Title:           "Dune",
+		Author:          "Frank Herbert",
+		Format:          sql.NullString{String: "audiobook", Valid: true},
+		DurationMinutes: sql.NullInt64{Int64: 1260, Valid: true},
+	})
+	CreateReadingEntry(id, models.StatusReading)
+
+	if err := UpdateListeningProgress(id, 192); err != nil {
+		t.Fatalf("failed to update listening progress: %v", err)
+	}
+
+	book, _ := GetBook(id)
+	if !book.ReadingEntry.CurrentMinutes.Valid || book.ReadingEntry.CurrentMinutes.Int64 != 192 {
+		t.Errorf("expected 192 minutes listened, got %v", book.ReadingEntry.CurrentMinutes)
+	}
+	if book.Book.DurationMinutes.Int64 != 1260 || !book.Book.IsAudiobook() {
+		t.Errorf("audiobook details not stored: %+v", book.Book)
+	}
+}
+
+func TestGetStatsSeparatesListening(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	pages := 300
+	printed, _ := AddBook("Printed", "Author", nil, nil, nil, nil, nil, &pages)
+	CreateReadingEntry(printed, models.StatusReading)
+	UpdateStatus(printed, models.StatusFinished)
+
+	audio, _ := InsertBook(&models.Book{
+		Title:           "Listened",
+		Author:          "Author",
+		Pages:           sql.NullInt64{Int64: 500, Valid: true},
+		Format:          sql.NullString{String: "audiobook", Valid: true},
+		DurationMinutes: sql.NullInt64{Int64: 690, Valid: true},
+	})
+	CreateReadingEntry(audio, models.StatusReading)
+	UpdateStatus(audio, models.StatusFinished)
+
+	stats, err := GetStats()
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	if stats.PagesThisYear != 300 {
+		t.Errorf("expected 300 pages read, got %d", stats.PagesThisYear)
+	}
+	if stats.MinutesListenedThisYear != 690 {
+		t.Errorf("expected 690 minutes listened, got %d", stats.MinutesListenedThisYear)
+	}
+	if stats.BooksThisYear != 2 {
+		t.Errorf("expected 2 books this year, got %d", stats.BooksThisYear)
+	}
+}
+
+func TestSaveAuthor(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	author := models.Author{
+		OpenLibraryKey: "/authors/OL79034A",
+		Name:           "Frank Herbert",
+		BirthDate:      sql.NullString{String: "8 October 1920", Valid: true},
+		Works:          sql.NullString{String: `[{"key": "/works/OL893415W", "title": "Dune"}]`, Valid: true},
+	}
+	id, err := SaveAuthor(&author)
+	if err != nil {
+		t.Fatalf("failed to save author: %v", err)
+	}
+
+	// Saving again updates the same row
+	author.Bio = sql.NullString{String: "Science fiction author.", Valid: true}
+	again, err := SaveAuthor(&author)
+	if err != nil {
+		t.Fatalf("failed to update author: %v", err)
+	}
+	if again != id {
+		t.Errorf("expected ID %d on update, got %d", id, again)
+	}
+
+	got, err := GetAuthorByName("herbert")
+	if err != nil {
+		t.Fatalf("failed to get author: %v", err)
+	}
+	if got == nil || got.Bio.String != "Science fiction author." || got.BirthDate.String != "8 October 1920" {
+		t.Fatalf("unexpected author: %+v", got)
+	}
+	if works := got.WorkList(); len(works) != 1 || works[0].Title != "Dune" {
+		t.Errorf("unexpected works: %+v", works)
+	}
+
+	missing, err := GetAuthorByName("Le Guin")
+	if err != nil || missing != nil {
+		t.Errorf("expected no author, got %+v, %v", missing, err)
+	}
+}
+
+func TestGetBooksByAuthor(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	dune, _ := AddBook("Dune", "Frank Herbert", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(dune, models.StatusFinished)
+	other, _ := AddBook("The Dispossessed", "Ursula K. Le Guin", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(other, models.StatusWantToRead)
+
+	books, err := GetBooksByAuthor("frank herbert")
+	if err != nil {
+		t.Fatalf("failed to get books: %v", err)
+	}
+	if len(books) != 1 || books[0].Book.ID != dune {
+		t.Errorf("expected only Dune, got %+v", books)
+	}
+}
+
+func TestReadingTimer(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	timer, err := GetTimer()
+	if err != nil {
+		t.Fatalf("failed to get timer: %v", err)
+	}
+	if timer != nil {
+		t.Fatal("expected no timer")
+	}
+
+	id, _ := AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
+	started := time.Date(2026, 3, 14, 20, 30, 0, 0, time.UTC)
+	page := 42
+	if err := StartTimer(id, started, &page); err != nil {
+		t.Fatalf("failed to start timer: %v", err)
+	}
+
+	if err := StartTimer(id, started, nil); err == nil {
+		t.Error("expected error starting a second timer")
+	}
+
+	timer, err = GetTimer()
+	if err != nil {
+		t.Fatalf("failed to get timer: %v", err)
+	}
+	if timer.BookID != id || !timer.StartedAt.Equal(started) || timer.StartPage.Int64 != 42 {
+		t.Errorf("unexpected timer: %+v", timer)
+	}
+
+	if err := ClearTimer(); err != nil {
+		t.Fatalf("failed to clear timer: %v", err)
+	}
+	timer, _ = GetTimer()
+	if timer != nil {
+		t.Error("expected timer to be cleared")
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, _ := AddBook("Dune", "Frank Herbert", nil, nil, nil, nil, nil, nil)
+	CreateReadingEntry(id, models.StatusReading)
+
+	later := models.Quote{BookID: id, Text: "The spice must flow.", Location: sql.NullString{String: "2010-2011", Valid: true}}
+	if _, err := AddQuote(&later); err != nil {
+		t.Fatalf("failed to add quote: %v", err)
+	}
+	earlier := models.Quote{
+		BookID:    id,
+		Text:      "I must not fear.",
+		Page:      sql.NullInt64{Int64: 8, Valid: true},
+		Note:      sql.NullString{String: "The litany", Valid: true},
+		CreatedAt: time.Now().AddDate(0, 0, -1),
+	}
+	if _, err := AddQuote(&earlier); err != nil {
+		t.Fatalf("failed to add quote: %v", err)
+	}
+
+	quotes, err := GetQuotes(id)
+	if err != nil {
+		t.Fatalf("failed to get quotes: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("expected 2 quotes, got %d", len(quotes))
+	}
+	if quotes[0].Text != "I must not fear." || quotes[0].Page.Int64 != 8 || quotes[0].Note.String != "The litany" {
+		t.Errorf("expected the older quote first, got %+v", quotes[0])
+	}
+	if quotes[1].Location.String != "2010-2011" || quotes[1].Page.Valid {
+		t.Errorf("unexpected quote: %+v", quotes[1])
+	}
+
+	if exists, _ := QuoteExists(id, "I must not fear."); !exists {
+		t.Error("expected quote to exist")
+	}
+	if exists, _ := QuoteExists(id, "Fear is the mind-killer."); exists {
+		t.Error("expected quote not to exist")
+	}
+
+	if err := DeleteBook(id); err != nil {
+		t.Fatalf("failed to delete book: %v", err)
+	}
+	if quotes, _ := GetQuotes(id); len(quotes) != 0 {
+		t.Errorf("expected quotes to be deleted, got %d", len(quotes))
+	}
+}
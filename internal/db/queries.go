@@ -19,6 +19,38 @@ func AddBook(title, author string, isbn, coverURL, description, openLibraryKey,
 	return result.LastInsertId()
 }
 
+// InsertBook adds a book from a fully populated model, ignoring its ID and CreatedAt.
+func InsertBook(book *models.Book) (int64, error) {
+	result, err := DB.Exec(`
+		INSERT INTO books (title, author, isbn, pages, cover_url, description, open_library_key, genres,
+		                   edition_key, publisher, publish_date, language, format, duration_minutes, tags,
+		                   series, series_index, calibre_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, book.Title, book.Author, book.ISBN, book.Pages, book.CoverURL, book.Description, book.OpenLibraryKey, book.Genres,
+		book.EditionKey, book.Publisher, book.PublishDate, book.Language, book.Format, book.DurationMinutes, book.Tags,
+		book.Series, book.SeriesIndex, book.CalibreID)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateBook overwrites every editable field of a book, including clearing
+// fields that are no longer set.
+func UpdateBook(book *models.Book) error {
+	_, err := DB.Exec(`
+		UPDATE books
+		SET title = ?, author = ?, isbn = ?, pages = ?, cover_url = ?,
+		    description = ?, open_library_key = ?, genres = ?,
+		    edition_key = ?, publisher = ?, publish_date = ?, language = ?, format = ?, duration_minutes = ?, tags = ?,
+		    series = ?, series_index = ?, calibre_id = ?
+		WHERE id = ?
+	`, book.Title, book.Author, book.ISBN, book.Pages, book.CoverURL, book.Description, book.OpenLibraryKey, book.Genres,
+		book.EditionKey, book.Publisher, book.PublishDate, book.Language, book.Format, book.DurationMinutes, book.Tags,
+		book.Series, book.SeriesIndex, book.CalibreID, book.ID)
+	return err
+}
+
 func CreateReadingEntry(bookID int64, status models.BookStatus) error {
 	_, err := DB.Exec(`
 		INSERT INTO reading_entries (book_id, status, updated_at)
@@ -27,25 +59,45 @@ func CreateReadingEntry(bookID int64, status models.BookStatus) error {
 	return err
 }
 
-func GetBook(id int64) (*models.BookWithEntry, error) {
-	row := DB.QueryRow(`
+// bookWithEntrySelect selects every column scanned by scanBookWithEntry.
+const bookWithEntrySelect = `
 		SELECT
 			b.id, b.title, b.author, b.isbn, b.pages, b.cover_url, b.description, b.open_library_key, b.genres, b.created_at,
-			r.id, r.book_id, r.status, r.started_at, r.finished_at, r.rating, r.review, r.updated_at
+			b.edition_key, b.publisher, b.publish_date, b.language, b.format, b.duration_minutes, b.tags,
+			b.series, b.series_index, b.calibre_id,
+			r.id, r.book_id, r.status, r.started_at, r.finished_at, r.rating, r.review, r.current_page, r.current_minutes, r.updated_at
 		FROM books b
 		LEFT JOIN reading_entries r ON b.id = r.book_id
-		WHERE b.id = ?
-	`, id)
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+func scanBookWithEntry(row rowScanner) (models.BookWithEntry, error) {
 	var book models.BookWithEntry
 	err := row.Scan(
 		&book.Book.ID, &book.Book.Title, &book.Book.Author, &book.Book.ISBN,
 		&book.Book.Pages, &book.Book.CoverURL, &book.Book.Description,
 		&book.Book.OpenLibraryKey, &book.Book.Genres, &book.Book.CreatedAt,
+		&book.Book.EditionKey, &book.Book.Publisher, &book.Book.PublishDate, &book.Book.Language, &book.Book.Format,
+		&book.Book.DurationMinutes, &book.Book.Tags,
+		&book.Book.Series, &book.Book.SeriesIndex, &book.Book.CalibreID,
 		&book.ReadingEntry.ID, &book.ReadingEntry.BookID, &book.ReadingEntry.Status,
 		&book.ReadingEntry.StartedAt, &book.ReadingEntry.FinishedAt,
-		&book.ReadingEntry.Rating, &book.ReadingEntry.Review, &book.ReadingEntry.UpdatedAt,
+		&book.ReadingEntry.Rating, &book.ReadingEntry.Review, &book.ReadingEntry.CurrentPage,
+		&book.ReadingEntry.CurrentMinutes,
+		&book.ReadingEntry.UpdatedAt,
 	)
+	return book, err
+}
+
+func GetBook(id int64) (*models.BookWithEntry, error) {
+	row := DB.QueryRow(bookWithEntrySelect+`
+		WHERE b.id = ?
+	`, id)
+
+	book, err := scanBookWithEntry(row)
 	if err != nil {
 		return nil, err
 	}
@@ -53,13 +105,7 @@ func GetBook(id int64) (*models.BookWithEntry, error) {
 }
 
 func ListBooks(opts models.ListOptions) ([]models.BookWithEntry, error) {
-	query := `
-		SELECT
-			b.id, b.title, b.author, b.isbn, b.pages, b.cover_url, b.description, b.open_library_key, b.genres, b.created_at,
-			r.id, r.book_id, r.status, r.started_at, r.finished_at, r.rating, r.review, r.updated_at
-		FROM books b
-		LEFT JOIN reading_entries r ON b.id = r.book_id
-	`
+	query := bookWithEntrySelect
 	var args []any
 	var conditions []string
 
@@ -98,15 +144,7 @@ func ListBooks(opts models.ListOptions) ([]models.BookWithEntry, error) {
 
 	var books []models.BookWithEntry
 	for rows.Next() {
-		var book models.BookWithEntry
-		err := rows.Scan(
-			&book.Book.ID, &book.Book.Title, &book.Book.Author, &book.Book.ISBN,
-			&book.Book.Pages, &book.Book.CoverURL, &book.Book.Description,
-			&book.Book.OpenLibraryKey, &book.Book.Genres, &book.Book.CreatedAt,
-			&book.ReadingEntry.ID, &book.ReadingEntry.BookID, &book.ReadingEntry.Status,
-			&book.ReadingEntry.StartedAt, &book.ReadingEntry.FinishedAt,
-			&book.ReadingEntry.Rating, &book.ReadingEntry.Review, &book.ReadingEntry.UpdatedAt,
-		)
+		book, err := scanBookWithEntry(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -120,15 +158,24 @@ func UpdateStatus(bookID int64, status models.BookStatus) error {
 }
 
 func UpdateStatusWithDate(bookID int64, status models.BookStatus, setDate bool) error {
-	now := time.Now().Format("2006-01-02 15:04:05")
+	if !setDate {
+		return UpdateStatusOn(bookID, status, nil)
+	}
+	now := time.Now()
+	return UpdateStatusOn(bookID, status, &now)
+}
+
+// UpdateStatusOn changes a book's status, recording date as the start or finish
+// date for reading and finished respectively. A nil date keeps the existing one.
+func UpdateStatusOn(bookID int64, status models.BookStatus, date *time.Time) error {
 	var startedAt, finishedAt any
 
-	if setDate {
+	if date != nil {
 		switch status {
 		case models.StatusReading:
-			startedAt = now
+			startedAt = date.Format("2006-01-02 15:04:05")
 		case models.StatusFinished:
-			finishedAt = now
+			finishedAt = date.Format("2006-01-02 15:04:05")
 		}
 	}
 
@@ -136,11 +183,34 @@ func UpdateStatusWithDate(bookID int64, status models.BookStatus, setDate bool)
 		UPDATE reading_entries
 		SET status = ?, started_at = COALESCE(?, started_at), finished_at = COALESCE(?, finished_at), updated_at = ?
 		WHERE book_id = ?
-	`, status, startedAt, finishedAt, now, bookID)
+	`, status, startedAt, finishedAt, time.Now().Format("2006-01-02 15:04:05"), bookID)
+	return err
+}
+
+// SetReadingDates overwrites a book's start and finish dates. Invalid values clear the date.
+func SetReadingDates(bookID int64, startedAt, finishedAt sql.NullTime) error {
+	if err := models.CheckReadingDates(startedAt, finishedAt); err != nil {
+		return err
+	}
+
+	var started, finished any
+	if startedAt.Valid {
+		started = startedAt.Time.Format("2006-01-02 15:04:05")
+	}
+	if finishedAt.Valid {
+		finished = finishedAt.Time.Format("2006-01-02 15:04:05")
+	}
+
+	_, err := DB.Exec(`
+		UPDATE reading_entries
+		SET started_at = ?, finished_at = ?, updated_at = ?
+		WHERE book_id = ?
+	`, started, finished, time.Now().Format("2006-01-02 15:04:05"), bookID)
 	return err
 }
 
-func UpdateRating(bookID int64, rating int) error {
+// UpdateRating sets a book's rating in stars (0.5 to 5).
+func UpdateRating(bookID int64, rating float64) error {
 	_, err := DB.Exec(`
 		UPDATE reading_entries
 		SET rating = ?, updated_at = ?
@@ -149,6 +219,26 @@ func UpdateRating(bookID int64, rating int) error {
 	return err
 }
 
+// UpdateProgress records the page a book is currently on.
+func UpdateProgress(bookID int64, page int) error {
+	_, err := DB.Exec(`
+		UPDATE reading_entries
+		SET current_page = ?, updated_at = ?
+		WHERE book_id = ?
+	`, page, time.Now().Format("2006-01-02 15:04:05"), bookID)
+	return err
+}
+
+// UpdateListeningProgress records how far into an audiobook you are, in minutes.
+func UpdateListeningProgress(bookID int64, minutes int) error {
+	_, err := DB.Exec(`
+		UPDATE reading_entries
+		SET current_minutes = ?, updated_at = ?
+		WHERE book_id = ?
+	`, minutes, time.Now().Format("2006-01-02 15:04:05"), bookID)
+	return err
+}
+
 func UpdateReview(bookID int64, review string) error {
 	_, err := DB.Exec(`
 		UPDATE reading_entries
@@ -159,14 +249,18 @@ func UpdateReview(bookID int64, review string) error {
 }
 
 type Stats struct {
-	TotalBooks      int
-	WantToRead      int
-	Reading         int
-	Finished        int
-	BooksThisYear   int
-	PagesThisYear   int
-	AverageRating   float64
-	RatedBooksCount int
+	TotalBooks              int
+	WantToRead              int
+	Reading                 int
+	Finished                int
+	DidNotFinish            int
+	BooksThisYear           int
+	PagesThisYear           int
+	MinutesListenedThisYear int
+	AverageRating           float64
+	RatedBooksCount         int
+	CurrentStreak           int
+	LongestStreak           int
 }
 
 func GetStats() (*Stats, error) {
@@ -186,6 +280,9 @@ func GetStats() (*Stats, error) {
 	row = DB.QueryRow(`SELECT COUNT(*) FROM reading_entries WHERE status = 'finished'`)
 	row.Scan(&stats.Finished)
 
+	row = DB.QueryRow(`SELECT COUNT(*) FROM reading_entries WHERE status = 'did-not-finish'`)
+	row.Scan(&stats.DidNotFinish)
+
 	// Books finished this year
 	row = DB.QueryRow(`
 		SELECT COUNT(*) FROM reading_entries
@@ -193,13 +290,16 @@ func GetStats() (*Stats, error) {
 	`, currentYear)
 	row.Scan(&stats.BooksThisYear)
 
-	// Pages read this year
+	// Pages read and time listened this year, kept apart so audiobooks don't count as pages
 	row = DB.QueryRow(`
-		SELECT COALESCE(SUM(b.pages), 0) FROM books b
+		SELECT
+			COALESCE(SUM(CASE WHEN COALESCE(b.format, '') != 'audiobook' THEN b.pages END), 0),
+			COALESCE(SUM(CASE WHEN b.format = 'audiobook' THEN b.duration_minutes END), 0)
+		FROM books b
 		JOIN reading_entries r ON b.id = r.book_id
 		WHERE r.status = 'finished' AND strftime('%Y', r.finished_at) = ?
 	`, currentYear)
-	row.Scan(&stats.PagesThisYear)
+	row.Scan(&stats.PagesThisYear, &stats.MinutesListenedThisYear)
 
 	// Average rating
 	row = DB.QueryRow(`
@@ -208,6 +308,14 @@ func GetStats() (*Stats, error) {
 	`)
 	row.Scan(&stats.AverageRating, &stats.RatedBooksCount)
 
+	// Reading streaks from logged sessions
+	current, longest, err := GetReadingStreaks(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stats.CurrentStreak = current
+	stats.LongestStreak = longest
+
 	return stats, nil
 }
 
@@ -231,30 +339,42 @@ func DeleteBook(bookID int64) error {
 	if err != nil {
 		return err
 	}
+	_, err = DB.Exec(`DELETE FROM reading_sessions WHERE book_id = ?`, bookID)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`DELETE FROM reading_timer WHERE book_id = ?`, bookID)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`DELETE FROM quotes WHERE book_id = ?`, bookID)
+	if err != nil {
+		return err
+	}
 	_, err = DB.Exec(`DELETE FROM books WHERE id = ?`, bookID)
 	return err
 }
 
-// UpdateBookMetadata updates optional fields that may have been missing when the book was added.
-func UpdateBookMetadata(bookID int64, description, genres *string) error {
+// UpdateBookMetadata updates metadata fetched from a provider. Nil values leave
+// the field unchanged.
+func UpdateBookMetadata(bookID int64, description, genres, coverURL *string, pages *int) error {
 	_, err := DB.Exec(`
 		UPDATE books
 		SET description = COALESCE(?, description),
-		    genres = COALESCE(?, genres)
+		    genres = COALESCE(?, genres),
+		    cover_url = COALESCE(?, cover_url),
+		    pages = COALESCE(?, pages)
 		WHERE id = ?
-	`, description, genres, bookID)
+	`, description, genres, coverURL, pages, bookID)
 	return err
 }
 
-// GetBooksWithOpenLibraryKey returns all books that have an Open Library key for refreshing metadata.
-func GetBooksWithOpenLibraryKey() ([]models.BookWithEntry, error) {
-	rows, err := DB.Query(`
-		SELECT
-			b.id, b.title, b.author, b.isbn, b.pages, b.cover_url, b.description, b.open_library_key, b.genres, b.created_at,
-			r.id, r.book_id, r.status, r.started_at, r.finished_at, r.rating, r.review, r.updated_at
-		FROM books b
-		LEFT JOIN reading_entries r ON b.id = r.book_id
-		WHERE b.open_library_key IS NOT NULL AND b.open_library_key != ''
+// GetBooksWithLookupKey returns all books with an Open Library key or an ISBN,
+// the books a metadata provider can find again.
+func GetBooksWithLookupKey() ([]models.BookWithEntry, error) {
+	rows, err := DB.Query(bookWithEntrySelect + `
+		WHERE (b.open_library_key IS NOT NULL AND b.open_library_key != '')
+		   OR (b.isbn IS NOT NULL AND b.isbn != '')
 		ORDER BY b.id
 	`)
 	if err != nil {
@@ -264,15 +384,7 @@ func GetBooksWithOpenLibraryKey() ([]models.BookWithEntry, error) {
 
 	var books []models.BookWithEntry
 	for rows.Next() {
-		var book models.BookWithEntry
-		err := rows.Scan(
-			&book.Book.ID, &book.Book.Title, &book.Book.Author, &book.Book.ISBN,
-			&book.Book.Pages, &book.Book.CoverURL, &book.Book.Description,
-			&book.Book.OpenLibraryKey, &book.Book.Genres, &book.Book.CreatedAt,
-			&book.ReadingEntry.ID, &book.ReadingEntry.BookID, &book.ReadingEntry.Status,
-			&book.ReadingEntry.StartedAt, &book.ReadingEntry.FinishedAt,
-			&book.ReadingEntry.Rating, &book.ReadingEntry.Review, &book.ReadingEntry.UpdatedAt,
-		)
+		book, err := scanBookWithEntry(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -443,6 +555,21 @@ func GetSiteConfig() (models.SiteConfig, error) {
 	if v, ok := allConfig["site.base_url"]; ok && v != "" {
 		config.BaseURL = v
 	}
+	if v, ok := allConfig["rating.scale"]; ok && models.RatingScale(v).IsValid() {
+		config.RatingScale = models.RatingScale(v)
+	}
 
 	return config, nil
 }
+
+// GetRatingScale returns the configured rating scale, defaulting to whole stars.
+func GetRatingScale() (models.RatingScale, error) {
+	value, err := GetConfig("rating.scale")
+	if err != nil {
+		return models.RatingScaleStars, err
+	}
+	if scale := models.RatingScale(value); scale.IsValid() {
+		return scale, nil
+	}
+	return models.RatingScaleStars, nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"bookshelf/internal/models"
+	"time"
+)
+
+// AddQuote saves a quote, ignoring its ID. A zero CreatedAt means now.
+func AddQuote(quote *models.Quote) (int64, error) {
+	createdAt := quote.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	result, err := DB.Exec(`
+		INSERT INTO quotes (book_id, text, page, location, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, quote.BookID, quote.Text, quote.Page, quote.Location, quote.Note, createdAt.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetQuotes returns a book's quotes, oldest first.
+func GetQuotes(bookID int64) ([]models.Quote, error) {
+	rows, err := DB.Query(`
+		SELECT id, book_id, text, page, location, note, created_at
+		FROM quotes
+		WHERE book_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotes []models.Quote
+	for rows.Next() {
+		var q models.Quote
+		if err := rows.Scan(&q.ID, &q.BookID, &q.Text, &q.Page, &q.Location, &q.Note, &q.CreatedAt); err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, q)
+	}
+	return quotes, rows.Err()
+}
+
+// QuoteExists reports whether a book already has a quote with exactly this text.
+func QuoteExists(bookID int64, text string) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM quotes WHERE book_id = ? AND text = ?)`, bookID, text).Scan(&exists)
+	return exists, err
+}
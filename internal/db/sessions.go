@@ -0,0 +1,164 @@
+package db
+
+import (
+	"bookshelf/internal/models"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SessionTotals summarises the reading sessions logged for a book.
+type SessionTotals struct {
+	Sessions int
+	Pages    int
+	Minutes  int
+}
+
+// AddSession logs a reading session for a book on the given day.
+func AddSession(bookID int64, date time.Time, pages, minutes *int, note *string) (int64, error) {
+	result, err := DB.Exec(`
+		INSERT INTO reading_sessions (book_id, date, pages, minutes, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, bookID, date.Format("2006-01-02"), pages, minutes, note, time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetSessions returns all sessions logged for a book, oldest first.
+func GetSessions(bookID int64) ([]models.ReadingSession, error) {
+	rows, err := DB.Query(`
+		SELECT id, book_id, date, pages, minutes, note, created_at
+		FROM reading_sessions
+		WHERE book_id = ?
+		ORDER BY date ASC, id ASC
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.ReadingSession
+	for rows.Next() {
+		var s models.ReadingSession
+		if err := rows.Scan(&s.ID, &s.BookID, &s.Date, &s.Pages, &s.Minutes, &s.Note, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetSessionTotals returns the number of sessions and the pages and minutes logged for a book.
+func GetSessionTotals(bookID int64) (*SessionTotals, error) {
+	var totals SessionTotals
+	err := DB.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(pages), 0), COALESCE(SUM(minutes), 0)
+		FROM reading_sessions
+		WHERE book_id = ?
+	`, bookID).Scan(&totals.Sessions, &totals.Pages, &totals.Minutes)
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+// GetReadingStreaks returns the current and longest run of consecutive days with
+// at least one reading session. The current streak is still alive if the last
+// session was today or yesterday.
+func GetReadingStreaks(today time.Time) (current, longest int, err error) {
+	rows, err := DB.Query(`SELECT DISTINCT date(date) FROM reading_sessions ORDER BY 1`)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return 0, 0, err
+		}
+		day, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return 0, 0, err
+		}
+		days = append(days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	current, longest = computeStreaks(days, today)
+	return current, longest, nil
+}
+
+// computeStreaks expects days sorted ascending without duplicates. Days after
+// today, from sessions logged in advance, don't count.
+func computeStreaks(days []time.Time, today time.Time) (current, longest int) {
+	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	for len(days) > 0 && days[len(days)-1].After(todayDate) {
+		days = days[:len(days)-1]
+	}
+	if len(days) == 0 {
+		return 0, 0
+	}
+
+	run := 1
+	longest = 1
+	for i := 1; i < len(days); i++ {
+		if days[i].Sub(days[i-1]) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	gap := todayDate.Sub(days[len(days)-1])
+	if gap <= 24*time.Hour {
+		current = run
+	}
+	return current, longest
+}
+
+// StartTimer persists a running reading timer. Only one timer may run at a time.
+func StartTimer(bookID int64, startedAt time.Time, startPage *int) error {
+	running, err := GetTimer()
+	if err != nil {
+		return err
+	}
+	if running != nil {
+		return fmt.Errorf("a reading timer is already running for book %d", running.BookID)
+	}
+
+	_, err = DB.Exec(`
+		INSERT INTO reading_timer (id, book_id, started_at, start_page)
+		VALUES (1, ?, ?, ?)
+	`, bookID, startedAt.UTC().Format("2006-01-02 15:04:05"), startPage)
+	return err
+}
+
+// GetTimer returns the running reading timer. Returns nil if none is running.
+func GetTimer() (*models.ReadingTimer, error) {
+	var timer models.ReadingTimer
+	err := DB.QueryRow(`
+		SELECT book_id, started_at, start_page FROM reading_timer WHERE id = 1
+	`).Scan(&timer.BookID, &timer.StartedAt, &timer.StartPage)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timer, nil
+}
+
+// ClearTimer removes the running reading timer, if any.
+func ClearTimer() error {
+	_, err := DB.Exec(`DELETE FROM reading_timer`)
+	return err
+}
@@ -0,0 +1,249 @@
+// Package export writes the shelf out in formats other tools can read.
+package export
+
+import (
+	"bookshelf/internal/models"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column is a CSV column, read from a book.
+type Column struct {
+	Name        string
+	Description string
+	Value       func(b *models.BookWithEntry) string
+}
+
+// Columns are the columns WriteCSV can write, by name.
+var Columns = map[string]Column{}
+
+// DefaultColumns are written when no columns are chosen.
+var DefaultColumns = []string{"title", "author", "status", "rating", "finished_at", "pages", "genres"}
+
+func init() {
+	for _, c := range []Column{
+		{"id", "Book ID", func(b *models.BookWithEntry) string { return strconv.FormatInt(b.Book.ID, 10) }},
+		{"title", "Title", func(b *models.BookWithEntry) string { return b.Book.Title }},
+		{"author", "Author", func(b *models.BookWithEntry) string { return b.Book.Author }},
+		{"isbn", "ISBN", func(b *models.BookWithEntry) string { return b.Book.ISBN.String }},
+		{"pages", "Page count", func(b *models.BookWithEntry) string { return nullInt(b.Book.Pages) }},
+		{"duration_minutes", "Running time, for audiobooks", func(b *models.BookWithEntry) string { return nullInt(b.Book.DurationMinutes) }},
+		{"format", "hardcover, paperback, ebook or audiobook", func(b *models.BookWithEntry) string { return b.Book.Format.String }},
+		{"publisher", "Publisher", func(b *models.BookWithEntry) string { return b.Book.Publisher.String }},
+		{"publish_date", "Publication date, as the publisher gives it", func(b *models.BookWithEntry) string { return b.Book.PublishDate.String }},
+		{"language", "Language, as a MARC code", func(b *models.BookWithEntry) string { return b.Book.Language.String }},
+		{"genres", "Genres, separated by semicolons", func(b *models.BookWithEntry) string { return strings.Join(jsonList(b.Book.Genres), "; ") }},
+		{"tags", "Your tags, separated by semicolons", func(b *models.BookWithEntry) string { return strings.Join(b.Book.TagList(), "; ") }},
+		{"series", "Series name", func(b *models.BookWithEntry) string { return b.Book.Series.String }},
+		{"series_index", "Position in the series, e.g. 2 or 2.5", func(b *models.BookWithEntry) string { return nullFloat(b.Book.SeriesIndex) }},
+		{"description", "Description", func(b *models.BookWithEntry) string { return b.Book.Description.String }},
+		{"cover_url", "Cover image URL", func(b *models.BookWithEntry) string { return b.Book.CoverURL.String }},
+		{"open_library_key", "Open Library work, e.g. /works/OL893415W", func(b *models.BookWithEntry) string { return b.Book.OpenLibraryKey.String }},
+		{"added_at", "Date added", func(b *models.BookWithEntry) string { return isoDate(b.Book.CreatedAt) }},
+		{"status", "want-to-read, reading, finished or did-not-finish", func(b *models.BookWithEntry) string { return string(b.ReadingEntry.Status) }},
+		{"started_at", "Date started", func(b *models.BookWithEntry) string { return nullDate(b.ReadingEntry.StartedAt) }},
+		{"finished_at", "Date finished", func(b *models.BookWithEntry) string { return nullDate(b.ReadingEntry.FinishedAt) }},
+		{"rating", "Rating in stars, 0.5 to 5", func(b *models.BookWithEntry) string { return nullFloat(b.ReadingEntry.Rating) }},
+		{"review", "Your review", func(b *models.BookWithEntry) string { return b.ReadingEntry.Review.String }},
+		{"current_page", "Page reached", func(b *models.BookWithEntry) string { return nullInt(b.ReadingEntry.CurrentPage) }},
+		{"current_minutes", "Time listened, in minutes", func(b *models.BookWithEntry) string { return nullInt(b.ReadingEntry.CurrentMinutes) }},
+	} {
+		Columns[c.Name] = c
+	}
+}
+
+// ColumnNames lists the column names, sorted.
+func ColumnNames() []string {
+	names := make([]string, 0, len(Columns))
+	for name := range Columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseColumns reads a comma-separated list of column names.
+func ParseColumns(spec string) ([]string, error) {
+	var columns []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := Columns[name]; !ok {
+			return nil, fmt.Errorf("unknown column: %s (use: %s)", name, strings.Join(ColumnNames(), ", "))
+		}
+		columns = append(columns, name)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns given")
+	}
+	return columns, nil
+}
+
+// WriteCSV writes books as CSV with a header row of the column names. Dates
+// are ISO 8601 (2006-01-02) and lists are flattened to "a; b".
+func WriteCSV(w io.Writer, books []models.BookWithEntry, columns []string) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(columns); err != nil {
+		return err
+	}
+	for i := range books {
+		row := make([]string, len(columns))
+		for j, name := range columns {
+			row[j] = Columns[name].Value(&books[i])
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// goodreadsColumns are the columns of a Goodreads library export, which
+// Goodreads' own import reads back.
+var goodreadsColumns = []string{
+	"Title", "Author", "ISBN", "ISBN13", "My Rating", "Publisher", "Binding", "Number of Pages",
+	"Year Published", "Date Read", "Date Added", "Bookshelves", "Exclusive Shelf", "My Review",
+}
+
+// WriteGoodreadsCSV writes books in the layout of a Goodreads library export,
+// so they can be imported into Goodreads (or anything that reads its
+// exports). Ratings are rounded to whole stars, and did-not-finish books go
+// on a "did-not-finish" shelf alongside "read".
+func WriteGoodreadsCSV(w io.Writer, books []models.BookWithEntry) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(goodreadsColumns); err != nil {
+		return err
+	}
+	for i := range books {
+		b := &books[i]
+
+		isbn10, isbn13 := "", ""
+		if isbn := b.Book.ISBN.String; len(isbn) == 13 {
+			isbn13 = isbn
+		} else {
+			isbn10 = isbn
+		}
+
+		rating := "0"
+		if b.ReadingEntry.Rating.Valid {
+			rating = strconv.Itoa(int(math.Round(b.ReadingEntry.Rating.Float64)))
+		}
+
+		shelf := goodreadsShelf(b.ReadingEntry.Status)
+		shelves := append([]string{}, b.Book.TagList()...)
+		if b.ReadingEntry.Status == models.StatusDNF {
+			shelves = append(shelves, "did-not-finish")
+		}
+
+		row := []string{
+			b.Book.Title,
+			b.Book.Author,
+			isbn10,
+			isbn13,
+			rating,
+			b.Book.Publisher.String,
+			goodreadsBinding(models.BookFormat(b.Book.Format.String)),
+			nullInt(b.Book.Pages),
+			year(b.Book.PublishDate.String),
+			goodreadsDate(b.ReadingEntry.FinishedAt),
+			b.Book.CreatedAt.Format("2006/01/02"),
+			strings.Join(shelves, ", "),
+			shelf,
+			b.ReadingEntry.Review.String,
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func goodreadsShelf(status models.BookStatus) string {
+	switch status {
+	case models.StatusReading:
+		return "currently-reading"
+	case models.StatusFinished, models.StatusDNF:
+		return "read"
+	}
+	return "to-read"
+}
+
+func goodreadsBinding(format models.BookFormat) string {
+	switch format {
+	case models.FormatHardcover:
+		return "Hardcover"
+	case models.FormatPaperback:
+		return "Paperback"
+	case models.FormatEbook:
+		return "Kindle Edition"
+	case models.FormatAudiobook:
+		return "Audiobook"
+	}
+	return ""
+}
+
+func goodreadsDate(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format("2006/01/02")
+}
+
+// year finds a four-digit year in a free-form publication date.
+func year(date string) string {
+	for i := 0; i+4 <= len(date); i++ {
+		if n, err := strconv.Atoi(date[i : i+4]); err == nil && n > 1000 {
+			return date[i : i+4]
+		}
+	}
+	return ""
+}
+
+func nullInt(n sql.NullInt64) string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.FormatInt(n.Int64, 10)
+}
+
+func nullFloat(f sql.NullFloat64) string {
+	if !f.Valid {
+		return ""
+	}
+	return strconv.FormatFloat(f.Float64, 'f', -1, 64)
+}
+
+func isoDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func nullDate(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return isoDate(t.Time)
+}
+
+// jsonList decodes a JSON array of strings, such as Genres.
+func jsonList(s sql.NullString) []string {
+	if !s.Valid {
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(s.String), &list); err != nil {
+		return nil
+	}
+	return list
+}
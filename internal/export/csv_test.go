@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bookshelf/internal/models"
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testBooks() []models.BookWithEntry {
+	finished := time.Date(2024, 3, 9, 21, 30, 0, 0, time.Local)
+	return []models.BookWithEntry{
+		{
+			Book: models.Book{
+				ID:          1,
+				Title:       "Dune",
+				Author:      "Frank Herbert",
+				ISBN:        sql.NullString{String: "9780441013593", Valid: true},
+				Pages:       sql.NullInt64{Int64: 528, Valid: true},
+				Genres:      sql.NullString{String: `["Science Fiction","Classics"]`, Valid: true},
+				Format:      sql.NullString{String: "paperback", Valid: true},
+				Publisher:   sql.NullString{String: "Ace", Valid: true},
+				PublishDate: sql.NullString{String: "October 1, 2005", Valid: true},
+				Tags:        sql.NullString{String: `["favourites"]`, Valid: true},
+				CreatedAt:   time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local),
+			},
+			ReadingEntry: models.ReadingEntry{
+				Status:     models.StatusFinished,
+				FinishedAt: sql.NullTime{Time: finished, Valid: true},
+				Rating:     sql.NullFloat64{Float64: 4.5, Valid: true},
+				Review:     sql.NullString{String: "Spice, sand and \"politics\".", Valid: true},
+			},
+		},
+		{
+			Book:         models.Book{ID: 2, Title: "Infinite Jest", Author: "David Foster Wallace", CreatedAt: time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local)},
+			ReadingEntry: models.ReadingEntry{Status: models.StatusDNF},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testBooks(), DefaultColumns); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	expected := `title,author,status,rating,finished_at,pages,genres
+Dune,Frank Herbert,finished,4.5,2024-03-09,528,Science Fiction; Classics
+Infinite Jest,David Foster Wallace,did-not-finish,,,,
+`
+	if buf.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns(" Title, review,tags ")
+	if err != nil {
+		t.Fatalf("failed to parse columns: %v", err)
+	}
+	if strings.Join(columns, ",") != "title,review,tags" {
+		t.Errorf("unexpected columns: %v", columns)
+	}
+
+	if _, err := ParseColumns("title,colour"); err == nil || !strings.Contains(err.Error(), "unknown column: colour") {
+		t.Errorf("expected unknown column error, got %v", err)
+	}
+	if _, err := ParseColumns(" , "); err == nil {
+		t.Error("expected an error for no columns")
+	}
+}
+
+func TestWriteGoodreadsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGoodreadsCSV(&buf, testBooks()); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got:\n%s", buf.String())
+	}
+	if lines[0] != "Title,Author,ISBN,ISBN13,My Rating,Publisher,Binding,Number of Pages,Year Published,Date Read,Date Added,Bookshelves,Exclusive Shelf,My Review" {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	if lines[1] != `Dune,Frank Herbert,,9780441013593,5,Ace,Paperback,528,2005,2024/03/09,2024/01/02,favourites,read,"Spice, sand and ""politics""."` {
+		t.Errorf("unexpected row: %s", lines[1])
+	}
+	if lines[2] != "Infinite Jest,David Foster Wallace,,,0,,,,,,2024/02/01,did-not-finish,read," {
+		t.Errorf("unexpected DNF row: %s", lines[2])
+	}
+}
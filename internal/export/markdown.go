@@ -0,0 +1,275 @@
+package export
+
+import (
+	"bookshelf/internal/models"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NotesMarker separates a note's generated content from your own. Everything
+// below it survives re-exporting.
+const NotesMarker = "<!-- bookshelf: your notes below this line are kept when re-exporting -->"
+
+// MarkdownResult counts the notes WriteMarkdown wrote.
+type MarkdownResult struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// WriteMarkdown writes one Markdown note per book into dir, for Obsidian and
+// similar apps: YAML front matter with the book's details, then the review
+// and description. A book's note is found again by the bookshelf_id in its
+// front matter, so it can be renamed or moved within dir. Re-exporting
+// replaces the front matter fields bookshelf writes and the generated body,
+// but keeps other front matter fields and everything below NotesMarker.
+func WriteMarkdown(dir string, books []models.BookWithEntry) (MarkdownResult, error) {
+	var result MarkdownResult
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return result, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	existing, err := findNotes(dir)
+	if err != nil {
+		return result, err
+	}
+
+	taken := make(map[string]bool)
+	for _, path := range existing {
+		taken[strings.ToLower(filepath.Base(path))] = true
+	}
+
+	for i := range books {
+		b := &books[i]
+
+		path, ok := existing[b.Book.ID]
+		var old []byte
+		if ok {
+			if old, err = os.ReadFile(path); err != nil {
+				return result, err
+			}
+		} else {
+			path = filepath.Join(dir, noteFileName(b, taken))
+			taken[strings.ToLower(filepath.Base(path))] = true
+		}
+
+		note, err := renderNote(b, old)
+		if err != nil {
+			return result, fmt.Errorf("failed to write note for \"%s\": %w", b.Book.Title, err)
+		}
+		if ok && bytes.Equal(note, old) {
+			result.Unchanged++
+			continue
+		}
+		if err := os.WriteFile(path, note, 0644); err != nil {
+			return result, err
+		}
+		if ok {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+	}
+	return result, nil
+}
+
+// findNotes maps book IDs to the notes under dir that have them in their
+// front matter.
+func findNotes(dir string) (map[int64]string, error) {
+	notes := make(map[int64]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		frontMatter, _ := splitNote(data)
+		var fields struct {
+			ID int64 `yaml:"bookshelf_id"`
+		}
+		if yaml.Unmarshal(frontMatter, &fields) == nil && fields.ID > 0 {
+			notes[fields.ID] = path
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes in %s: %w", dir, err)
+	}
+	return notes, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[/\\:*?"<>|#^\[\]]+`)
+
+// noteFileName names a new note after the book's title, adding the author or
+// ID when another note already has that name.
+func noteFileName(b *models.BookWithEntry, taken map[string]bool) string {
+	clean := func(s string) string {
+		s = strings.Join(strings.Fields(unsafeFileChars.ReplaceAllString(s, " ")), " ")
+		if len(s) > 100 {
+			// Cut at 100 bytes, well under filesystems' 255, between characters.
+			cut := 100
+			for !utf8.RuneStart(s[cut]) {
+				cut--
+			}
+			s = strings.TrimSpace(s[:cut])
+		}
+		return strings.Trim(s, ". ")
+	}
+
+	title := clean(b.Book.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	for _, name := range []string{
+		title + ".md",
+		title + " (" + clean(b.Book.Author) + ").md",
+		title + " (" + strconv.FormatInt(b.Book.ID, 10) + ").md",
+	} {
+		if !taken[strings.ToLower(name)] {
+			return name
+		}
+	}
+	return fmt.Sprintf("%s (%d-%d).md", title, b.Book.ID, len(taken))
+}
+
+// splitNote separates a note's YAML front matter from its body. A note
+// without front matter is all body.
+func splitNote(data []byte) (frontMatter, body []byte) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return nil, data
+	}
+	rest := data[len("---\n"):]
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if bytes.HasSuffix(rest, []byte("\n---")) {
+			return rest[:len(rest)-len("\n---")], nil
+		}
+		return nil, data
+	}
+	return rest[:end+1], rest[end+len("\n---\n"):]
+}
+
+// renderNote builds a book's note, keeping what's yours from the old one: its
+// other front matter fields, and the notes below the marker. If the old note
+// has lost its marker, its whole body is kept as notes rather than dropped.
+func renderNote(b *models.BookWithEntry, old []byte) ([]byte, error) {
+	oldFrontMatter, oldBody := splitNote(old)
+
+	frontMatter, err := noteFrontMatter(b, oldFrontMatter)
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []byte
+	if i := bytes.Index(oldBody, []byte(NotesMarker)); i >= 0 {
+		notes = oldBody[i+len(NotesMarker):]
+	} else if len(bytes.TrimSpace(oldBody)) > 0 {
+		notes = append([]byte("\n\n"), bytes.TrimSpace(oldBody)...)
+		notes = append(notes, '\n')
+	} else {
+		notes = []byte("\n")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(frontMatter)
+	buf.WriteString("---\n\n")
+	buf.WriteString(noteBody(b))
+	buf.WriteString(NotesMarker)
+	buf.Write(notes)
+	return buf.Bytes(), nil
+}
+
+// noteFields are the front matter fields bookshelf writes, in order.
+var noteFields = []string{"title", "author", "isbn", "status", "rating", "started", "finished", "genres", "tags", "cover", "bookshelf_id"}
+
+// noteFrontMatter renders the book's front matter fields, followed by any
+// other fields from the old front matter. Empty fields are left out.
+func noteFrontMatter(b *models.BookWithEntry, old []byte) ([]byte, error) {
+	values := map[string]any{
+		"title":        b.Book.Title,
+		"author":       b.Book.Author,
+		"status":       string(b.ReadingEntry.Status),
+		"bookshelf_id": b.Book.ID,
+	}
+	if b.Book.ISBN.Valid {
+		values["isbn"] = b.Book.ISBN.String
+	}
+	if b.ReadingEntry.Rating.Valid {
+		values["rating"] = b.ReadingEntry.Rating.Float64
+	}
+	if b.ReadingEntry.StartedAt.Valid {
+		values["started"] = isoDate(b.ReadingEntry.StartedAt.Time)
+	}
+	if b.ReadingEntry.FinishedAt.Valid {
+		values["finished"] = isoDate(b.ReadingEntry.FinishedAt.Time)
+	}
+	if genres := jsonList(b.Book.Genres); len(genres) > 0 {
+		values["genres"] = genres
+	}
+	if tags := b.Book.TagList(); len(tags) > 0 {
+		values["tags"] = tags
+	}
+	if b.Book.CoverURL.Valid {
+		values["cover"] = b.Book.CoverURL.String
+	}
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range noteFields {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		var valueNode yaml.Node
+		if err := valueNode.Encode(value); err != nil {
+			return nil, err
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &valueNode)
+	}
+
+	var oldDoc yaml.Node
+	if len(old) > 0 && yaml.Unmarshal(old, &oldDoc) == nil && len(oldDoc.Content) > 0 && oldDoc.Content[0].Kind == yaml.MappingNode {
+		oldMapping := oldDoc.Content[0]
+		for i := 0; i+1 < len(oldMapping.Content); i += 2 {
+			if !slices.Contains(noteFields, oldMapping.Content[i].Value) {
+				mapping.Content = append(mapping.Content, oldMapping.Content[i], oldMapping.Content[i+1])
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(mapping); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// noteBody renders the generated part of a note, ending in a blank line.
+func noteBody(b *models.BookWithEntry) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "# %s\n\n", b.Book.Title)
+	if b.ReadingEntry.Review.Valid && strings.TrimSpace(b.ReadingEntry.Review.String) != "" {
+		fmt.Fprintf(&body, "## Review\n\n%s\n\n", strings.TrimSpace(b.ReadingEntry.Review.String))
+	}
+	if b.Book.Description.Valid && strings.TrimSpace(b.Book.Description.String) != "" {
+		fmt.Fprintf(&body, "## Description\n\n%s\n\n", strings.TrimSpace(b.Book.Description.String))
+	}
+	return body.String()
+}
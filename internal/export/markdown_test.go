@@ -0,0 +1,116 @@
+package export
+
+import (
+	"bookshelf/internal/models"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	books := testBooks()
+
+	result, err := WriteMarkdown(dir, books)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if result != (MarkdownResult{Created: 2}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	path := filepath.Join(dir, "Dune.md")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	expected := `---
+title: Dune
+author: Frank Herbert
+isbn: "9780441013593"
+status: finished
+rating: 4.5
+finished: "2024-03-09"
+genres:
+  - Science Fiction
+  - Classics
+tags:
+  - favourites
+bookshelf_id: 1
+---
+
+# Dune
+
+## Review
+
+Spice, sand and "politics".
+
+` + NotesMarker + "\n"
+	if string(data) != expected {
+		t.Errorf("unexpected note:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	// Your own front matter and notes survive, even after renaming the note
+	edited := strings.Replace(string(data), "bookshelf_id: 1\n", "bookshelf_id: 1\naliases:\n  - Dune (novel)\n", 1) +
+		"\nMy thoughts on the Bene Gesserit.\n"
+	renamed := filepath.Join(dir, "Herbert - Dune.md")
+	os.Remove(path)
+	if err := os.WriteFile(renamed, []byte(edited), 0644); err != nil {
+		t.Fatalf("failed to edit note: %v", err)
+	}
+
+	books[0].ReadingEntry.Rating = sql.NullFloat64{Float64: 5, Valid: true}
+	result, err = WriteMarkdown(dir, books)
+	if err != nil {
+		t.Fatalf("second export failed: %v", err)
+	}
+	if result != (MarkdownResult{Updated: 1, Unchanged: 1}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the renamed note to be updated in place")
+	}
+
+	data, _ = os.ReadFile(renamed)
+	for _, want := range []string{"rating: 5\n", "aliases:\n  - Dune (novel)\n", NotesMarker + "\n\nMy thoughts on the Bene Gesserit.\n"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %q in note:\n%s", want, data)
+		}
+	}
+}
+
+func TestWriteMarkdownKeepsNotesWithoutMarker(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Dune.md")
+	os.WriteFile(path, []byte("---\nbookshelf_id: 1\n---\nNotes written before the marker existed.\n"), 0644)
+
+	if _, err := WriteMarkdown(dir, testBooks()[:1]); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), NotesMarker+"\n\nNotes written before the marker existed.\n") {
+		t.Errorf("expected the old body kept below the marker:\n%s", data)
+	}
+}
+
+func TestNoteFileName(t *testing.T) {
+	book := &models.BookWithEntry{Book: models.Book{ID: 7, Title: "Who/What? A #1 Guide: Part [2]", Author: "A. Writer"}}
+
+	taken := map[string]bool{}
+	if name := noteFileName(book, taken); name != "Who What A 1 Guide Part 2.md" {
+		t.Errorf("unexpected name: %s", name)
+	}
+	taken["who what a 1 guide part 2.md"] = true
+	if name := noteFileName(book, taken); name != "Who What A 1 Guide Part 2 (A. Writer).md" {
+		t.Errorf("expected the author to disambiguate, got %s", name)
+	}
+
+	long := &models.BookWithEntry{Book: models.Book{ID: 8, Title: strings.Repeat("日本語", 20), Author: "Someone"}}
+	name := noteFileName(long, map[string]bool{})
+	if !utf8.ValidString(name) || len(name) > 100+len(".md") {
+		t.Errorf("long title should be cut between characters, got %q (%d bytes)", name, len(name))
+	}
+}
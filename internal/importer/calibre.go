@@ -0,0 +1,318 @@
+package importer
+
+import (
+	"bookshelf/internal/models"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormatCalibre is a Calibre library folder. It's read with ParseCalibre
+// rather than Parse, as it's a folder instead of a file.
+const FormatCalibre = "calibre"
+
+// ParseCalibre reads the books in a Calibre library folder: from its
+// metadata.db, or, if the folder has none (say, a copy of just the book
+// folders), from the metadata.opf Calibre keeps next to each book. Every book
+// is an ebook on the want-to-read shelf, with Calibre's ID kept so it's
+// recognized when the library is read again.
+func ParseCalibre(dir string) ([]Record, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a folder: give the Calibre library folder, which has metadata.db in it", dir)
+	}
+
+	dbPath := filepath.Join(dir, "metadata.db")
+	if _, err := os.Stat(dbPath); err == nil {
+		return readCalibreDB(dir, dbPath)
+	}
+
+	records, err := readCalibreOPFs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("not a Calibre library: no metadata.db or metadata.opf files in %s", dir)
+	}
+	return records, nil
+}
+
+// readCalibreDB reads a library's metadata.db, read-only so Calibre can keep
+// running.
+func readCalibreDB(dir, dbPath string) ([]Record, error) {
+	if abs, err := filepath.Abs(dbPath); err == nil {
+		dbPath = abs
+	}
+	conn, err := sql.Open("sqlite", (&url.URL{Scheme: "file", Path: dbPath, RawQuery: "mode=ro"}).String())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.Query(`
+		SELECT b.id, b.title, b.path, b.has_cover, b.series_index,
+			COALESCE((SELECT s.name FROM books_series_link l JOIN series s ON s.id = l.series WHERE l.book = b.id), ''),
+			COALESCE((SELECT r.rating FROM books_ratings_link l JOIN ratings r ON r.id = l.rating WHERE l.book = b.id), 0),
+			COALESCE((SELECT p.name FROM books_publishers_link l JOIN publishers p ON p.id = l.publisher WHERE l.book = b.id), ''),
+			COALESCE((SELECT i.val FROM identifiers i WHERE i.book = b.id AND i.type = 'isbn'), b.isbn, ''),
+			COALESCE((SELECT c.text FROM comments c WHERE c.book = b.id), '')
+		FROM books b
+		ORDER BY b.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("not a Calibre library: failed to read %s: %w", dbPath, err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var rec Record
+		var path string
+		var hasCover bool
+		var rating int
+		if err := rows.Scan(&rec.CalibreID, &rec.Title, &path, &hasCover, &rec.SeriesIndex,
+			&rec.Series, &rating, &rec.Publisher, &rec.ISBN, &rec.Description); err != nil {
+			return nil, err
+		}
+		rec.Format = models.FormatEbook
+		rec.Rating = roundRating(float64(rating) / 2)
+		rec.Description = stripHTML(rec.Description)
+		if rec.Series == "" {
+			rec.SeriesIndex = 0
+		}
+		if cover := filepath.Join(dir, filepath.FromSlash(path), "cover.jpg"); hasCover && IsCoverIn(dir, cover) {
+			rec.CoverURL = fileURL(cover)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	authors, err := calibreLinks(conn, `
+		SELECT l.book, a.name FROM books_authors_link l JOIN authors a ON a.id = l.author ORDER BY l.id`)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := calibreLinks(conn, `
+		SELECT l.book, t.name FROM books_tags_link l JOIN tags t ON t.id = l.tag ORDER BY l.id`)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Author = strings.Join(authors[records[i].CalibreID], ", ")
+		records[i].Tags = tags[records[i].CalibreID]
+	}
+	return records, nil
+}
+
+// calibreLinks reads a many-to-many link, such as books to authors, into a
+// map from book ID to names.
+func calibreLinks(conn *sql.DB, query string) (map[int64][]string, error) {
+	rows, err := conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make(map[int64][]string)
+	for rows.Next() {
+		var book int64
+		var name string
+		if err := rows.Scan(&book, &name); err != nil {
+			return nil, err
+		}
+		links[book] = append(links[book], name)
+	}
+	return links, rows.Err()
+}
+
+// opfPackage is the part of a metadata.opf that bookshelf reads.
+type opfPackage struct {
+	Metadata struct {
+		Titles      []string  `xml:"http://purl.org/dc/elements/1.1/ title"`
+		Creators    []opfText `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Identifiers []opfText `xml:"http://purl.org/dc/elements/1.1/ identifier"`
+		Subjects    []string  `xml:"http://purl.org/dc/elements/1.1/ subject"`
+		Publisher   string    `xml:"http://purl.org/dc/elements/1.1/ publisher"`
+		Description string    `xml:"http://purl.org/dc/elements/1.1/ description"`
+		Meta        []struct {
+			Name    string `xml:"name,attr"`
+			Content string `xml:"content,attr"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Guide struct {
+		References []struct {
+			Type string `xml:"type,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"reference"`
+	} `xml:"guide"`
+}
+
+type opfText struct {
+	Value  string `xml:",chardata"`
+	Role   string `xml:"http://www.idpf.org/2007/opf role,attr"`
+	Scheme string `xml:"http://www.idpf.org/2007/opf scheme,attr"`
+}
+
+// readCalibreOPFs reads every metadata.opf under dir.
+func readCalibreOPFs(dir string) ([]Record, error) {
+	var records []Record
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() != "metadata.opf" {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		var pkg opfPackage
+		if err := xml.NewDecoder(f).Decode(&pkg); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if rec, ok := opfRecord(pkg, filepath.Dir(path)); ok {
+			records = append(records, rec)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return records, err
+}
+
+// opfRecord maps a metadata.opf to a Record. Books without a Calibre ID are
+// left out, as they couldn't be told apart when the library is read again.
+func opfRecord(pkg opfPackage, bookDir string) (Record, bool) {
+	meta := pkg.Metadata
+	rec := Record{Format: models.FormatEbook, Publisher: strings.TrimSpace(meta.Publisher), Tags: meta.Subjects}
+	if len(meta.Titles) > 0 {
+		rec.Title = strings.TrimSpace(meta.Titles[0])
+	}
+
+	var authors []string
+	for _, c := range meta.Creators {
+		if c.Role == "" || c.Role == "aut" {
+			authors = append(authors, strings.TrimSpace(c.Value))
+		}
+	}
+	rec.Author = strings.Join(authors, ", ")
+
+	for _, id := range meta.Identifiers {
+		scheme, value := strings.ToLower(id.Scheme), strings.TrimSpace(id.Value)
+		if scheme == "" {
+			// OPF 3 writes "calibre:42" and "isbn:978..."
+			scheme, value, _ = strings.Cut(value, ":")
+			scheme = strings.ToLower(scheme)
+		}
+		switch scheme {
+		case "calibre":
+			rec.CalibreID, _ = strconv.ParseInt(value, 10, 64)
+		case "isbn":
+			rec.ISBN = value
+		}
+	}
+	if rec.CalibreID == 0 {
+		return rec, false
+	}
+
+	for _, m := range meta.Meta {
+		switch m.Name {
+		case "calibre:series":
+			rec.Series = m.Content
+		case "calibre:series_index":
+			rec.SeriesIndex, _ = strconv.ParseFloat(m.Content, 64)
+		case "calibre:rating":
+			if rating, err := strconv.ParseFloat(m.Content, 64); err == nil {
+				rec.Rating = roundRating(rating / 2)
+			}
+		}
+	}
+	if rec.Series == "" {
+		rec.SeriesIndex = 0
+	}
+	rec.Description = stripHTML(meta.Description)
+
+	for _, ref := range pkg.Guide.References {
+		if ref.Type == "cover" && ref.Href != "" {
+			cover := filepath.Join(bookDir, filepath.FromSlash(ref.Href))
+			if _, err := os.Stat(cover); err == nil && IsCoverIn(bookDir, cover) {
+				rec.CoverURL = fileURL(cover)
+			}
+		}
+	}
+	return rec, true
+}
+
+// IsCoverIn reports whether path is an image (.jpg, .jpeg, .png, .gif or
+// .webp) inside dir, once both are made absolute and cleaned, with symlinks
+// resolved. Covers pointing elsewhere, e.g. an OPF cover of
+// ../../../etc/shadow, are refused, as publish and the web app serve them.
+func IsCoverIn(dir, path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+	default:
+		return false
+	}
+	resolve := func(p string) (string, error) {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "", err
+		}
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			return real, nil
+		}
+		return abs, nil
+	}
+	absDir, err := resolve(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := resolve(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// fileURL turns a path into a file:// URL, which publish copies into the site.
+func fileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+var (
+	htmlParagraphs = regexp.MustCompile(`(?i)</p>|</div>`)
+	htmlBreaks     = regexp.MustCompile(`(?i)<br\s*/?>|</li>`)
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+	blankLines     = regexp.MustCompile(`\n\s*\n\s*`)
+)
+
+// stripHTML turns Calibre's HTML comments into plain text, keeping paragraphs.
+func stripHTML(s string) string {
+	s = htmlParagraphs.ReplaceAllString(s, "\n\n")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTags.ReplaceAllString(s, ""))
+	lines := strings.Split(s, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
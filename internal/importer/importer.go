@@ -0,0 +1,424 @@
+// Package importer reads other reading trackers' exports into the shelf.
+//
+// Each format's adapter parses its export into Records, a common shape that
+// Import then matches against the shelf: books already there are filled in,
+// new ones are added.
+package importer
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Format names accepted by Parse.
+const (
+	FormatStoryGraph   = "storygraph"
+	FormatLibraryThing = "librarything"
+)
+
+// FormatNames lists the formats that can be imported.
+var FormatNames = []string{FormatStoryGraph, FormatLibraryThing}
+
+// Record is one book from an export, with the fields bookshelf can keep.
+// Zero values mean the export didn't say.
+type Record struct {
+	Title      string
+	Author     string
+	ISBN       string
+	Pages      int
+	Publisher  string
+	Format     models.BookFormat
+	Status     models.BookStatus // Defaults to want-to-read
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Rating     float64 // Stars from 0.5 to 5, in half steps
+	Review     string
+	Tags       []string
+
+	Description string
+	Series      string
+	SeriesIndex float64
+	CoverURL    string
+	CalibreID   int64 // The book's ID in a Calibre library
+}
+
+// Parse reads an export in the named format.
+func Parse(format string, r io.Reader) ([]Record, error) {
+	switch format {
+	case FormatStoryGraph:
+		return ParseStoryGraph(r)
+	case FormatLibraryThing:
+		return ParseLibraryThing(r)
+	}
+	return nil, fmt.Errorf("unknown import format: %s (use: %s)", format, strings.Join(FormatNames, ", "))
+}
+
+// Result counts what Import did with each record.
+type Result struct {
+	Created int
+	Updated int
+	Skipped int // Already up to date, or missing a title
+}
+
+// Import adds the records to the shelf. A record matching a book already
+// there, by ISBN or else by title and author, only fills in what the book is
+// missing, so importing the same export twice changes nothing. The records
+// aren't imported in one transaction: when one fails, the result counts the
+// ones before it, which stay imported, and the error says how many there were.
+func Import(records []Record) (Result, error) {
+	var result Result
+
+	books, err := db.ListBooks(models.ListOptions{})
+	if err != nil {
+		return result, fmt.Errorf("failed to list books: %w", err)
+	}
+	shelf := newIndex(books)
+
+	for _, rec := range records {
+		rec.Title = strings.TrimSpace(rec.Title)
+		rec.Author = strings.TrimSpace(rec.Author)
+		if rec.Title == "" {
+			result.Skipped++
+			continue
+		}
+		if rec.Author == "" {
+			rec.Author = "Unknown Author"
+		}
+		if !rec.Status.IsValid() {
+			rec.Status = models.StatusWantToRead
+		}
+
+		existing := shelf.find(rec)
+		if existing == nil {
+			book, err := create(rec)
+			if err != nil {
+				return result, stopped(result, fmt.Errorf("failed to add \"%s\": %w", rec.Title, err))
+			}
+			shelf.add(book)
+			result.Created++
+			continue
+		}
+
+		changed, err := merge(existing, rec)
+		if err != nil {
+			return result, stopped(result, fmt.Errorf("failed to update \"%s\": %w", rec.Title, err))
+		}
+		if changed {
+			result.Updated++
+		} else {
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// stopped adds to an error stopping Import what was imported before it.
+// Importing again is safe and carries on from there.
+func stopped(result Result, err error) error {
+	return fmt.Errorf("%w (%d books were created and %d updated before this; importing again carries on from there)",
+		err, result.Created, result.Updated)
+}
+
+func create(rec Record) (*models.BookWithEntry, error) {
+	var book models.BookWithEntry
+	book.Book = models.Book{
+		Title:     rec.Title,
+		Author:    rec.Author,
+		ISBN:      models.NullString(rec.ISBN),
+		Publisher: models.NullString(rec.Publisher),
+		Format:    models.NullString(string(rec.Format)),
+	}
+	if rec.Pages > 0 {
+		book.Book.Pages = sql.NullInt64{Int64: int64(rec.Pages), Valid: true}
+	}
+	book.Book.SetTags(rec.Tags)
+	fillDetails(&book.Book, rec)
+
+	id, err := db.InsertBook(&book.Book)
+	if err != nil {
+		return nil, err
+	}
+	book.Book.ID = id
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		return nil, err
+	}
+	book.ReadingEntry.Status = models.StatusWantToRead
+
+	if _, err := merge(&book, Record{Status: rec.Status, StartedAt: rec.StartedAt, FinishedAt: rec.FinishedAt,
+		Rating: rec.Rating, Review: rec.Review}); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// merge fills in what book is missing from rec, reporting whether anything
+// changed. A status only moves forward from want-to-read; the shelf is
+// trusted over the export for everything it already has.
+func merge(book *models.BookWithEntry, rec Record) (bool, error) {
+	changed := false
+
+	b := book.Book
+	if !b.ISBN.Valid && rec.ISBN != "" {
+		b.ISBN = models.NullString(rec.ISBN)
+	}
+	if !b.Pages.Valid && rec.Pages > 0 {
+		b.Pages = sql.NullInt64{Int64: int64(rec.Pages), Valid: true}
+	}
+	if !b.Publisher.Valid && rec.Publisher != "" {
+		b.Publisher = models.NullString(rec.Publisher)
+	}
+	if !b.Format.Valid && rec.Format != "" {
+		b.Format = models.NullString(string(rec.Format))
+	}
+	b.SetTags(mergeTags(book.Book.TagList(), rec.Tags))
+	fillDetails(&b, rec)
+	if b != book.Book {
+		if err := db.UpdateBook(&b); err != nil {
+			return false, err
+		}
+		book.Book = b
+		changed = true
+	}
+
+	entry := &book.ReadingEntry
+	if entry.Status == models.StatusWantToRead && rec.Status != models.StatusWantToRead && rec.Status != "" {
+		if err := db.UpdateStatusOn(b.ID, rec.Status, nil); err != nil {
+			return false, err
+		}
+		entry.Status = rec.Status
+		changed = true
+	}
+
+	started, finished := entry.StartedAt, entry.FinishedAt
+	if !started.Valid && !rec.StartedAt.IsZero() {
+		started = sql.NullTime{Time: rec.StartedAt, Valid: true}
+	}
+	if !finished.Valid && !rec.FinishedAt.IsZero() && entry.Status != models.StatusReading {
+		finished = sql.NullTime{Time: rec.FinishedAt, Valid: true}
+	}
+	if started.Valid && finished.Valid && finished.Time.Before(started.Time) {
+		// Exports record the latest read's finish but sometimes the first read's start
+		started = entry.StartedAt
+	}
+	if started != entry.StartedAt || finished != entry.FinishedAt {
+		if err := db.SetReadingDates(b.ID, started, finished); err != nil {
+			return false, err
+		}
+		entry.StartedAt, entry.FinishedAt = started, finished
+		changed = true
+	}
+
+	if !entry.Rating.Valid && rec.Rating > 0 {
+		if err := db.UpdateRating(b.ID, rec.Rating); err != nil {
+			return false, err
+		}
+		entry.Rating = sql.NullFloat64{Float64: rec.Rating, Valid: true}
+		changed = true
+	}
+
+	if (!entry.Review.Valid || entry.Review.String == "") && rec.Review != "" {
+		if err := db.UpdateReview(b.ID, rec.Review); err != nil {
+			return false, err
+		}
+		entry.Review = sql.NullString{String: rec.Review, Valid: true}
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// fillDetails sets the details only some exports have, where the book
+// doesn't have them yet.
+func fillDetails(b *models.Book, rec Record) {
+	if !b.Description.Valid && rec.Description != "" {
+		b.Description = models.NullString(rec.Description)
+	}
+	if !b.Series.Valid && rec.Series != "" {
+		b.Series = models.NullString(rec.Series)
+		if rec.SeriesIndex > 0 {
+			b.SeriesIndex = sql.NullFloat64{Float64: rec.SeriesIndex, Valid: true}
+		}
+	}
+	if !b.CoverURL.Valid && rec.CoverURL != "" {
+		b.CoverURL = models.NullString(rec.CoverURL)
+	}
+	if !b.CalibreID.Valid && rec.CalibreID > 0 {
+		b.CalibreID = sql.NullInt64{Int64: rec.CalibreID, Valid: true}
+	}
+}
+
+// mergeTags appends the new tags that aren't already present, ignoring case.
+func mergeTags(tags, more []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, tag := range append(append([]string{}, tags...), more...) {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
+
+// index finds books on the shelf by Calibre ID, ISBN, and title and author.
+type index struct {
+	byCalibreID map[int64]*models.BookWithEntry
+	byISBN      map[string]*models.BookWithEntry
+	byTitle     map[string]*models.BookWithEntry
+}
+
+func newIndex(books []models.BookWithEntry) *index {
+	idx := &index{
+		byCalibreID: make(map[int64]*models.BookWithEntry),
+		byISBN:      make(map[string]*models.BookWithEntry),
+		byTitle:     make(map[string]*models.BookWithEntry),
+	}
+	for i := range books {
+		idx.add(&books[i])
+	}
+	return idx
+}
+
+func (idx *index) add(book *models.BookWithEntry) {
+	if book.Book.CalibreID.Valid {
+		idx.byCalibreID[book.Book.CalibreID.Int64] = book
+	}
+	if isbn := api.NormalizeISBN(book.Book.ISBN.String); isbn != "" {
+		idx.byISBN[isbn] = book
+	}
+	idx.byTitle[titleKey(book.Book.Title, book.Book.Author)] = book
+}
+
+func (idx *index) find(rec Record) *models.BookWithEntry {
+	if book, ok := idx.byCalibreID[rec.CalibreID]; ok && rec.CalibreID > 0 {
+		return book
+	}
+	if isbn := api.NormalizeISBN(rec.ISBN); isbn != "" {
+		if book, ok := idx.byISBN[isbn]; ok {
+			return book
+		}
+	}
+	return idx.byTitle[titleKey(rec.Title, rec.Author)]
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// titleKey identifies a book across apps, which disagree on subtitles,
+// punctuation and how many authors to list: "Dune: Deluxe Edition" by "Frank
+// Herbert, Brian Herbert" matches "Dune" by "Frank Herbert".
+func titleKey(title, author string) string {
+	if i := strings.IndexAny(title, ":("); i > 0 {
+		title = title[:i]
+	}
+	if i := strings.IndexAny(author, ",;&"); i > 0 {
+		author = author[:i]
+	}
+	normalize := func(s string) string {
+		return strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(s), " "), " ")
+	}
+	return normalize(title) + "|" + normalize(author)
+}
+
+// roundRating rounds a rating to the nearest half star, as some apps allow
+// quarter stars. Returns 0 for no rating.
+func roundRating(stars float64) float64 {
+	rounded := math.Round(stars*2) / 2
+	if rounded < 0.5 {
+		return 0
+	}
+	return math.Min(rounded, 5)
+}
+
+// parseDate reads the date formats exports use, returning the zero time for
+// anything else.
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02", "2006/01/02", "2006-01-02 15:04:05", "01/02/2006", "2006-01", "2006/01"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// splitList splits a comma-separated field, dropping blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readTable reads a CSV or TSV export with a header row into one map per row,
+// keyed by column name, so adapters don't depend on column order.
+func readTable(r io.Reader, comma rune) ([]map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var rows []map[string]string
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(fields) {
+				row[name] = strings.TrimSpace(fields[i])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// requireColumns checks that an export has the columns an adapter needs, to
+// catch the wrong file or format early.
+func requireColumns(rows []map[string]string, format string, columns ...string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	for _, column := range columns {
+		if _, ok := rows[0][column]; !ok {
+			return fmt.Errorf("not a %s export: missing the %q column", format, column)
+		}
+	}
+	return nil
+}
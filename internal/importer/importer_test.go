@@ -0,0 +1,466 @@
+package importer
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bookshelf/internal/testutil"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseFile(t *testing.T, format, path string) []Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	records, err := Parse(format, f)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+	return records
+}
+
+func TestParseStoryGraph(t *testing.T) {
+	records := parseFile(t, FormatStoryGraph, "testdata/storygraph.csv")
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+
+	dune := records[0]
+	if dune.Title != "Dune" || dune.Author != "Frank Herbert" || dune.ISBN != "9780441013593" {
+		t.Errorf("unexpected book: %+v", dune)
+	}
+	if dune.Status != models.StatusFinished {
+		t.Errorf("expected finished, got %s", dune.Status)
+	}
+	if dune.Rating != 4.5 {
+		t.Errorf("expected 4.25 stars rounded to 4.5, got %v", dune.Rating)
+	}
+	if dune.StartedAt.Format("2006-01-02") != "2023-01-05" || dune.FinishedAt.Format("2006-01-02") != "2023-02-10" {
+		t.Errorf("expected the latest read's dates, got %v - %v", dune.StartedAt, dune.FinishedAt)
+	}
+	if !reflect.DeepEqual(dune.Tags, []string{"sci-fi", "classics"}) {
+		t.Errorf("unexpected tags: %v", dune.Tags)
+	}
+	if dune.Format != models.FormatPaperback {
+		t.Errorf("expected paperback, got %s", dune.Format)
+	}
+
+	jest := records[1]
+	if jest.Status != models.StatusDNF || jest.Format != models.FormatEbook {
+		t.Errorf("expected a DNF ebook, got %s %s", jest.Status, jest.Format)
+	}
+	if jest.FinishedAt.Format("2006-01-02") != "2023-04-15" {
+		t.Errorf("expected Last Date Read as the finish date, got %v", jest.FinishedAt)
+	}
+
+	if records[2].Status != models.StatusReading {
+		t.Errorf("expected reading, got %s", records[2].Status)
+	}
+
+	leGuin := records[3]
+	if leGuin.Status != models.StatusWantToRead || leGuin.Format != models.FormatAudiobook {
+		t.Errorf("expected a want-to-read audiobook, got %s %s", leGuin.Status, leGuin.Format)
+	}
+	if leGuin.ISBN != "" {
+		t.Errorf("expected StoryGraph's own ID to be dropped, got %s", leGuin.ISBN)
+	}
+}
+
+func TestParseLibraryThing(t *testing.T) {
+	for _, path := range []string{"testdata/librarything.tsv", "testdata/librarything.json"} {
+		t.Run(path, func(t *testing.T) {
+			records := parseFile(t, FormatLibraryThing, path)
+			if len(records) < 2 {
+				t.Fatalf("expected at least 2 records, got %d", len(records))
+			}
+
+			dune := records[0]
+			if dune.Title != "Dune" || dune.Author != "Frank Herbert" {
+				t.Errorf("unexpected book: %+v", dune)
+			}
+			if dune.ISBN != "0441013597" || dune.Pages != 528 || dune.Format != models.FormatPaperback {
+				t.Errorf("unexpected details: %+v", dune)
+			}
+			if dune.Status != models.StatusFinished || dune.Rating != 4.5 || dune.Review != "Still great." {
+				t.Errorf("unexpected reading: %+v", dune)
+			}
+			if !reflect.DeepEqual(dune.Tags, []string{"sci-fi", "reread", "Favorites"}) {
+				t.Errorf("expected tags and non-status collections, got %v", dune.Tags)
+			}
+
+			dispossessed := records[1]
+			if dispossessed.Author != "Ursula K. Le Guin" || dispossessed.Pages != 387 {
+				t.Errorf("unexpected book: %+v", dispossessed)
+			}
+			if dispossessed.Status != models.StatusReading {
+				t.Errorf("expected reading from the collection, got %s", dispossessed.Status)
+			}
+		})
+	}
+}
+
+func TestParseWrongFormat(t *testing.T) {
+	_, err := Parse(FormatStoryGraph, strings.NewReader("Book Id\tTitle\n1\tDune\n"))
+	if err == nil || !strings.Contains(err.Error(), "not a StoryGraph export") {
+		t.Errorf("expected a wrong format error, got %v", err)
+	}
+
+	_, err = Parse("goodreads", strings.NewReader(""))
+	if err == nil || !strings.Contains(err.Error(), "unknown import format") {
+		t.Errorf("expected an unknown format error, got %v", err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	// Already on the shelf without an ISBN, under a longer title
+	id, err := db.InsertBook(&models.Book{Title: "Dune: Deluxe Edition", Author: "Frank Herbert"})
+	if err != nil {
+		t.Fatalf("failed to add book: %v", err)
+	}
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		t.Fatalf("failed to create entry: %v", err)
+	}
+
+	records := parseFile(t, FormatStoryGraph, "testdata/storygraph.csv")
+	result, err := Import(records)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result != (Result{Created: 3, Updated: 1}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	dune, err := db.GetBook(id)
+	if err != nil {
+		t.Fatalf("failed to get book: %v", err)
+	}
+	if dune.ReadingEntry.Status != models.StatusFinished || dune.ReadingEntry.Rating.Float64 != 4.5 {
+		t.Errorf("expected the existing book to be filled in, got %+v", dune.ReadingEntry)
+	}
+	if dune.Book.ISBN.String != "9780441013593" || !reflect.DeepEqual(dune.Book.TagList(), []string{"sci-fi", "classics"}) {
+		t.Errorf("expected ISBN and tags, got %+v", dune.Book)
+	}
+
+	// Importing again changes nothing
+	result, err = Import(records)
+	if err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if result != (Result{Skipped: 4}) {
+		t.Errorf("expected everything skipped, got %+v", result)
+	}
+
+	// LibraryThing's Dune matches by ISBN-10 and only adds its own tags
+	result, err = Import(parseFile(t, FormatLibraryThing, "testdata/librarything.tsv"))
+	if err != nil {
+		t.Fatalf("LibraryThing import failed: %v", err)
+	}
+	if result != (Result{Created: 2, Updated: 1}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	dune, _ = db.GetBook(id)
+	if !reflect.DeepEqual(dune.Book.TagList(), []string{"sci-fi", "classics", "reread", "Favorites"}) {
+		t.Errorf("expected merged tags, got %v", dune.Book.TagList())
+	}
+	if dune.ReadingEntry.Review.String != "Spice, sand and politics." {
+		t.Errorf("expected the existing review to be kept, got %s", dune.ReadingEntry.Review.String)
+	}
+}
+
+func parseClippings(t *testing.T) []Clipping {
+	t.Helper()
+	f, err := os.Open("testdata/My Clippings.txt")
+	if err != nil {
+		t.Fatalf("failed to open clippings: %v", err)
+	}
+	defer f.Close()
+
+	clippings, err := ParseKindleClippings(f)
+	if err != nil {
+		t.Fatalf("failed to parse clippings: %v", err)
+	}
+	return clippings
+}
+
+func TestParseKindleClippings(t *testing.T) {
+	clippings := parseClippings(t)
+	if len(clippings) != 3 {
+		t.Fatalf("expected 3 highlights, got %d: %+v", len(clippings), clippings)
+	}
+
+	dune := clippings[0]
+	if dune.Title != "Dune" || dune.Author != "Frank Herbert" {
+		t.Errorf("unexpected title or author: %q by %q", dune.Title, dune.Author)
+	}
+	if dune.Text != "I must not fear. Fear is the mind-killer." || dune.Page != 8 || dune.Location != "180-182" {
+		t.Errorf("unexpected highlight: %+v", dune)
+	}
+	if dune.Note != "The litany against fear" {
+		t.Errorf("expected the note to be attached, got %q", dune.Note)
+	}
+	if dune.AddedAt.Format("2006-01-02 15:04") != "2023-01-02 21:15" {
+		t.Errorf("unexpected date: %v", dune.AddedAt)
+	}
+
+	piranesi := clippings[1]
+	if piranesi.Text != "The Beauty of the House is immeasurable;\nits Kindness infinite." || piranesi.Page != 0 || piranesi.Location != "95-97" {
+		t.Errorf("unexpected highlight: %+v", piranesi)
+	}
+	if piranesi.AddedAt.Format("2006-01-02") != "2023-03-04" {
+		t.Errorf("expected a UK date to parse, got %v", piranesi.AddedAt)
+	}
+
+	wind := clippings[2]
+	if wind.Title != "The Name of the Wind (Kingkiller Chronicle, Book 1)" || wind.Author != "Patrick Rothfuss" {
+		t.Errorf("expected the last parentheses to be the author, got %q by %q", wind.Title, wind.Author)
+	}
+}
+
+func TestImportClippings(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	add := func(title, author string) int64 {
+		id, err := db.InsertBook(&models.Book{Title: title, Author: author})
+		if err != nil {
+			t.Fatalf("failed to add book: %v", err)
+		}
+		db.CreateReadingEntry(id, models.StatusReading)
+		return id
+	}
+	duneID := add("Dune: Deluxe Edition", "Frank Herbert")
+	add("Dune Messiah", "Frank Herbert")
+	windID := add("The Name of the Wind", "Patrick Rothfuss")
+
+	result, err := ImportClippings(parseClippings(t))
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result.Imported != 2 || result.Duplicates != 0 || !reflect.DeepEqual(result.Unmatched, []string{"Piranesi"}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	quotes, _ := db.GetQuotes(duneID)
+	if len(quotes) != 1 || quotes[0].Note.String != "The litany against fear" || quotes[0].Page.Int64 != 8 {
+		t.Errorf("unexpected Dune quotes: %+v", quotes)
+	}
+	if quotes, _ := db.GetQuotes(windID); len(quotes) != 1 {
+		t.Errorf("expected 1 quote from The Name of the Wind, got %d", len(quotes))
+	}
+
+	// Importing again changes nothing
+	result, err = ImportClippings(parseClippings(t))
+	if err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if result.Imported != 0 || result.Duplicates != 2 {
+		t.Errorf("expected duplicates to be skipped, got %+v", result)
+	}
+}
+
+func TestMatchBook(t *testing.T) {
+	books := []models.BookWithEntry{
+		{Book: models.Book{ID: 1, Title: "Dune", Author: "Frank Herbert"}},
+		{Book: models.Book{ID: 2, Title: "Children of Dune", Author: "Frank Herbert"}},
+		{Book: models.Book{ID: 3, Title: "The Left Hand of Darkness", Author: "Ursula K. Le Guin"}},
+		{Book: models.Book{ID: 4, Title: "Emma", Author: "Jane Austen"}},
+		{Book: models.Book{ID: 5, Title: "Emma", Author: "Alexander McCall Smith"}},
+	}
+
+	tests := []struct {
+		title, author string
+		want          int64
+	}{
+		{"Dune", "Frank Herbert", 1},
+		{"dune", "", 1},
+		{"Children of Dune (Dune Chronicles, Book 3)", "Frank Herbert", 2},
+		{"Left Hand of Darkness", "Ursula K. Le Guin", 3},
+		{"Dune", "Brian Herbert Anderson", 0},
+		{"Emma", "Jane Austen", 4},
+		{"Emma", "", 0}, // Two books could be it
+		{"Piranesi", "Susanna Clarke", 0},
+	}
+	for _, tt := range tests {
+		var got int64
+		if book := matchBook(tt.title, tt.author, books); book != nil {
+			got = book.Book.ID
+		}
+		if got != tt.want {
+			t.Errorf("matchBook(%q, %q) = %d, want %d", tt.title, tt.author, got, tt.want)
+		}
+	}
+}
+
+func TestParseCalibre(t *testing.T) {
+	records, err := ParseCalibre("testdata/calibre")
+	if err != nil {
+		t.Fatalf("failed to read library: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	dune := records[0]
+	if dune.CalibreID != 1 || dune.Title != "Dune" || dune.Author != "Frank Herbert" || dune.ISBN != "9780441013593" {
+		t.Errorf("unexpected book: %+v", dune)
+	}
+	if dune.Series != "Dune" || dune.SeriesIndex != 1 || dune.Rating != 5 || dune.Publisher != "Ace" {
+		t.Errorf("unexpected series, rating or publisher: %+v", dune)
+	}
+	if !reflect.DeepEqual(dune.Tags, []string{"Science Fiction", "Classics"}) {
+		t.Errorf("unexpected tags: %v", dune.Tags)
+	}
+	if !strings.HasPrefix(dune.CoverURL, "file:///") || !strings.HasSuffix(dune.CoverURL, "/Frank%20Herbert/Dune%20%281%29/cover.jpg") {
+		t.Errorf("expected a file URL for the cover, got %s", dune.CoverURL)
+	}
+	if dune.Description != "Set on the desert planet Arrakis.\n\nA \"stunning\" blend of adventure." {
+		t.Errorf("expected the HTML to be stripped, got %q", dune.Description)
+	}
+	if dune.Format != models.FormatEbook || dune.Status != "" {
+		t.Errorf("expected an ebook with no status, got %s %s", dune.Format, dune.Status)
+	}
+
+	if messiah := records[1]; messiah.CoverURL != "" || messiah.SeriesIndex != 2 || messiah.Rating != 0 {
+		t.Errorf("unexpected book: %+v", messiah)
+	}
+
+	omens := records[2]
+	if omens.Author != "Neil Gaiman, Terry Pratchett" || omens.Series != "" || omens.SeriesIndex != 0 || omens.Rating != 3.5 {
+		t.Errorf("unexpected book: %+v", omens)
+	}
+}
+
+func TestParseCalibreOPF(t *testing.T) {
+	records, err := ParseCalibre("testdata/calibre-opf")
+	if err != nil {
+		t.Fatalf("failed to read library: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+
+	book := records[0]
+	if book.CalibreID != 7 || book.Title != "The Left Hand of Darkness" || book.Author != "Ursula K. Le Guin" || book.ISBN != "9780441478125" {
+		t.Errorf("unexpected book: %+v", book)
+	}
+	if book.Series != "Hainish Cycle" || book.SeriesIndex != 4 || book.Rating != 4.5 {
+		t.Errorf("unexpected series or rating: %+v", book)
+	}
+	if !reflect.DeepEqual(book.Tags, []string{"Science Fiction", "Hainish"}) {
+		t.Errorf("unexpected tags: %v", book.Tags)
+	}
+	if book.Description != "A lone human ambassador is sent to Winter." || !strings.HasSuffix(book.CoverURL, "/cover.jpg") {
+		t.Errorf("unexpected description or cover: %+v", book)
+	}
+}
+
+func TestParseCalibreOPFCoverOutsideBook(t *testing.T) {
+	opf, err := os.ReadFile("testdata/calibre-opf/Ursula K. Le Guin/The Left Hand of Darkness (7)/metadata.opf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	bookDir := filepath.Join(dir, "Ursula K. Le Guin", "The Left Hand of Darkness (7)")
+	if err := os.MkdirAll(bookDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "secret.jpg"), []byte("not a cover"), 0o600)
+	for _, href := range []string{"../../secret.jpg", "../../../../../../etc/passwd"} {
+		data := strings.Replace(string(opf), `href="cover.jpg"`, `href="`+href+`"`, 1)
+		if err := os.WriteFile(filepath.Join(bookDir, "metadata.opf"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		records, err := ParseCalibre(dir)
+		if err != nil || len(records) != 1 {
+			t.Fatalf("failed to read library: %v, %d records", err, len(records))
+		}
+		if records[0].CoverURL != "" {
+			t.Errorf("cover %s should be refused, got %s", href, records[0].CoverURL)
+		}
+	}
+}
+
+func TestIsCoverIn(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/library/Author/Book (1)/cover.jpg", true},
+		{"/library/Author/Book (1)/images/front.PNG", true},
+		{"/library/Author/Book (1)/../../cover.webp", true},
+		{"/library/../etc/cover.jpg", false},
+		{"/library-other/cover.jpg", false},
+		{"/library/Author/Book (1)/book.epub", false},
+		{"/etc/shadow", false},
+	}
+	for _, tt := range tests {
+		if got := IsCoverIn("/library", filepath.FromSlash(tt.path)); got != tt.want {
+			t.Errorf("IsCoverIn(/library, %s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseCalibreNotALibrary(t *testing.T) {
+	if _, err := ParseCalibre(t.TempDir()); err == nil || !strings.Contains(err.Error(), "not a Calibre library") {
+		t.Errorf("expected an error for a folder without a library, got %v", err)
+	}
+	if _, err := ParseCalibre("testdata/storygraph.csv"); err == nil || !strings.Contains(err.Error(), "is not a folder") {
+		t.Errorf("expected an error for a file, got %v", err)
+	}
+}
+
+func TestImportCalibre(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	// Already on the shelf, found by ISBN
+	id, _ := db.InsertBook(&models.Book{Title: "Good Omens", Author: "Terry Pratchett", ISBN: sql.NullString{String: "9780060853983", Valid: true}})
+	db.CreateReadingEntry(id, models.StatusFinished)
+
+	records, err := ParseCalibre("testdata/calibre")
+	if err != nil {
+		t.Fatalf("failed to read library: %v", err)
+	}
+	result, err := Import(records)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if result != (Result{Created: 2, Updated: 1}) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	omens, _ := db.GetBook(id)
+	if omens.Book.CalibreID.Int64 != 3 || omens.Book.Author != "Terry Pratchett" || omens.ReadingEntry.Status != models.StatusFinished {
+		t.Errorf("expected the Calibre ID to be added and the rest kept, got %+v", omens)
+	}
+
+	books, _ := db.ListBooks(models.ListOptions{SortBy: models.SortByTitle})
+	dune := books[0]
+	if dune.Book.SeriesLabel() != "Dune #1" || !dune.Book.CalibreID.Valid || dune.ReadingEntry.Status != models.StatusWantToRead {
+		t.Errorf("unexpected book: %+v", dune)
+	}
+
+	// A book renamed on the shelf is still found by its Calibre ID
+	dune.Book.Title = "Dune (40th Anniversary Edition)"
+	dune.Book.ISBN = sql.NullString{}
+	db.UpdateBook(&dune.Book)
+
+	result, err = Import(records)
+	if err != nil {
+		t.Fatalf("second import failed: %v", err)
+	}
+	if result.Created != 0 {
+		t.Errorf("expected no duplicates, got %+v", result)
+	}
+}
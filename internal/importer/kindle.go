@@ -0,0 +1,307 @@
+package importer
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatKindle is a Kindle's "My Clippings.txt". It holds quotes rather than
+// books, so it's read with ParseKindleClippings and ImportClippings instead
+// of Parse and Import.
+const FormatKindle = "kindle"
+
+// Clipping is a highlight from a Kindle, with the note made on it, if any.
+type Clipping struct {
+	Title    string
+	Author   string
+	Text     string
+	Page     int
+	Location string // e.g. "180-182"
+	Note     string
+	AddedAt  time.Time
+}
+
+var (
+	clippingKind     = regexp.MustCompile(`(?i)^-\s*(?:your\s+)?(highlight|note|bookmark)`)
+	clippingPage     = regexp.MustCompile(`(?i)\bpage\s+(\d+)`)
+	clippingLocation = regexp.MustCompile(`(?i)\b(?:location|loc\.)\s+(\d+(?:-\d+)?)`)
+	clippingAdded    = regexp.MustCompile(`(?i)added on\s+(.+)$`)
+)
+
+// clippingDateLayouts are the "Added on" formats of US and UK Kindles.
+var clippingDateLayouts = []string{
+	"Monday, January 2, 2006 3:04:05 PM",
+	"Monday, January 2, 2006, 3:04 PM",
+	"Monday, 2 January 2006 15:04:05",
+	"Monday, January 2, 2006",
+}
+
+// ParseKindleClippings reads a Kindle's "My Clippings.txt": entries of a
+// "Title (Author)" line, a line like "- Your Highlight on page 12 | Location
+// 180-182 | Added on ...", a blank line and the text, each ending in
+// "==========". Notes are attached to the highlight they were made on;
+// bookmarks, and notes without a highlight, are left out.
+func ParseKindleClippings(r io.Reader) ([]Clipping, error) {
+	var clippings []Clipping
+	var entry []string
+
+	flush := func() {
+		defer func() { entry = entry[:0] }()
+		for len(entry) > 0 && strings.TrimSpace(entry[0]) == "" {
+			entry = entry[1:]
+		}
+		if len(entry) < 2 {
+			return
+		}
+		kind := clippingKind.FindStringSubmatch(entry[1])
+		if kind == nil {
+			return
+		}
+
+		c := Clipping{Text: strings.TrimSpace(strings.Join(entry[2:], "\n"))}
+		c.Title, c.Author = splitClippingTitle(entry[0])
+		meta := entry[1]
+		if m := clippingPage.FindStringSubmatch(meta); m != nil {
+			c.Page, _ = strconv.Atoi(m[1])
+		}
+		if m := clippingLocation.FindStringSubmatch(meta); m != nil {
+			c.Location = m[1]
+		}
+		if m := clippingAdded.FindStringSubmatch(meta); m != nil {
+			for _, layout := range clippingDateLayouts {
+				if t, err := time.ParseInLocation(layout, strings.TrimSpace(m[1]), time.Local); err == nil {
+					c.AddedAt = t
+					break
+				}
+			}
+		}
+		if c.Text == "" || c.Title == "" {
+			return
+		}
+
+		switch strings.ToLower(kind[1]) {
+		case "highlight":
+			clippings = append(clippings, c)
+		case "note":
+			// A note follows the highlight it's on, at the highlight's last location
+			for i := len(clippings) - 1; i >= 0; i-- {
+				h := &clippings[i]
+				if h.Title == c.Title && h.Note == "" && locationWithin(c.Location, h.Location) {
+					h.Note = c.Text
+					break
+				}
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\uFEFF"), "\r")
+		if strings.HasPrefix(line, "==========") {
+			flush()
+			continue
+		}
+		entry = append(entry, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return clippings, nil
+}
+
+// splitClippingTitle splits "Title (Author)" at its last parenthesis, as
+// titles can have their own: "Dune (Dune Chronicles, Book 1) (Frank
+// Herbert)". Authors written "Herbert, Frank" are turned around.
+func splitClippingTitle(line string) (title, author string) {
+	line = strings.TrimSpace(line)
+	if !strings.HasSuffix(line, ")") {
+		return line, ""
+	}
+	open := strings.LastIndex(line, "(")
+	if open <= 0 {
+		return line, ""
+	}
+	title = strings.TrimSpace(line[:open])
+	author = strings.TrimSpace(line[open+1 : len(line)-1])
+	if i := strings.Index(author, ";"); i > 0 {
+		author = strings.TrimSpace(author[:i])
+	}
+	if last, first, ok := strings.Cut(author, ","); ok && !strings.Contains(first, ",") {
+		author = strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+	}
+	return title, author
+}
+
+// locationWithin reports whether a note's location falls in a highlight's
+// range, such as "182" in "180-182".
+func locationWithin(location, highlight string) bool {
+	n, err := strconv.Atoi(location)
+	if err != nil {
+		return false
+	}
+	startText, endText, ok := strings.Cut(highlight, "-")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return false
+	}
+	end := start
+	if ok {
+		if end, err = strconv.Atoi(endText); err != nil {
+			return false
+		}
+	}
+	return n >= start && n <= end
+}
+
+// ClippingsResult counts what ImportClippings did.
+type ClippingsResult struct {
+	Imported   int
+	Duplicates int      // Already saved
+	Unmatched  []string // Titles of books that aren't on the shelf
+}
+
+// ImportClippings saves clippings as quotes on the books they're from, found
+// by a fuzzy match on title and author. Clippings already saved are skipped,
+// so importing the same file again is safe. Clippings whose book isn't on
+// the shelf, or could be more than one, aren't imported.
+func ImportClippings(clippings []Clipping) (ClippingsResult, error) {
+	var result ClippingsResult
+
+	books, err := db.ListBooks(models.ListOptions{})
+	if err != nil {
+		return result, fmt.Errorf("failed to list books: %w", err)
+	}
+
+	matches := make(map[string]*models.BookWithEntry)
+	unmatched := make(map[string]bool)
+	for _, c := range clippings {
+		key := c.Title + "|" + c.Author
+		book, ok := matches[key]
+		if !ok {
+			book = matchBook(c.Title, c.Author, books)
+			matches[key] = book
+		}
+		if book == nil {
+			if !unmatched[c.Title] {
+				unmatched[c.Title] = true
+				result.Unmatched = append(result.Unmatched, c.Title)
+			}
+			continue
+		}
+
+		exists, err := db.QuoteExists(book.Book.ID, c.Text)
+		if err != nil {
+			return result, err
+		}
+		if exists {
+			result.Duplicates++
+			continue
+		}
+
+		quote := models.Quote{
+			BookID:    book.Book.ID,
+			Text:      c.Text,
+			Location:  models.NullString(c.Location),
+			Note:      models.NullString(c.Note),
+			CreatedAt: c.AddedAt,
+		}
+		if c.Page > 0 {
+			quote.Page = sql.NullInt64{Int64: int64(c.Page), Valid: true}
+		}
+		if _, err := db.AddQuote(&quote); err != nil {
+			return result, fmt.Errorf("failed to save a quote from \"%s\": %w", book.Book.Title, err)
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// matchBook finds the book a Kindle title and author are most likely to be,
+// or nil if none is close or two are equally close. Titles match when they
+// share most of their words, ignoring subtitles and articles, and the
+// author's surname must appear in the book's author.
+func matchBook(title, author string, books []models.BookWithEntry) *models.BookWithEntry {
+	var best *models.BookWithEntry
+	bestScore, tied := 0.0, false
+	for i := range books {
+		b := &books[i]
+		if !authorMatches(author, b.Book.Author) {
+			continue
+		}
+		score := titleSimilarity(title, b.Book.Title)
+		if score < 0.75 {
+			continue
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = b, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+	if tied {
+		return nil
+	}
+	return best
+}
+
+var titleStopWords = map[string]bool{"the": true, "a": true, "an": true, "and": true, "of": true}
+
+// titleWords splits a title into its significant words, without a subtitle.
+func titleWords(title string) []string {
+	if i := strings.IndexAny(title, ":("); i > 0 {
+		title = title[:i]
+	}
+	var words []string
+	for _, w := range strings.Fields(nonAlphanumeric.ReplaceAllString(strings.ToLower(title), " ")) {
+		if !titleStopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// titleSimilarity scores two titles from 0 to 1 by the words they share.
+func titleSimilarity(a, b string) float64 {
+	wordsA, wordsB := titleWords(a), titleWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	inB := make(map[string]bool, len(wordsB))
+	for _, w := range wordsB {
+		inB[w] = true
+	}
+	shared := 0
+	for _, w := range wordsA {
+		if inB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(max(len(wordsA), len(wordsB)))
+}
+
+// authorMatches reports whether the clipping's author, if it has one, has a
+// surname found in the book's author.
+func authorMatches(clippingAuthor, bookAuthor string) bool {
+	words := strings.Fields(nonAlphanumeric.ReplaceAllString(strings.ToLower(clippingAuthor), " "))
+	if len(words) == 0 {
+		return true
+	}
+	surname := words[len(words)-1]
+	for _, w := range strings.Fields(nonAlphanumeric.ReplaceAllString(strings.ToLower(bookAuthor), " ")) {
+		if w == surname {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package importer
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseLibraryThing reads a LibraryThing export (More > Import/Export), in
+// either its tab-delimited or its JSON form, which it tells apart itself.
+// Collections that mean a status ("Currently reading", "To read", ...) set
+// it; the rest become tags alongside the book's own.
+func ParseLibraryThing(r io.Reader) ([]Record, error) {
+	reader := bufio.NewReader(r)
+	start, _ := reader.Peek(64)
+	start = bytes.TrimLeft(bytes.TrimPrefix(start, []byte("\uFEFF")), " \t\r\n")
+	if len(start) > 0 && (start[0] == '{' || start[0] == '[') {
+		return parseLibraryThingJSON(reader)
+	}
+	return parseLibraryThingTSV(reader)
+}
+
+func parseLibraryThingTSV(r io.Reader) ([]Record, error) {
+	rows, err := readTable(r, '\t')
+	if err != nil {
+		return nil, err
+	}
+	if err := requireColumns(rows, "LibraryThing", "Title", "Primary Author"); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		rec := Record{
+			Title:      row["Title"],
+			Author:     authorFirstLast(row["Primary Author"]),
+			ISBN:       firstISBN(row["ISBN"], row["ISBNs"]),
+			Pages:      leadingInt(row["Page Count"]),
+			Format:     models.ParseBookFormat(row["Media"]),
+			StartedAt:  parseDate(row["Date Started"]),
+			FinishedAt: parseDate(row["Date Read"]),
+			Review:     row["Review"],
+		}
+		if stars, err := strconv.ParseFloat(row["Rating"], 64); err == nil {
+			rec.Rating = roundRating(stars)
+		}
+		rec.Status, rec.Tags = libraryThingCollections(splitList(row["Collections"]), rec)
+		rec.Tags = mergeTags(splitList(row["Tags"]), rec.Tags)
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// libraryThingBook is one book in the JSON export. Its fields' types vary
+// between books (and LibraryThing versions), so the awkward ones are decoded
+// by hand.
+type libraryThingBook struct {
+	Title         string          `json:"title"`
+	PrimaryAuthor string          `json:"primaryauthor"`
+	Authors       json.RawMessage `json:"authors"`
+	ISBN          json.RawMessage `json:"isbn"`
+	Pages         json.RawMessage `json:"pages"`
+	Format        json.RawMessage `json:"format"`
+	Rating        json.RawMessage `json:"rating"`
+	Review        string          `json:"review"`
+	DateStarted   string          `json:"datestarted"`
+	DateRead      string          `json:"dateread"`
+	Tags          []string        `json:"tags"`
+	Collections   []string        `json:"collections"`
+}
+
+func parseLibraryThingJSON(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\uFEFF"))
+
+	// An object keyed by LibraryThing's book ID, or in older exports a list
+	var books []libraryThingBook
+	var byID map[string]libraryThingBook
+	if err := json.Unmarshal(data, &byID); err == nil {
+		ids := make([]string, 0, len(byID))
+		for id := range byID {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return leadingInt(ids[i]) < leadingInt(ids[j]) })
+		for _, id := range ids {
+			books = append(books, byID[id])
+		}
+	} else if err := json.Unmarshal(data, &books); err != nil {
+		return nil, fmt.Errorf("not a LibraryThing JSON export: %w", err)
+	}
+
+	records := make([]Record, 0, len(books))
+	for _, b := range books {
+		rec := Record{
+			Title:      b.Title,
+			Author:     libraryThingAuthor(b),
+			ISBN:       firstISBN(jsonStrings(b.ISBN)...),
+			Pages:      leadingInt(jsonString(b.Pages)),
+			StartedAt:  parseDate(b.DateStarted),
+			FinishedAt: parseDate(b.DateRead),
+			Review:     b.Review,
+		}
+		for _, format := range libraryThingFormats(b.Format) {
+			if rec.Format = models.ParseBookFormat(format); rec.Format != "" {
+				break
+			}
+		}
+		if stars, err := strconv.ParseFloat(jsonString(b.Rating), 64); err == nil {
+			rec.Rating = roundRating(stars)
+		}
+		rec.Status, rec.Tags = libraryThingCollections(b.Collections, rec)
+		rec.Tags = mergeTags(b.Tags, rec.Tags)
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// libraryThingCollections works out a status from LibraryThing's built-in
+// collections, or failing that from the reading dates, and returns the other
+// collections as tags.
+func libraryThingCollections(collections []string, rec Record) (models.BookStatus, []string) {
+	var status models.BookStatus
+	var tags []string
+	for _, collection := range collections {
+		switch strings.ToLower(collection) {
+		case "currently reading":
+			status = models.StatusReading
+		case "read but unowned":
+			status = models.StatusFinished
+		case "to read", "wishlist":
+			if status == "" {
+				status = models.StatusWantToRead
+			}
+		case "your library":
+		default:
+			tags = append(tags, collection)
+		}
+	}
+
+	if status == "" {
+		switch {
+		case !rec.FinishedAt.IsZero():
+			status = models.StatusFinished
+		case !rec.StartedAt.IsZero():
+			status = models.StatusReading
+		default:
+			status = models.StatusWantToRead
+		}
+	}
+	return status, tags
+}
+
+// libraryThingAuthor prefers the first author's "First Last" name over the
+// primary author, which is "Last, First".
+func libraryThingAuthor(b libraryThingBook) string {
+	var authors []struct {
+		FirstLast string `json:"fl"`
+	}
+	if json.Unmarshal(b.Authors, &authors) == nil {
+		for _, a := range authors {
+			if a.FirstLast != "" {
+				return a.FirstLast
+			}
+		}
+	}
+	return authorFirstLast(b.PrimaryAuthor)
+}
+
+// libraryThingFormats reads the format field, a list of {"code", "text"}.
+func libraryThingFormats(raw json.RawMessage) []string {
+	var formats []struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &formats) != nil {
+		return jsonStrings(raw)
+	}
+	var texts []string
+	for _, f := range formats {
+		texts = append(texts, f.Text)
+	}
+	return texts
+}
+
+// authorFirstLast turns "Herbert, Frank" into "Frank Herbert".
+func authorFirstLast(name string) string {
+	last, first, ok := strings.Cut(name, ",")
+	if !ok || strings.Contains(first, ",") {
+		return strings.TrimSpace(name)
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// firstISBN returns the first valid ISBN among values, which LibraryThing
+// writes bracketed and comma-separated, e.g. "[0441013597]".
+func firstISBN(values ...string) string {
+	for _, value := range values {
+		for _, isbn := range splitList(strings.Trim(value, "[] ")) {
+			if api.NormalizeISBN(isbn) != "" {
+				return strings.Trim(isbn, "[] ")
+			}
+		}
+	}
+	return ""
+}
+
+// leadingInt parses the number at the start of s, e.g. 528 from "528 p.",
+// returning 0 if there isn't one.
+func leadingInt(s string) int {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+// jsonString reads a JSON string or number as a string.
+func jsonString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var n json.Number
+	if json.Unmarshal(raw, &n) == nil {
+		return n.String()
+	}
+	return ""
+}
+
+// jsonStrings reads a JSON string, list of strings or object of strings, in
+// key order.
+func jsonStrings(raw json.RawMessage) []string {
+	if s := jsonString(raw); s != "" {
+		return []string{s}
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list
+	}
+	var object map[string]string
+	if json.Unmarshal(raw, &object) == nil {
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			list = append(list, object[key])
+		}
+	}
+	return list
+}
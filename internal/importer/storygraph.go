@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseStoryGraph reads a StoryGraph library export (Manage Account > Export
+// StoryGraph Library), a CSV with columns such as Title, Authors, ISBN/UID,
+// Format, Read Status, Dates Read, Star Rating, Review and Tags.
+func ParseStoryGraph(r io.Reader) ([]Record, error) {
+	rows, err := readTable(r, ',')
+	if err != nil {
+		return nil, err
+	}
+	if err := requireColumns(rows, "StoryGraph", "Title", "Authors", "Read Status"); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(rows))
+	for _, row := range rows {
+		rec := Record{
+			Title:  row["Title"],
+			Author: firstOf(row["Authors"]),
+			ISBN:   row["ISBN/UID"],
+			Format: storyGraphFormat(row["Format"]),
+			Status: storyGraphStatus(row["Read Status"]),
+			Review: row["Review"],
+			Tags:   splitList(row["Tags"]),
+		}
+		if api.NormalizeISBN(rec.ISBN) == "" {
+			// StoryGraph puts its own IDs here for books without an ISBN
+			rec.ISBN = ""
+		}
+		if stars, err := strconv.ParseFloat(row["Star Rating"], 64); err == nil {
+			rec.Rating = roundRating(stars)
+		}
+		rec.StartedAt, rec.FinishedAt = storyGraphDates(row["Dates Read"])
+		if rec.FinishedAt.IsZero() {
+			rec.FinishedAt = parseDate(row["Last Date Read"])
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func storyGraphStatus(status string) models.BookStatus {
+	switch strings.ToLower(status) {
+	case "read":
+		return models.StatusFinished
+	case "currently-reading":
+		return models.StatusReading
+	case "did-not-finish":
+		return models.StatusDNF
+	}
+	return models.StatusWantToRead
+}
+
+func storyGraphFormat(format string) models.BookFormat {
+	if strings.EqualFold(format, "digital") {
+		return models.FormatEbook
+	}
+	return models.ParseBookFormat(format)
+}
+
+// storyGraphDates reads the most recent read from Dates Read, a list of
+// ranges like "2023/01/05-2023/01/20, 2024/03/01-2024/03/09". A range may
+// have only an end date.
+func storyGraphDates(datesRead string) (started, finished time.Time) {
+	reads := splitList(datesRead)
+	if len(reads) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	last := reads[len(reads)-1]
+	if start, end, ok := strings.Cut(last, "-"); ok {
+		if finished := parseDate(end); !finished.IsZero() {
+			return parseDate(start), finished
+		}
+	}
+	return time.Time{}, parseDate(last)
+}
+
+// firstOf returns the first name in a comma-separated list of authors.
+func firstOf(authors string) string {
+	if names := splitList(authors); len(names) > 0 {
+		return names[0]
+	}
+	return ""
+}
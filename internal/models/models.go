@@ -1,7 +1,13 @@
 package models
 
 import (
+	"bookshelf/internal/dateparse"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,30 +17,196 @@ const (
 	StatusWantToRead BookStatus = "want-to-read"
 	StatusReading    BookStatus = "reading"
 	StatusFinished   BookStatus = "finished"
+	StatusDNF        BookStatus = "did-not-finish" // Abandoned partway through
 )
 
+// BookStatuses lists the valid statuses.
+var BookStatuses = []BookStatus{StatusWantToRead, StatusReading, StatusFinished, StatusDNF}
+
+func (s BookStatus) IsValid() bool {
+	for _, valid := range BookStatuses {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
 type Book struct {
-	ID             int64
-	Title          string
-	Author         string
-	ISBN           sql.NullString
-	Pages          sql.NullInt64
-	CoverURL       sql.NullString
-	Description    sql.NullString
-	OpenLibraryKey sql.NullString
-	Genres         sql.NullString
-	CreatedAt      time.Time
+	ID              int64
+	Title           string
+	Author          string
+	ISBN            sql.NullString
+	Pages           sql.NullInt64
+	CoverURL        sql.NullString
+	Description     sql.NullString
+	OpenLibraryKey  sql.NullString
+	Genres          sql.NullString
+	EditionKey      sql.NullString // Open Library edition, e.g. /books/OL123M
+	Publisher       sql.NullString
+	PublishDate     sql.NullString // As given by the publisher, e.g. "2005" or "October 1, 2005"
+	Language        sql.NullString // MARC code, e.g. "eng"
+	Format          sql.NullString // One of BookFormats
+	DurationMinutes sql.NullInt64  // Running time, for audiobooks
+	Tags            sql.NullString // JSON array of your own labels, unlike Genres
+	Series          sql.NullString
+	SeriesIndex     sql.NullFloat64 // Position in Series; can be fractional, e.g. 2.5 for a novella
+	CalibreID       sql.NullInt64   // The book's ID in the Calibre library it was imported from
+	CreatedAt       time.Time
+}
+
+// NullString trims s, storing it as NULL if that leaves it empty.
+func NullString(s string) sql.NullString {
+	s = strings.TrimSpace(s)
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// TagList decodes Tags, returning nil if it's empty or malformed.
+func (b *Book) TagList() []string {
+	if !b.Tags.Valid {
+		return nil
+	}
+	var tags []string
+	if err := json.Unmarshal([]byte(b.Tags.String), &tags); err != nil {
+		return nil
+	}
+	return tags
+}
+
+// SetTags stores tags as Tags, or NULL if there are none.
+func (b *Book) SetTags(tags []string) {
+	b.Tags = sql.NullString{}
+	if len(tags) > 0 {
+		if jsonBytes, err := json.Marshal(tags); err == nil {
+			b.Tags = sql.NullString{String: string(jsonBytes), Valid: true}
+		}
+	}
+}
+
+// SeriesLabel describes the book's place in its series, e.g. "Dune #2", or
+// returns "" if it isn't in one.
+func (b Book) SeriesLabel() string {
+	if !b.Series.Valid || b.Series.String == "" {
+		return ""
+	}
+	if !b.SeriesIndex.Valid {
+		return b.Series.String
+	}
+	return b.Series.String + " #" + strconv.FormatFloat(b.SeriesIndex.Float64, 'f', -1, 64)
+}
+
+// BookFormat is the physical (or not) form of a book.
+type BookFormat string
+
+const (
+	FormatHardcover BookFormat = "hardcover"
+	FormatPaperback BookFormat = "paperback"
+	FormatEbook     BookFormat = "ebook"
+	FormatAudiobook BookFormat = "audiobook"
+)
+
+// BookFormats lists the valid formats.
+var BookFormats = []BookFormat{FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook}
+
+// IsAudiobook reports whether the book is listened to rather than read.
+func (b *Book) IsAudiobook() bool {
+	return b.Format.String == string(FormatAudiobook)
+}
+
+// FormatMinutes renders a running time or reading time, e.g. "45m", "3h" or
+// "3h 5m".
+func FormatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
+
+func (f BookFormat) IsValid() bool {
+	for _, valid := range BookFormats {
+		if f == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseBookFormat maps a free-form description like "Mass Market Paperback" or
+// "Audio CD" to a BookFormat. Returns "" if it isn't recognised.
+func ParseBookFormat(s string) BookFormat {
+	s = strings.ToLower(s)
+	switch {
+	case s == "":
+		return ""
+	case strings.Contains(s, "audio") || strings.Contains(s, "mp3") || strings.Contains(s, "cd"):
+		return FormatAudiobook
+	case strings.Contains(s, "ebook") || strings.Contains(s, "e-book") || strings.Contains(s, "kindle") ||
+		strings.Contains(s, "epub") || strings.Contains(s, "electronic"):
+		return FormatEbook
+	case strings.Contains(s, "hardcover") || strings.Contains(s, "hardback") || strings.Contains(s, "hard cover") ||
+		strings.Contains(s, "library binding"):
+		return FormatHardcover
+	case strings.Contains(s, "paperback") || strings.Contains(s, "softcover") || strings.Contains(s, "soft cover") ||
+		strings.Contains(s, "mass market") || strings.Contains(s, "trade paper"):
+		return FormatPaperback
+	}
+	return ""
 }
 
 type ReadingEntry struct {
-	ID         int64
-	BookID     int64
-	Status     BookStatus
-	StartedAt  sql.NullTime
-	FinishedAt sql.NullTime
-	Rating     sql.NullInt64
-	Review     sql.NullString
-	UpdatedAt  time.Time
+	ID             int64
+	BookID         int64
+	Status         BookStatus
+	StartedAt      sql.NullTime
+	FinishedAt     sql.NullTime
+	Rating         sql.NullFloat64 // Stars from 0.5 to 5, in half steps
+	Review         sql.NullString
+	CurrentPage    sql.NullInt64
+	CurrentMinutes sql.NullInt64 // Listening position, for audiobooks
+	UpdatedAt      time.Time
+}
+
+// ParseReadingDate parses an exact or fuzzy date, such as a start or finish
+// date, relative to now. Dates in the future are rejected.
+func ParseReadingDate(value string, now time.Time) (time.Time, error) {
+	date, err := dateparse.Parse(value, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %w", err)
+	}
+	if date.After(now) {
+		return time.Time{}, fmt.Errorf("invalid date: %s is in the future", date.Format("2006-01-02"))
+	}
+	return date, nil
+}
+
+// CheckReadingDates returns an error if startedAt is after finishedAt; either
+// may be unset. Dates are compared by calendar day, so a book can be started
+// and finished on the same day.
+func CheckReadingDates(startedAt, finishedAt sql.NullTime) error {
+	if !startedAt.Valid || !finishedAt.Valid {
+		return nil
+	}
+	started, finished := startedAt.Time.Format("2006-01-02"), finishedAt.Time.Format("2006-01-02")
+	if started > finished {
+		return fmt.Errorf("start date %s is after finish date %s", started, finished)
+	}
+	return nil
+}
+
+// CheckStatusDate returns an error if recording date as the start (reading)
+// or finish (finished) of the entry would put its start after its finish.
+func (e ReadingEntry) CheckStatusDate(status BookStatus, date time.Time) error {
+	started, finished := e.StartedAt, e.FinishedAt
+	switch status {
+	case StatusReading:
+		started = sql.NullTime{Time: date, Valid: true}
+	case StatusFinished:
+		finished = sql.NullTime{Time: date, Valid: true}
+	}
+	return CheckReadingDates(started, finished)
 }
 
 type BookWithEntry struct {
@@ -50,6 +222,69 @@ type ReadingGoal struct {
 	UpdatedAt time.Time
 }
 
+// Author is an author's Open Library record, saved by `bookshelf author`.
+type Author struct {
+	ID             int64
+	OpenLibraryKey string // e.g. /authors/OL79034A
+	Name           string
+	Bio            sql.NullString
+	BirthDate      sql.NullString // As Open Library gives it, e.g. "8 October 1920"
+	DeathDate      sql.NullString
+	PhotoURL       sql.NullString
+	Works          sql.NullString // JSON array of AuthorWork
+	UpdatedAt      time.Time
+}
+
+// AuthorWork is one of an author's works, as saved on Author.
+type AuthorWork struct {
+	Key            string `json:"key"`
+	Title          string `json:"title"`
+	FirstPublished string `json:"first_published,omitempty"`
+	CoverURL       string `json:"cover_url,omitempty"`
+}
+
+// WorkList decodes Works, returning nil if it's empty or malformed.
+func (a *Author) WorkList() []AuthorWork {
+	if !a.Works.Valid {
+		return nil
+	}
+	var works []AuthorWork
+	if err := json.Unmarshal([]byte(a.Works.String), &works); err != nil {
+		return nil
+	}
+	return works
+}
+
+// ReadingSession is a single day's reading activity on a book.
+type ReadingSession struct {
+	ID        int64
+	BookID    int64
+	Date      time.Time
+	Pages     sql.NullInt64
+	Minutes   sql.NullInt64
+	Note      sql.NullString
+	CreatedAt time.Time
+}
+
+// Quote is a passage saved from a book, typed in or imported from a Kindle.
+type Quote struct {
+	ID        int64
+	BookID    int64
+	Text      string
+	Page      sql.NullInt64
+	Location  sql.NullString // Kindle location, e.g. "180-182"
+	Note      sql.NullString
+	CreatedAt time.Time
+}
+
+// ReadingTimer is an in-progress `bookshelf read` session, persisted so it
+// survives a crash.
+type ReadingTimer struct {
+	BookID    int64
+	StartedAt time.Time
+	StartPage sql.NullInt64
+}
+
 type SortField string
 
 const (
@@ -65,13 +300,131 @@ type ListOptions struct {
 	SortBy       SortField
 }
 
+// ParseListOptions builds ListOptions from a status filter, search text and
+// sort field as the user wrote them, e.g. from list's --status, --search and
+// --sort flags. An empty status lists every book; an empty sort is "added".
+func ParseListOptions(status, search, sort string) (ListOptions, error) {
+	opts := ListOptions{SearchQuery: search}
+
+	if status != "" {
+		s := BookStatus(status)
+		if !s.IsValid() {
+			return opts, fmt.Errorf("invalid status: %s (use: want-to-read, reading, finished, did-not-finish)", status)
+		}
+		opts.StatusFilter = &s
+	}
+
+	switch sort {
+	case "added", "":
+		opts.SortBy = SortByAdded
+	case "title":
+		opts.SortBy = SortByTitle
+	case "author":
+		opts.SortBy = SortByAuthor
+	case "rating":
+		opts.SortBy = SortByRating
+	default:
+		return opts, fmt.Errorf("invalid sort option: %s (use: added, title, author, rating)", sort)
+	}
+	return opts, nil
+}
+
+// RatingScale is how ratings are entered and displayed. Ratings are always
+// stored as stars from 0.5 to 5 in half steps, which every scale maps onto.
+type RatingScale string
+
+const (
+	RatingScaleStars     RatingScale = "stars"      // 1-5 whole stars
+	RatingScaleHalfStars RatingScale = "half-stars" // 0.5-5 in half steps
+	RatingScaleTenPoint  RatingScale = "10-point"   // 1-10 points
+)
+
+// RatingScales lists the supported scales.
+var RatingScales = []RatingScale{RatingScaleStars, RatingScaleHalfStars, RatingScaleTenPoint}
+
+// IsValid reports whether s is a supported scale.
+func (s RatingScale) IsValid() bool {
+	for _, scale := range RatingScales {
+		if s == scale {
+			return true
+		}
+	}
+	return false
+}
+
+// Range describes the accepted input, for help and error messages.
+func (s RatingScale) Range() string {
+	switch s {
+	case RatingScaleHalfStars:
+		return "0.5 and 5 in half steps"
+	case RatingScaleTenPoint:
+		return "1 and 10"
+	default:
+		return "1 and 5"
+	}
+}
+
+// Parse converts a rating entered on this scale into stars.
+func (s RatingScale) Parse(input string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+	errRange := fmt.Errorf("rating must be between %s", s.Range())
+	if err != nil {
+		return 0, errRange
+	}
+
+	switch s {
+	case RatingScaleHalfStars:
+		if value < 0.5 || value > 5 || value*2 != math.Trunc(value*2) {
+			return 0, errRange
+		}
+		return value, nil
+	case RatingScaleTenPoint:
+		if value < 1 || value > 10 || value != math.Trunc(value) {
+			return 0, errRange
+		}
+		return value / 2, nil
+	default:
+		if value < 1 || value > 5 || value != math.Trunc(value) {
+			return 0, errRange
+		}
+		return value, nil
+	}
+}
+
+// Value converts stars into this scale's units.
+func (s RatingScale) Value(stars float64) float64 {
+	if s == RatingScaleTenPoint {
+		return stars * 2
+	}
+	return stars
+}
+
+// Max is the highest rating on this scale.
+func (s RatingScale) Max() int {
+	if s == RatingScaleTenPoint {
+		return 10
+	}
+	return 5
+}
+
+// Format renders stars on this scale, e.g. "4/5", "4.5/5" or "9/10".
+func (s RatingScale) Format(stars float64) string {
+	return fmt.Sprintf("%s/%d", strconv.FormatFloat(s.Value(stars), 'f', -1, 64), s.Max())
+}
+
+// FormatAverage renders an average rating to one decimal place, e.g. "4.3/5".
+func (s RatingScale) FormatAverage(stars float64) string {
+	return fmt.Sprintf("%.1f/%d", s.Value(stars), s.Max())
+}
+
 // SiteConfig holds configurable website parameters.
 type SiteConfig struct {
-	Title       string // Site title (default: "My Bookshelf")
-	Subtitle    string // Site subtitle (default: "Personal Reading Tracker")
-	Author      string // Author name for attribution
-	Description string // Meta description
-	BaseURL     string // Base URL for the site (for canonical links)
+	Title       string      // Site title (default: "My Bookshelf")
+	Subtitle    string      // Site subtitle (default: "Personal Reading Tracker")
+	Author      string      // Author name for attribution
+	Description string      // Meta description
+	BaseURL     string      // Base URL for the site (for canonical links)
+	RatingScale RatingScale // Scale ratings are shown on (default: stars)
 }
 
 // DefaultSiteConfig returns the default configuration.
@@ -82,5 +435,6 @@ func DefaultSiteConfig() SiteConfig {
 		Author:      "",
 		Description: "",
 		BaseURL:     "",
+		RatingScale: RatingScaleStars,
 	}
 }
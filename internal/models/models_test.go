@@ -1,7 +1,9 @@
 package models
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 )
 
 func TestBookStatusConstants(t *testing.T) {
@@ -30,3 +32,139 @@ func TestBookStatusIsValid(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckReadingDates(t *testing.T) {
+	day := func(s string) sql.NullTime {
+		date, _ := time.Parse("2006-01-02 15:04", s)
+		return sql.NullTime{Time: date, Valid: true}
+	}
+	tests := []struct {
+		name     string
+		started  sql.NullTime
+		finished sql.NullTime
+		wantErr  bool
+	}{
+		{"same day, started later in it", day("2026-03-14 18:30"), day("2026-03-14 00:00"), false},
+		{"finished after started", day("2026-03-14 09:00"), day("2026-03-20 00:00"), false},
+		{"finished before started", day("2026-03-14 00:00"), day("2026-03-13 23:59"), true},
+		{"no start", sql.NullTime{}, day("2026-03-13 00:00"), false},
+		{"no finish", day("2026-03-14 00:00"), sql.NullTime{}, false},
+	}
+
+	for _, tt := range tests {
+		err := CheckReadingDates(tt.started, tt.finished)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckReadingDates() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRatingScaleParse(t *testing.T) {
+	tests := []struct {
+		scale    RatingScale
+		input    string
+		expected float64
+		wantErr  bool
+	}{
+		{RatingScaleStars, "4", 4, false},
+		{RatingScaleStars, "4.5", 0, true},
+		{RatingScaleStars, "0", 0, true},
+		{RatingScaleStars, "6", 0, true},
+		{RatingScaleStars, "abc", 0, true},
+		{RatingScaleHalfStars, "4.5", 4.5, false},
+		{RatingScaleHalfStars, "0.5", 0.5, false},
+		{RatingScaleHalfStars, "4.3", 0, true},
+		{RatingScaleHalfStars, "0", 0, true},
+		{RatingScaleTenPoint, "9", 4.5, false},
+		{RatingScaleTenPoint, "1", 0.5, false},
+		{RatingScaleTenPoint, "11", 0, true},
+		{RatingScaleTenPoint, "7.5", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.scale.Parse(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s.Parse(%q) expected error", tt.scale, tt.input)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Errorf("%s.Parse(%q) = %v, %v; expected %v", tt.scale, tt.input, got, err, tt.expected)
+		}
+	}
+}
+
+func TestRatingScaleFormat(t *testing.T) {
+	tests := []struct {
+		scale    RatingScale
+		stars    float64
+		expected string
+	}{
+		{RatingScaleStars, 4, "4/5"},
+		{RatingScaleStars, 4.5, "4.5/5"},
+		{RatingScaleHalfStars, 3.5, "3.5/5"},
+		{RatingScaleTenPoint, 4.5, "9/10"},
+		{RatingScaleTenPoint, 5, "10/10"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.scale.Format(tt.stars); got != tt.expected {
+			t.Errorf("%s.Format(%v) = %s, expected %s", tt.scale, tt.stars, got, tt.expected)
+		}
+	}
+
+	if got := RatingScaleTenPoint.FormatAverage(4.25); got != "8.5/10" {
+		t.Errorf("expected '8.5/10', got %s", got)
+	}
+}
+
+func TestParseBookFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected BookFormat
+	}{
+		{"Hardcover", FormatHardcover},
+		{"Library Binding", FormatHardcover},
+		{"Mass Market Paperback", FormatPaperback},
+		{"paperback", FormatPaperback},
+		{"E-book", FormatEbook},
+		{"Kindle Edition", FormatEbook},
+		{"Audio CD", FormatAudiobook},
+		{"MP3 CD", FormatAudiobook},
+		{"Microfilm", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseBookFormat(tt.input); got != tt.expected {
+			t.Errorf("ParseBookFormat(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+
+	if !FormatAudiobook.IsValid() || BookFormat("scroll").IsValid() {
+		t.Error("unexpected IsValid result")
+	}
+}
+
+func TestParseListOptions(t *testing.T) {
+	opts, err := ParseListOptions("reading", "dune", "title")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.StatusFilter == nil || *opts.StatusFilter != StatusReading || opts.SearchQuery != "dune" || opts.SortBy != SortByTitle {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+
+	opts, err = ParseListOptions("", "", "")
+	if err != nil || opts.StatusFilter != nil || opts.SortBy != SortByAdded {
+		t.Errorf("expected defaults, got %+v, %v", opts, err)
+	}
+
+	if _, err := ParseListOptions("done", "", ""); err == nil {
+		t.Error("expected an error for an invalid status")
+	}
+	if _, err := ParseListOptions("", "", "pages"); err == nil {
+		t.Error("expected an error for an invalid sort")
+	}
+}
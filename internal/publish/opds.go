@@ -0,0 +1,342 @@
+package publish
+
+import (
+	"bookshelf/internal/models"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OPDS link types, from the OPDS 1.2 catalog spec.
+const (
+	opdsNavigation  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	opdsAcquisition = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+)
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Xmlns     string      `xml:"xmlns,attr"`
+	XmlnsDC   string      `xml:"xmlns:dc,attr"`
+	XmlnsOPDS string      `xml:"xmlns:opds,attr"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    *atomPerson `xml:"author,omitempty"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Authors    []atomPerson   `xml:"author"`
+	Identifier string         `xml:"dc:identifier,omitempty"`
+	Publisher  string         `xml:"dc:publisher,omitempty"`
+	Language   string         `xml:"dc:language,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Links      []atomLink     `xml:"link"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomLink struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+// opdsShelf is an acquisition feed: a named list of books.
+type opdsShelf struct {
+	file  string
+	title string
+	books []models.BookWithEntry
+}
+
+// generateOPDS writes an OPDS 1.2 catalog into outputDir/opds, for browsing
+// the shelf from e-reader apps: a root catalog.xml leading to feeds of the
+// books by status, genre and author. Each book's entry links to its HTML
+// page. Covers are as on the site, so copyLocalCovers must run first.
+// Returns the number of feeds written.
+func generateOPDS(outputDir string, books []models.BookWithEntry, config models.SiteConfig, now time.Time) (int, error) {
+	dir := filepath.Join(outputDir, "opds")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create opds directory: %w", err)
+	}
+	updated := now.UTC().Format(time.RFC3339)
+
+	statusShelves := []opdsShelf{
+		{file: "reading.xml", title: "Currently Reading", books: booksWithStatus(books, models.StatusReading)},
+		{file: "want-to-read.xml", title: "Want to Read", books: booksWithStatus(books, models.StatusWantToRead)},
+		{file: "finished.xml", title: "Finished", books: booksWithStatus(books, models.StatusFinished)},
+		{file: "all.xml", title: "All Books", books: books},
+	}
+	genreShelves := groupShelves(books, "genre", func(b *models.BookWithEntry) []string { return bookGenres(b.Book) })
+	authorShelves := groupShelves(books, "author", func(b *models.BookWithEntry) []string { return splitAuthors(b.Book.Author) })
+
+	feeds := 0
+	write := func(name string, feed atomFeed) error {
+		feed.Xmlns = "http://www.w3.org/2005/Atom"
+		feed.XmlnsDC = "http://purl.org/dc/terms/"
+		feed.XmlnsOPDS = "http://opds-spec.org/2010/catalog"
+		feed.ID = "urn:bookshelf:opds:" + strings.TrimSuffix(name, ".xml")
+		feed.Updated = updated
+		// Atom needs an author for the navigation entries, which have none of their own
+		feed.Author = &atomPerson{Name: config.Title}
+		if config.Author != "" {
+			feed.Author.Name = config.Author
+		}
+		feed.Links = append([]atomLink{
+			{Rel: "start", Href: "catalog.xml", Type: opdsNavigation},
+		}, feed.Links...)
+
+		data, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append([]byte(xml.Header), append(data, '\n')...)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		feeds++
+		return nil
+	}
+
+	navigationEntry := func(id, title, href, kind, content string) atomEntry {
+		return atomEntry{
+			Title:   title,
+			ID:      "urn:bookshelf:opds:" + id,
+			Updated: updated,
+			Content: &atomText{Type: "text", Text: content},
+			Links:   []atomLink{{Rel: "subsection", Href: href, Type: kind}},
+		}
+	}
+	countText := func(n int) string {
+		if n == 1 {
+			return "1 book"
+		}
+		return fmt.Sprintf("%d books", n)
+	}
+
+	// Root catalog
+	root := atomFeed{
+		Title: config.Title,
+		Links: []atomLink{{Rel: "self", Href: "catalog.xml", Type: opdsNavigation}},
+	}
+	for _, shelf := range statusShelves {
+		root.Entries = append(root.Entries, navigationEntry(strings.TrimSuffix(shelf.file, ".xml"), shelf.title, shelf.file, opdsAcquisition, countText(len(shelf.books))))
+	}
+	root.Entries = append(root.Entries,
+		navigationEntry("genres", "Genres", "genres.xml", opdsNavigation, fmt.Sprintf("%d genres", len(genreShelves))),
+		navigationEntry("authors", "Authors", "authors.xml", opdsNavigation, fmt.Sprintf("%d authors", len(authorShelves))),
+	)
+	if err := write("catalog.xml", root); err != nil {
+		return feeds, err
+	}
+
+	// Navigation feeds listing the genres and authors
+	for _, nav := range []struct {
+		file, title string
+		shelves     []opdsShelf
+	}{
+		{"genres.xml", "Genres", genreShelves},
+		{"authors.xml", "Authors", authorShelves},
+	} {
+		feed := atomFeed{
+			Title: nav.title,
+			Links: []atomLink{
+				{Rel: "self", Href: nav.file, Type: opdsNavigation},
+				{Rel: "up", Href: "catalog.xml", Type: opdsNavigation},
+			},
+		}
+		for _, shelf := range nav.shelves {
+			feed.Entries = append(feed.Entries, navigationEntry(strings.TrimSuffix(shelf.file, ".xml"), shelf.title, shelf.file, opdsAcquisition, countText(len(shelf.books))))
+		}
+		if err := write(nav.file, feed); err != nil {
+			return feeds, err
+		}
+	}
+
+	// Acquisition feeds of books
+	writeShelf := func(shelf opdsShelf, up string) error {
+		feed := atomFeed{
+			Title: shelf.title,
+			Links: []atomLink{
+				{Rel: "self", Href: shelf.file, Type: opdsAcquisition},
+				{Rel: "up", Href: up, Type: opdsNavigation},
+			},
+		}
+		for i := range shelf.books {
+			feed.Entries = append(feed.Entries, bookEntry(&shelf.books[i], config))
+		}
+		return write(shelf.file, feed)
+	}
+	for _, shelf := range statusShelves {
+		if err := writeShelf(shelf, "catalog.xml"); err != nil {
+			return feeds, err
+		}
+	}
+	for _, shelf := range genreShelves {
+		if err := writeShelf(shelf, "genres.xml"); err != nil {
+			return feeds, err
+		}
+	}
+	for _, shelf := range authorShelves {
+		if err := writeShelf(shelf, "authors.xml"); err != nil {
+			return feeds, err
+		}
+	}
+	return feeds, nil
+}
+
+// bookEntry describes a book for an acquisition feed. There's no ebook to
+// download, so the acquisition link is the book's page on the site.
+func bookEntry(b *models.BookWithEntry, config models.SiteConfig) atomEntry {
+	book := b.Book
+	updated := book.CreatedAt
+	if b.ReadingEntry.UpdatedAt.After(updated) {
+		updated = b.ReadingEntry.UpdatedAt
+	}
+
+	page := fmt.Sprintf("../books/%d.html", book.ID)
+	entry := atomEntry{
+		Title:     book.Title,
+		ID:        "urn:bookshelf:book:" + strconv.FormatInt(book.ID, 10),
+		Updated:   updated.UTC().Format(time.RFC3339),
+		Publisher: book.Publisher.String,
+		Language:  book.Language.String,
+		Links: []atomLink{
+			{Rel: "http://opds-spec.org/acquisition", Href: page, Type: "text/html", Title: "View on " + config.Title},
+			{Rel: "alternate", Href: page, Type: "text/html"},
+		},
+	}
+	for _, author := range splitAuthors(book.Author) {
+		entry.Authors = append(entry.Authors, atomPerson{Name: author})
+	}
+	if book.ISBN.Valid && book.ISBN.String != "" {
+		entry.Identifier = "urn:isbn:" + book.ISBN.String
+	}
+	for _, genre := range bookGenres(book) {
+		entry.Categories = append(entry.Categories, atomCategory{Term: genre, Label: genre})
+	}
+	if book.Description.Valid && strings.TrimSpace(book.Description.String) != "" {
+		entry.Summary = &atomText{Type: "text", Text: strings.TrimSpace(book.Description.String)}
+	}
+	if book.CoverURL.Valid && book.CoverURL.String != "" {
+		cover := book.CoverURL.String
+		if !strings.Contains(cover, "://") {
+			cover = "../" + cover
+		}
+		entry.Links = append(entry.Links,
+			atomLink{Rel: "http://opds-spec.org/image", Href: cover, Type: imageType(cover)},
+			atomLink{Rel: "http://opds-spec.org/image/thumbnail", Href: cover, Type: imageType(cover)},
+		)
+	}
+	return entry
+}
+
+// bookGenres decodes a book's genres, returning nil if they're malformed.
+func bookGenres(book models.Book) []string {
+	var genres []string
+	if book.Genres.Valid {
+		json.Unmarshal([]byte(book.Genres.String), &genres)
+	}
+	return genres
+}
+
+func booksWithStatus(books []models.BookWithEntry, status models.BookStatus) []models.BookWithEntry {
+	var matching []models.BookWithEntry
+	for _, b := range books {
+		if b.ReadingEntry.Status == status {
+			matching = append(matching, b)
+		}
+	}
+	return matching
+}
+
+// groupShelves makes a shelf for each name keys returns for the books, such
+// as each genre, sorted by name.
+func groupShelves(books []models.BookWithEntry, prefix string, keys func(*models.BookWithEntry) []string) []opdsShelf {
+	byName := make(map[string][]models.BookWithEntry)
+	for i := range books {
+		for _, name := range keys(&books[i]) {
+			byName[name] = append(byName[name], books[i])
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+
+	shelves := make([]opdsShelf, 0, len(names))
+	taken := make(map[string]bool)
+	for _, name := range names {
+		file := prefix + "-" + slug(name)
+		for n := 2; taken[file]; n++ {
+			file = fmt.Sprintf("%s-%s-%d", prefix, slug(name), n)
+		}
+		taken[file] = true
+		shelves = append(shelves, opdsShelf{file: file + ".xml", title: name, books: byName[name]})
+	}
+	return shelves
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug makes a name safe for a file name, e.g. "Science Fiction" becomes
+// "science-fiction".
+func slug(name string) string {
+	s := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if s == "" {
+		return "x"
+	}
+	return s
+}
+
+// splitAuthors splits an author field listing several, such as "Neil Gaiman,
+// Terry Pratchett".
+func splitAuthors(author string) []string {
+	var authors []string
+	for _, a := range strings.FieldsFunc(author, func(r rune) bool { return r == ',' || r == ';' || r == '&' }) {
+		if a = strings.TrimSpace(a); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	return authors
+}
+
+func imageType(url string) string {
+	switch strings.ToLower(filepath.Ext(url)) {
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	}
+	return "image/jpeg"
+}
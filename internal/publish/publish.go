@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,22 +20,42 @@ type GoalProgress struct {
 	Year    int
 }
 
+// Option configures Generate.
+type Option func(*options)
+
+type options struct {
+	opds bool
+}
+
+// WithOPDS also writes an OPDS catalog of the shelf to opds/catalog.xml, for
+// e-reader apps.
+func WithOPDS() Option {
+	return func(o *options) { o.opds = true }
+}
+
 type SiteData struct {
 	Books       []models.BookWithEntry
 	Stats       *db.Stats
 	Config      models.SiteConfig
 	Genres      []string
 	Goal        *GoalProgress
+	OPDS        bool // Whether there's an OPDS catalog to link to
 	GeneratedAt string
 }
 
 type BookPageData struct {
 	Book        models.BookWithEntry
+	Quotes      []models.Quote
 	Config      models.SiteConfig
 	GeneratedAt string
 }
 
-func Generate(outputDir string) error {
+func Generate(outputDir string, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Create output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -57,36 +78,20 @@ func Generate(outputDir string) error {
 	}
 
 	// Fetch reading goal for current year
-	currentYear := time.Now().Year()
-	var goalProgress *GoalProgress
-	goal, err := db.GetGoal(currentYear)
+	goalProgress, err := ReadingGoalProgress(time.Now().Year())
 	if err != nil {
-		return fmt.Errorf("failed to fetch reading goal: %w", err)
+		return err
 	}
-	if goal != nil {
-		booksFinished, err := db.GetBooksFinishedInYear(currentYear)
-		if err != nil {
-			return fmt.Errorf("failed to fetch books finished: %w", err)
-		}
-		percent := 0
-		if goal.Target > 0 {
-			percent = (booksFinished * 100) / goal.Target
-			if percent > 100 {
-				percent = 100
-			}
-		}
-		goalProgress = &GoalProgress{
-			Target:  goal.Target,
-			Current: booksFinished,
-			Percent: percent,
-			Year:    currentYear,
-		}
+
+	if err := copyLocalCovers(outputDir, books); err != nil {
+		return err
 	}
 
 	// Collect unique genres
 	genres := collectUniqueGenres(books)
 
-	generatedAt := time.Now().Format("January 2, 2006")
+	now := time.Now()
+	generatedAt := now.Format("January 2, 2006")
 
 	// Generate index page
 	siteData := SiteData{
@@ -95,6 +100,7 @@ func Generate(outputDir string) error {
 		Config:      config,
 		Genres:      genres,
 		Goal:        goalProgress,
+		OPDS:        o.opds,
 		GeneratedAt: generatedAt,
 	}
 
@@ -119,16 +125,55 @@ func Generate(outputDir string) error {
 		return err
 	}
 
+	feeds := 0
+	if o.opds {
+		if feeds, err = generateOPDS(outputDir, books, config, now); err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf("Generated static site in %s/\n", outputDir)
 	fmt.Printf("  - index.html\n")
 	fmt.Printf("  - style.css\n")
 	fmt.Printf("  - books/ (%d book pages)\n", len(books))
+	if o.opds {
+		fmt.Printf("  - opds/ (OPDS catalog, %d feeds)\n", feeds)
+	}
 
 	return nil
 }
 
+// ReadingGoalProgress reports how far along a year's reading goal is, or nil
+// if the year has no goal.
+func ReadingGoalProgress(year int) (*GoalProgress, error) {
+	goal, err := db.GetGoal(year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reading goal: %w", err)
+	}
+	if goal == nil {
+		return nil, nil
+	}
+	booksFinished, err := db.GetBooksFinishedInYear(year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch books finished: %w", err)
+	}
+	percent := 0
+	if goal.Target > 0 {
+		percent = (booksFinished * 100) / goal.Target
+		if percent > 100 {
+			percent = 100
+		}
+	}
+	return &GoalProgress{
+		Target:  goal.Target,
+		Current: booksFinished,
+		Percent: percent,
+		Year:    year,
+	}, nil
+}
+
 func generateIndex(outputDir string, data SiteData) error {
-	tmpl, err := template.New("index").Funcs(templateFuncs()).Parse(indexTemplate)
+	tmpl, err := template.New("index").Funcs(TemplateFuncs()).Parse(indexTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to parse index template: %w", err)
 	}
@@ -143,7 +188,7 @@ func generateIndex(outputDir string, data SiteData) error {
 }
 
 func generateBookPage(booksDir string, book models.BookWithEntry, config models.SiteConfig, generatedAt string) error {
-	tmpl, err := template.New("book").Funcs(templateFuncs()).Parse(bookTemplate)
+	tmpl, err := template.New("book").Funcs(TemplateFuncs()).Parse(bookTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to parse book template: %w", err)
 	}
@@ -155,11 +200,53 @@ func generateBookPage(booksDir string, book models.BookWithEntry, config models.
 	}
 	defer f.Close()
 
-	return tmpl.Execute(f, BookPageData{Book: book, Config: config, GeneratedAt: generatedAt})
+	if cover := book.Book.CoverURL.String; book.Book.CoverURL.Valid && !strings.Contains(cover, "://") {
+		// Copied covers are relative to the site's root
+		book.Book.CoverURL.String = "../" + cover
+	}
+
+	quotes, err := db.GetQuotes(book.Book.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch quotes: %w", err)
+	}
+
+	return tmpl.Execute(f, BookPageData{Book: book, Quotes: quotes, Config: config, GeneratedAt: generatedAt})
+}
+
+// copyLocalCovers copies covers stored on this computer, such as a Calibre
+// library's, into the site's covers folder and points the books at the
+// copies. Covers that can't be found are dropped rather than left broken.
+func copyLocalCovers(outputDir string, books []models.BookWithEntry) error {
+	for i := range books {
+		cover := &books[i].Book.CoverURL
+		if !cover.Valid || !strings.HasPrefix(cover.String, "file://") {
+			continue
+		}
+		u, err := url.Parse(cover.String)
+		if err != nil {
+			cover.Valid = false
+			continue
+		}
+		data, err := os.ReadFile(filepath.FromSlash(u.Path))
+		if err != nil {
+			cover.Valid = false
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Join(outputDir, "covers"), 0755); err != nil {
+			return fmt.Errorf("failed to create covers directory: %w", err)
+		}
+		name := fmt.Sprintf("%d%s", books[i].Book.ID, strings.ToLower(filepath.Ext(u.Path)))
+		if err := os.WriteFile(filepath.Join(outputDir, "covers", name), data, 0644); err != nil {
+			return fmt.Errorf("failed to copy cover: %w", err)
+		}
+		cover.String = "covers/" + name
+	}
+	return nil
 }
 
 func generateCSS(outputDir string) error {
-	return os.WriteFile(filepath.Join(outputDir, "style.css"), []byte(cssStyles), 0644)
+	return os.WriteFile(filepath.Join(outputDir, "style.css"), []byte(Stylesheet), 0644)
 }
 
 // collectUniqueGenres extracts all unique genres from a list of books, sorted alphabetically.
@@ -193,20 +280,31 @@ func collectUniqueGenres(books []models.BookWithEntry) []string {
 	return genres
 }
 
-func templateFuncs() template.FuncMap {
+// TemplateFuncs are the helpers the site's templates use, such as stars and
+// statusClass. The web UI's templates use them too.
+func TemplateFuncs() template.FuncMap {
 	return template.FuncMap{
 		"statusClass": func(status models.BookStatus) string {
 			return strings.ReplaceAll(string(status), "-", "")
 		},
-		"stars": func(rating int64) string {
-			var s strings.Builder
-			for i := int64(0); i < rating; i++ {
-				s.WriteString("★")
+		"stars": stars,
+		"rating": func(scale models.RatingScale, rating float64) string {
+			if scale == models.RatingScaleTenPoint {
+				return scale.Format(rating)
 			}
-			for i := rating; i < 5; i++ {
-				s.WriteString("☆")
-			}
-			return s.String()
+			return stars(rating)
+		},
+		"ratingText": func(scale models.RatingScale, rating float64) string {
+			return scale.Format(rating)
+		},
+		"averageRating": func(scale models.RatingScale, average float64) string {
+			return fmt.Sprintf("%.1f", scale.Value(average))
+		},
+		"duration": func(minutes int64) string {
+			return models.FormatMinutes(int(minutes))
+		},
+		"hours": func(minutes int) string {
+			return fmt.Sprintf("%.1f", float64(minutes)/60)
 		},
 		"formatDate": func(t time.Time) string {
 			return t.Format("Jan 2, 2006")
@@ -271,6 +369,26 @@ func templateFuncs() template.FuncMap {
 	}
 }
 
+// stars renders a rating as five stars, using ½ for a half star.
+func stars(rating float64) string {
+	full := int(rating)
+	half := rating-float64(full) >= 0.5
+
+	var s strings.Builder
+	for i := 0; i < full; i++ {
+		s.WriteString("★")
+	}
+	empty := 5 - full
+	if half {
+		s.WriteString("½")
+		empty--
+	}
+	for i := 0; i < empty; i++ {
+		s.WriteString("☆")
+	}
+	return s.String()
+}
+
 const indexTemplate = `<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -282,6 +400,7 @@ const indexTemplate = `<!DOCTYPE html>
     <meta property="og:description" content="{{.Stats.TotalBooks}} books tracked, {{.Stats.Finished}} finished, {{.Stats.Reading}} currently reading">
     <meta property="og:type" content="website">
     {{if .Config.BaseURL}}<link rel="canonical" href="{{.Config.BaseURL}}">{{end}}
+    {{if .OPDS}}<link rel="alternate" type="application/atom+xml;profile=opds-catalog;kind=navigation" href="opds/catalog.xml" title="OPDS catalog">{{end}}
     <link rel="icon" href="data:image/svg+xml,<svg xmlns='http://www.w3.org/2000/svg' viewBox='0 0 100 100'><text y='.9em' font-size='90'>📚</text></svg>">
     <link rel="stylesheet" href="style.css">
 </head>
@@ -315,12 +434,28 @@ const indexTemplate = `<!DOCTYPE html>
                 <span class="stat-label">This Year</span>
             </div>
             {{end}}
+            {{if gt .Stats.MinutesListenedThisYear 0}}
+            <div class="stat-card">
+                <span class="stat-number">{{hours .Stats.MinutesListenedThisYear}}</span>
+                <span class="stat-label">Hours Listened</span>
+            </div>
+            {{end}}
             {{if gt .Stats.RatedBooksCount 0}}
             <div class="stat-card">
-                <span class="stat-number">{{printf "%.1f" .Stats.AverageRating}}</span>
+                <span class="stat-number">{{averageRating .Config.RatingScale .Stats.AverageRating}}</span>
                 <span class="stat-label">Avg Rating</span>
             </div>
             {{end}}
+            {{if gt .Stats.LongestStreak 0}}
+            <div class="stat-card">
+                <span class="stat-number">{{.Stats.CurrentStreak}}</span>
+                <span class="stat-label">Day Streak</span>
+            </div>
+            <div class="stat-card">
+                <span class="stat-number">{{.Stats.LongestStreak}}</span>
+                <span class="stat-label">Longest Streak</span>
+            </div>
+            {{end}}
         </section>
 
         {{if .Goal}}
@@ -348,6 +483,7 @@ const indexTemplate = `<!DOCTYPE html>
                         <button class="filter-btn" data-filter="reading">Reading</button>
                         <button class="filter-btn" data-filter="finished">Finished</button>
                         <button class="filter-btn" data-filter="wanttoread">Want to Read</button>
+                        <button class="filter-btn" data-filter="didnotfinish">Did Not Finish</button>
                     </div>
                     {{if .Genres}}
                     <div class="genre-filter">
@@ -378,8 +514,11 @@ const indexTemplate = `<!DOCTYPE html>
                         <h3><a href="books/{{.Book.ID}}.html">{{.Book.Title}}</a></h3>
                         <p class="author">{{.Book.Author}}</p>
                         <span class="status {{statusClass .ReadingEntry.Status}}">{{.ReadingEntry.Status}}</span>
+                        {{if .Book.Format.Valid}}
+                        <span class="format-badge {{.Book.Format.String}}">{{.Book.Format.String}}</span>
+                        {{end}}
                         {{if .ReadingEntry.Rating.Valid}}
-                        <span class="rating">{{stars .ReadingEntry.Rating.Int64}}</span>
+                        <span class="rating">{{rating $.Config.RatingScale .ReadingEntry.Rating.Float64}}</span>
                         {{end}}
                         {{if .Book.Genres.Valid}}
                         <div class="genres">
@@ -447,7 +586,7 @@ const bookTemplate = `<!DOCTYPE html>
     <title>{{.Book.Book.Title}} - {{.Config.Title}}</title>
     <meta name="description" content="{{.Book.Book.Title}} by {{.Book.Book.Author}} - {{.Book.ReadingEntry.Status}}">
     <meta property="og:title" content="{{.Book.Book.Title}} - {{.Config.Title}}">
-    <meta property="og:description" content="{{.Book.Book.Title}} by {{.Book.Book.Author}}{{if .Book.ReadingEntry.Rating.Valid}} - Rated {{.Book.ReadingEntry.Rating.Int64}}/5{{end}}">
+    <meta property="og:description" content="{{.Book.Book.Title}} by {{.Book.Book.Author}}{{if .Book.ReadingEntry.Rating.Valid}} - Rated {{ratingText .Config.RatingScale .Book.ReadingEntry.Rating.Float64}}{{end}}">
     <meta property="og:type" content="book">
     {{if .Book.Book.CoverURL.Valid}}<meta property="og:image" content="{{.Book.Book.CoverURL.String}}">{{end}}
     {{if .Config.BaseURL}}<link rel="canonical" href="{{.Config.BaseURL}}/books/{{.Book.Book.ID}}.html">{{end}}
@@ -479,7 +618,12 @@ const bookTemplate = `<!DOCTYPE html>
 
                         {{if .Book.ReadingEntry.Rating.Valid}}
                         <dt>Rating</dt>
-                        <dd class="rating">{{stars .Book.ReadingEntry.Rating.Int64}}</dd>
+                        <dd class="rating">{{rating .Config.RatingScale .Book.ReadingEntry.Rating.Float64}}</dd>
+                        {{end}}
+
+                        {{with .Book.Book.SeriesLabel}}
+                        <dt>Series</dt>
+                        <dd>{{.}}</dd>
                         {{end}}
 
                         {{if .Book.Book.Pages.Valid}}
@@ -492,6 +636,31 @@ const bookTemplate = `<!DOCTYPE html>
                         <dd>{{.Book.Book.ISBN.String}}</dd>
                         {{end}}
 
+                        {{if .Book.Book.Format.Valid}}
+                        <dt>Format</dt>
+                        <dd class="format">{{.Book.Book.Format.String}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.DurationMinutes.Valid}}
+                        <dt>Duration</dt>
+                        <dd>{{duration .Book.Book.DurationMinutes.Int64}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.Publisher.Valid}}
+                        <dt>Publisher</dt>
+                        <dd>{{.Book.Book.Publisher.String}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.PublishDate.Valid}}
+                        <dt>Published</dt>
+                        <dd>{{.Book.Book.PublishDate.String}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.Language.Valid}}
+                        <dt>Language</dt>
+                        <dd>{{.Book.Book.Language.String}}</dd>
+                        {{end}}
+
                         {{if .Book.ReadingEntry.StartedAt.Valid}}
                         <dt>Started</dt>
                         <dd>{{formatDate .Book.ReadingEntry.StartedAt.Time}}</dd>
@@ -517,7 +686,9 @@ const bookTemplate = `<!DOCTYPE html>
                         {{end}}
                     </dl>
 
-                    {{if .Book.Book.OpenLibraryKey.Valid}}
+                    {{if .Book.Book.EditionKey.Valid}}
+                    <a href="{{openLibraryURL .Book.Book.EditionKey.String}}" class="external-link" target="_blank" rel="noopener">View on Open Library →</a>
+                    {{else if .Book.Book.OpenLibraryKey.Valid}}
                     <a href="{{openLibraryURL .Book.Book.OpenLibraryKey.String}}" class="external-link" target="_blank" rel="noopener">View on Open Library →</a>
                     {{end}}
                 </div>
@@ -537,6 +708,19 @@ const bookTemplate = `<!DOCTYPE html>
             </section>
             {{end}}
 
+            {{if .Quotes}}
+            <section class="quotes">
+                <h3>Quotes</h3>
+                {{range .Quotes}}
+                <figure class="quote">
+                    <blockquote>{{nl2br .Text}}</blockquote>
+                    {{if or .Page.Valid .Location.Valid}}<figcaption>{{if .Page.Valid}}Page {{.Page.Int64}}{{end}}{{if and .Page.Valid .Location.Valid}}, {{end}}{{if .Location.Valid}}{{if .Page.Valid}}location{{else}}Location{{end}} {{.Location.String}}{{end}}</figcaption>{{end}}
+                    {{if .Note.Valid}}<p class="quote-note">{{.Note.String}}</p>{{end}}
+                </figure>
+                {{end}}
+            </section>
+            {{end}}
+
             <a href="../index.html" class="back-link">← Back to all books</a>
         </article>
     </main>
@@ -547,7 +731,8 @@ const bookTemplate = `<!DOCTYPE html>
 </body>
 </html>`
 
-const cssStyles = `:root {
+// Stylesheet is the site's style.css, which the web UI also serves.
+const Stylesheet = `:root {
     --bg-primary: #f5f5f5;
     --bg-card: white;
     --bg-header: #2c3e50;
@@ -591,6 +776,16 @@ const cssStyles = `:root {
         color: #69db7c;
     }
 
+    .status.didnotfinish {
+        background: #3d1a1a;
+        color: #ff8787;
+    }
+
+    .format-badge.audiobook {
+        background: #2e1a3d;
+        color: #da77f2;
+    }
+
     .review-text {
         background: #1a1a2e;
     }
@@ -915,6 +1110,27 @@ main {
     color: #27ae60;
 }
 
+.status.didnotfinish {
+    background: #fdecea;
+    color: #c0392b;
+}
+
+.format-badge {
+    display: inline-block;
+    padding: 0.2rem 0.45rem;
+    margin-left: 0.25rem;
+    border: 1px solid var(--border);
+    border-radius: 4px;
+    color: var(--text-secondary);
+    font-size: 0.7rem;
+    text-transform: uppercase;
+}
+
+.format-badge.audiobook {
+    background: #f5ebfb;
+    color: #8e44ad;
+}
+
 .rating {
     color: #f39c12;
     font-size: 0.9rem;
@@ -1029,13 +1245,13 @@ main {
     text-decoration: underline;
 }
 
-.description, .review {
+.description, .review, .quotes {
     margin-top: 2rem;
     padding-top: 2rem;
     border-top: 1px solid var(--border);
 }
 
-.description h3, .review h3 {
+.description h3, .review h3, .quotes h3 {
     margin-bottom: 1rem;
     color: var(--text-primary);
 }
@@ -1052,6 +1268,30 @@ main {
     border-left: 4px solid var(--accent);
 }
 
+.quote {
+    margin-bottom: 1.5rem;
+}
+
+.quote blockquote {
+    font-style: italic;
+    line-height: 1.8;
+    padding-left: 1rem;
+    border-left: 4px solid var(--border);
+}
+
+.quote figcaption {
+    margin-top: 0.25rem;
+    padding-left: 1rem;
+    font-size: 0.8rem;
+    color: var(--text-secondary);
+}
+
+.quote-note {
+    margin-top: 0.5rem;
+    padding-left: 1rem;
+    color: var(--text-secondary);
+}
+
 .back-link {
     display: inline-block;
     margin-top: 2rem;
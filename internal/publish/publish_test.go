@@ -4,6 +4,7 @@ import (
 	"bookshelf/internal/db"
 	"bookshelf/internal/models"
 	"bookshelf/internal/testutil"
+	"database/sql"
 	"os"
 	"path/filepath"
 	"strings"
@@ -49,7 +50,7 @@ func TestGenerateWithBooks(t *testing.T) {
 	// Add a book
 	id, _ := db.AddBook("Test Book", "Test Author", nil, nil, nil, nil, nil, nil)
 	db.CreateReadingEntry(id, models.StatusFinished)
-	db.UpdateRating(id, 5)
+	db.UpdateRating(id, 4.5)
 	db.UpdateReview(id, "Great book!")
 
 	outputDir, err := os.MkdirTemp("", "bookshelf-output-*")
@@ -80,10 +81,137 @@ func TestGenerateWithBooks(t *testing.T) {
 	if !strings.Contains(string(bookContent), "Great book!") {
 		t.Error("book page does not contain review")
 	}
+	if !strings.Contains(string(bookContent), "★★★★½") {
+		t.Error("book page does not show half-star rating")
+	}
+}
+
+func TestGenerateBookPageShowsEdition(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	book := models.Book{
+		Title:          "Dune",
+		Author:         "Frank Herbert",
+		OpenLibraryKey: sql.NullString{String: "/works/OL893415W", Valid: true},
+		EditionKey:     sql.NullString{String: "/books/OL26242482M", Valid: true},
+		Publisher:      sql.NullString{String: "Ace", Valid: true},
+		PublishDate:    sql.NullString{String: "2005", Valid: true},
+		Language:       sql.NullString{String: "eng", Valid: true},
+		Format:         sql.NullString{String: "paperback", Valid: true},
+	}
+	id, _ := db.InsertBook(&book)
+	db.CreateReadingEntry(id, models.StatusReading)
+
+	outputDir := t.TempDir()
+	if err := Generate(outputDir); err != nil {
+		t.Fatalf("failed to generate site: %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(outputDir, "books", "1.html"))
+	for _, expected := range []string{"paperback", "Ace", "2005", "eng", "https://openlibrary.org/books/OL26242482M"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("book page does not contain %q", expected)
+		}
+	}
+}
+
+func TestGenerateBookPageShowsQuotes(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	id, _ := db.InsertBook(&models.Book{Title: "Dune", Author: "Frank Herbert"})
+	db.CreateReadingEntry(id, models.StatusReading)
+	db.AddQuote(&models.Quote{
+		BookID: id,
+		Text:   "I must not fear.\nFear is the mind-killer.",
+		Page:   sql.NullInt64{Int64: 8, Valid: true},
+		Note:   sql.NullString{String: "The litany <3", Valid: true},
+	})
+
+	outputDir := t.TempDir()
+	if err := Generate(outputDir); err != nil {
+		t.Fatalf("failed to generate site: %v", err)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(outputDir, "books", "1.html"))
+	for _, expected := range []string{`class="quotes"`, "I must not fear.<br>Fear is the mind-killer.", "Page 8", "The litany &lt;3"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("book page does not contain %q", expected)
+		}
+	}
+}
+
+func TestGenerateCopiesLocalCovers(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	cover := filepath.Join(t.TempDir(), "cover.jpg")
+	os.WriteFile(cover, []byte("jpeg"), 0644)
+
+	id, _ := db.InsertBook(&models.Book{
+		Title:       "Dune",
+		Author:      "Frank Herbert",
+		CoverURL:    sql.NullString{String: "file://" + filepath.ToSlash(cover), Valid: true},
+		Series:      sql.NullString{String: "Dune", Valid: true},
+		SeriesIndex: sql.NullFloat64{Float64: 1, Valid: true},
+	})
+	db.CreateReadingEntry(id, models.StatusWantToRead)
+	missing, _ := db.InsertBook(&models.Book{Title: "Emma", Author: "Jane Austen", CoverURL: sql.NullString{String: "file:///nowhere/cover.jpg", Valid: true}})
+	db.CreateReadingEntry(missing, models.StatusWantToRead)
+
+	outputDir := t.TempDir()
+	if err := Generate(outputDir); err != nil {
+		t.Fatalf("failed to generate site: %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(outputDir, "covers", "1.jpg")); err != nil || string(data) != "jpeg" {
+		t.Errorf("expected the cover to be copied, got %q, %v", data, err)
+	}
+	index, _ := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if !strings.Contains(string(index), `src="covers/1.jpg"`) || strings.Contains(string(index), "file://") {
+		t.Errorf("expected the index to use the copied cover")
+	}
+	page, _ := os.ReadFile(filepath.Join(outputDir, "books", "1.html"))
+	if !strings.Contains(string(page), `src="../covers/1.jpg"`) || !strings.Contains(string(page), "Dune #1") {
+		t.Errorf("expected the book page to use the copied cover and show the series")
+	}
+}
+
+func TestGenerateShowsAudiobooks(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	book := models.Book{
+		Title:           "Dune",
+		Author:          "Frank Herbert",
+		Format:          sql.NullString{String: "audiobook", Valid: true},
+		DurationMinutes: sql.NullInt64{Int64: 1265, Valid: true},
+	}
+	id, _ := db.InsertBook(&book)
+	db.CreateReadingEntry(id, models.StatusReading)
+	db.UpdateStatus(id, models.StatusFinished)
+
+	outputDir := t.TempDir()
+	if err := Generate(outputDir); err != nil {
+		t.Fatalf("failed to generate site: %v", err)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	for _, expected := range []string{`<span class="format-badge audiobook">audiobook</span>`, "21.1", "Hours Listened"} {
+		if !strings.Contains(string(index), expected) {
+			t.Errorf("index does not contain %q", expected)
+		}
+	}
+
+	page, _ := os.ReadFile(filepath.Join(outputDir, "books", "1.html"))
+	if !strings.Contains(string(page), "21h 5m") {
+		t.Error("book page does not show the duration")
+	}
 }
 
 func TestTemplateFuncsStatusClass(t *testing.T) {
-	funcs := templateFuncs()
+	funcs := TemplateFuncs()
 	statusClassFn := funcs["statusClass"].(func(models.BookStatus) string)
 
 	tests := []struct {
@@ -104,29 +232,43 @@ func TestTemplateFuncsStatusClass(t *testing.T) {
 }
 
 func TestTemplateFuncsStars(t *testing.T) {
-	funcs := templateFuncs()
-	starsFn := funcs["stars"].(func(int64) string)
+	funcs := TemplateFuncs()
+	starsFn := funcs["stars"].(func(float64) string)
 
 	tests := []struct {
-		rating   int64
+		rating   float64
 		expected string
 	}{
 		{0, "☆☆☆☆☆"},
+		{0.5, "½☆☆☆☆"},
 		{1, "★☆☆☆☆"},
 		{3, "★★★☆☆"},
+		{3.5, "★★★½☆"},
 		{5, "★★★★★"},
 	}
 
 	for _, tt := range tests {
 		result := starsFn(tt.rating)
 		if result != tt.expected {
-			t.Errorf("stars(%d) = %s, expected %s", tt.rating, result, tt.expected)
+			t.Errorf("stars(%.1f) = %s, expected %s", tt.rating, result, tt.expected)
 		}
 	}
 }
 
+func TestTemplateFuncsRating(t *testing.T) {
+	funcs := TemplateFuncs()
+	ratingFn := funcs["rating"].(func(models.RatingScale, float64) string)
+
+	if got := ratingFn(models.RatingScaleHalfStars, 4.5); got != "★★★★½" {
+		t.Errorf("expected half-star rendering, got %s", got)
+	}
+	if got := ratingFn(models.RatingScaleTenPoint, 4.5); got != "9/10" {
+		t.Errorf("expected '9/10', got %s", got)
+	}
+}
+
 func TestTemplateFuncsTruncate(t *testing.T) {
-	funcs := templateFuncs()
+	funcs := TemplateFuncs()
 	truncateFn := funcs["truncate"].(func(string, int) string)
 
 	tests := []struct {
@@ -157,3 +299,94 @@ func TestGenerateInvalidOutputDir(t *testing.T) {
 		t.Error("expected error for invalid output directory")
 	}
 }
+
+func TestGenerateOPDS(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	genres := `["Science Fiction","Classics"]`
+	dune, _ := db.InsertBook(&models.Book{
+		Title:       "Dune",
+		Author:      "Frank Herbert",
+		ISBN:        sql.NullString{String: "9780441013593", Valid: true},
+		CoverURL:    sql.NullString{String: "https://covers.openlibrary.org/b/id/1-L.jpg", Valid: true},
+		Description: sql.NullString{String: "Spice & sand.", Valid: true},
+		Genres:      sql.NullString{String: genres, Valid: true},
+	})
+	db.CreateReadingEntry(dune, models.StatusReading)
+	omens, _ := db.InsertBook(&models.Book{Title: "Good Omens", Author: "Neil Gaiman, Terry Pratchett"})
+	db.CreateReadingEntry(omens, models.StatusFinished)
+
+	outputDir := t.TempDir()
+	if err := Generate(outputDir, WithOPDS()); err != nil {
+		t.Fatalf("failed to generate site: %v", err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(outputDir, "opds", name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	catalog := read("catalog.xml")
+	for _, expected := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/terms/" xmlns:opds="http://opds-spec.org/2010/catalog">`,
+		`<link rel="subsection" href="reading.xml" type="application/atom+xml;profile=opds-catalog;kind=acquisition">`,
+		`href="want-to-read.xml"`, `href="finished.xml"`,
+		`<link rel="subsection" href="genres.xml" type="application/atom+xml;profile=opds-catalog;kind=navigation">`,
+		`href="authors.xml"`,
+	} {
+		if !strings.Contains(catalog, expected) {
+			t.Errorf("catalog does not contain %q:\n%s", expected, catalog)
+		}
+	}
+
+	reading := read("reading.xml")
+	for _, expected := range []string{
+		"<title>Dune</title>",
+		"<name>Frank Herbert</name>",
+		"<dc:identifier>urn:isbn:9780441013593</dc:identifier>",
+		`<summary type="text">Spice &amp; sand.</summary>`,
+		`<link rel="http://opds-spec.org/acquisition" href="../books/1.html" type="text/html"`,
+		`<link rel="http://opds-spec.org/image" href="https://covers.openlibrary.org/b/id/1-L.jpg" type="image/jpeg">`,
+		`<category term="Science Fiction" label="Science Fiction"></category>`,
+	} {
+		if !strings.Contains(reading, expected) {
+			t.Errorf("reading feed does not contain %q:\n%s", expected, reading)
+		}
+	}
+	if strings.Contains(reading, "Good Omens") {
+		t.Error("reading feed contains a finished book")
+	}
+
+	if genres := read("genres.xml"); !strings.Contains(genres, `href="genre-science-fiction.xml"`) {
+		t.Errorf("genres feed does not link to the genre:\n%s", genres)
+	}
+	if !strings.Contains(read("genre-science-fiction.xml"), "<title>Dune</title>") {
+		t.Error("genre feed does not contain its book")
+	}
+	if authors := read("authors.xml"); !strings.Contains(authors, `href="author-neil-gaiman.xml"`) || !strings.Contains(authors, `href="author-terry-pratchett.xml"`) {
+		t.Errorf("expected a feed for each author:\n%s", authors)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if !strings.Contains(string(index), `href="opds/catalog.xml"`) {
+		t.Error("index does not link to the catalog")
+	}
+}
+
+func TestGenerateWithoutOPDS(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	outputDir := t.TempDir()
+	if err := Generate(outputDir); err != nil {
+		t.Fatalf("failed to generate site: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "opds")); !os.IsNotExist(err) {
+		t.Error("expected no OPDS catalog without WithOPDS")
+	}
+}
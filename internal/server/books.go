@@ -0,0 +1,428 @@
+package server
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// bookFields are the parts of a book that can be created and patched. Blank
+// strings and zeros are stored as NULL, so patching a field to "" or 0
+// clears it.
+type bookFields struct {
+	Title           string   `json:"title"`
+	Author          string   `json:"author"`
+	ISBN            string   `json:"isbn"`
+	Pages           int64    `json:"pages"`
+	CoverURL        string   `json:"cover_url"`
+	Description     string   `json:"description"`
+	OpenLibraryKey  string   `json:"open_library_key"`
+	Genres          []string `json:"genres"`
+	Tags            []string `json:"tags"`
+	Series          string   `json:"series"`
+	SeriesIndex     float64  `json:"series_index"`
+	Format          string   `json:"format"`
+	DurationMinutes int64    `json:"duration_minutes"`
+	Publisher       string   `json:"publisher"`
+	PublishDate     string   `json:"publish_date"`
+	Language        string   `json:"language"`
+	EditionKey      string   `json:"edition_key"`
+}
+
+// bookJSON is a book as the API returns it. Rating is in stars.
+type bookJSON struct {
+	ID int64 `json:"id"`
+	bookFields
+	Status         models.BookStatus `json:"status"`
+	StartedAt      *time.Time        `json:"started_at"`
+	FinishedAt     *time.Time        `json:"finished_at"`
+	Rating         *float64          `json:"rating"`
+	Review         *string           `json:"review"`
+	CurrentPage    *int64            `json:"current_page"`
+	CurrentMinutes *int64            `json:"current_minutes"`
+	CalibreID      *int64            `json:"calibre_id"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+func fieldsFromBook(b models.Book) bookFields {
+	f := bookFields{
+		Title:           b.Title,
+		Author:          b.Author,
+		ISBN:            b.ISBN.String,
+		Pages:           b.Pages.Int64,
+		CoverURL:        b.CoverURL.String,
+		Description:     b.Description.String,
+		OpenLibraryKey:  b.OpenLibraryKey.String,
+		Tags:            b.TagList(),
+		Series:          b.Series.String,
+		SeriesIndex:     b.SeriesIndex.Float64,
+		Format:          b.Format.String,
+		DurationMinutes: b.DurationMinutes.Int64,
+		Publisher:       b.Publisher.String,
+		PublishDate:     b.PublishDate.String,
+		Language:        b.Language.String,
+		EditionKey:      b.EditionKey.String,
+	}
+	if b.Genres.Valid {
+		json.Unmarshal([]byte(b.Genres.String), &f.Genres)
+	}
+	if f.Genres == nil {
+		f.Genres = []string{}
+	}
+	if f.Tags == nil {
+		f.Tags = []string{}
+	}
+	return f
+}
+
+func toBookJSON(b models.BookWithEntry) bookJSON {
+	out := bookJSON{
+		ID:         b.Book.ID,
+		bookFields: fieldsFromBook(b.Book),
+		Status:     b.ReadingEntry.Status,
+		CreatedAt:  b.Book.CreatedAt,
+		UpdatedAt:  b.ReadingEntry.UpdatedAt,
+	}
+	if b.ReadingEntry.StartedAt.Valid {
+		out.StartedAt = &b.ReadingEntry.StartedAt.Time
+	}
+	if b.ReadingEntry.FinishedAt.Valid {
+		out.FinishedAt = &b.ReadingEntry.FinishedAt.Time
+	}
+	if b.ReadingEntry.Rating.Valid {
+		out.Rating = &b.ReadingEntry.Rating.Float64
+	}
+	if b.ReadingEntry.Review.Valid && b.ReadingEntry.Review.String != "" {
+		out.Review = &b.ReadingEntry.Review.String
+	}
+	if b.ReadingEntry.CurrentPage.Valid {
+		out.CurrentPage = &b.ReadingEntry.CurrentPage.Int64
+	}
+	if b.ReadingEntry.CurrentMinutes.Valid {
+		out.CurrentMinutes = &b.ReadingEntry.CurrentMinutes.Int64
+	}
+	if b.Book.CalibreID.Valid {
+		out.CalibreID = &b.Book.CalibreID.Int64
+	}
+	return out
+}
+
+// validate trims the fields and checks them as the edit form does.
+func (f *bookFields) validate() error {
+	f.Title = strings.TrimSpace(f.Title)
+	f.Author = strings.TrimSpace(f.Author)
+	if f.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if f.Author == "" {
+		return fmt.Errorf("author is required")
+	}
+	if f.Pages < 0 {
+		return fmt.Errorf("pages must be positive")
+	}
+	if f.SeriesIndex < 0 {
+		return fmt.Errorf("series_index must be positive")
+	}
+	if f.DurationMinutes < 0 {
+		return fmt.Errorf("duration_minutes must be positive")
+	}
+	f.Series = strings.TrimSpace(f.Series)
+	f.Format = strings.ToLower(strings.TrimSpace(f.Format))
+	if f.Format != "" && !models.BookFormat(f.Format).IsValid() {
+		names := make([]string, len(models.BookFormats))
+		for i, format := range models.BookFormats {
+			names[i] = string(format)
+		}
+		return fmt.Errorf("invalid format: %s (use: %s)", f.Format, strings.Join(names, ", "))
+	}
+	f.Genres = trimAll(f.Genres)
+	f.Tags = trimAll(f.Tags)
+	return nil
+}
+
+// trimAll trims each string, dropping blank ones.
+func trimAll(values []string) []string {
+	var trimmed []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
+
+// applyTo copies the fields onto book, turning blank values into NULLs.
+func (f bookFields) applyTo(book *models.Book) {
+	book.Title = f.Title
+	book.Author = f.Author
+	book.ISBN = models.NullString(f.ISBN)
+	book.Pages = sql.NullInt64{Int64: f.Pages, Valid: f.Pages > 0}
+	book.CoverURL = models.NullString(f.CoverURL)
+	book.Description = models.NullString(f.Description)
+	book.OpenLibraryKey = models.NullString(f.OpenLibraryKey)
+	book.Series = models.NullString(f.Series)
+	book.SeriesIndex = sql.NullFloat64{Float64: f.SeriesIndex, Valid: f.Series != "" && f.SeriesIndex > 0}
+	book.Format = models.NullString(f.Format)
+	book.DurationMinutes = sql.NullInt64{Int64: f.DurationMinutes, Valid: f.DurationMinutes > 0}
+	book.Publisher = models.NullString(f.Publisher)
+	book.PublishDate = models.NullString(f.PublishDate)
+	book.Language = models.NullString(f.Language)
+	book.EditionKey = models.NullString(f.EditionKey)
+
+	book.Genres = sql.NullString{}
+	if len(f.Genres) > 0 {
+		if jsonBytes, err := json.Marshal(f.Genres); err == nil {
+			book.Genres = sql.NullString{String: string(jsonBytes), Valid: true}
+		}
+	}
+	book.SetTags(f.Tags)
+}
+
+// lookupBook loads the book named by the {id} path parameter, writing a 400
+// or 404 if there isn't one.
+func lookupBook(w http.ResponseWriter, r *http.Request) (*models.BookWithEntry, bool) {
+	id, ok := pathInt(w, r, "id")
+	if !ok {
+		return nil, false
+	}
+	book, err := db.GetBook(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "book with ID %d not found", id)
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get book: %v", err)
+		return nil, false
+	}
+	return book, true
+}
+
+// writeBook responds with the book's current state.
+func writeBook(w http.ResponseWriter, status int, id int64) {
+	book, err := db.GetBook(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get book: %v", err)
+		return
+	}
+	writeJSON(w, status, toBookJSON(*book))
+}
+
+func (s *Server) handleListBooks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts, err := models.ParseListOptions(q.Get("status"), q.Get("search"), q.Get("sort"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	books, err := db.ListBooks(opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list books: %v", err)
+		return
+	}
+
+	out := make([]bookJSON, len(books))
+	for i, b := range books {
+		out[i] = toBookJSON(b)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleGetBook(w http.ResponseWriter, r *http.Request) {
+	book, ok := lookupBook(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toBookJSON(*book))
+}
+
+// handleCreateBook adds a book from its details, on the want-to-read shelf
+// unless the body gives another status.
+func (s *Server) handleCreateBook(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		bookFields
+		Status models.BookStatus `json:"status"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if err := input.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	if input.Status == "" {
+		input.Status = models.StatusWantToRead
+	}
+	if !input.Status.IsValid() {
+		writeError(w, http.StatusBadRequest, "invalid status: %s (use: want-to-read, reading, finished, did-not-finish)", input.Status)
+		return
+	}
+
+	var book models.Book
+	input.applyTo(&book)
+	id, err := db.InsertBook(&book)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to add book: %v", err)
+		return
+	}
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create reading entry: %v", err)
+		return
+	}
+	if input.Status != models.StatusWantToRead {
+		now := time.Now()
+		if err := db.UpdateStatusOn(id, input.Status, &now); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to update status: %v", err)
+			return
+		}
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/books/%d", id))
+	writeBook(w, http.StatusCreated, id)
+}
+
+// handlePatchBook changes the fields given in the body, leaving the others.
+func (s *Server) handlePatchBook(w http.ResponseWriter, r *http.Request) {
+	book, ok := lookupBook(w, r)
+	if !ok {
+		return
+	}
+
+	fields := fieldsFromBook(book.Book)
+	if !readJSON(w, r, &fields) {
+		return
+	}
+	if err := fields.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	fields.applyTo(&book.Book)
+	if err := db.UpdateBook(&book.Book); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update book: %v", err)
+		return
+	}
+	writeBook(w, http.StatusOK, book.Book.ID)
+}
+
+func (s *Server) handleDeleteBook(w http.ResponseWriter, r *http.Request) {
+	book, ok := lookupBook(w, r)
+	if !ok {
+		return
+	}
+	if err := db.DeleteBook(book.Book.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to remove book: %v", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetStatus moves a book to another shelf. Starting and finishing
+// record today as the start or finish date, or the given date, exact or
+// fuzzy as on the command line; no_date leaves the date as it was.
+func (s *Server) handleSetStatus(w http.ResponseWriter, r *http.Request) {
+	book, ok := lookupBook(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Status models.BookStatus `json:"status"`
+		Date   string            `json:"date"`
+		NoDate bool              `json:"no_date"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if !input.Status.IsValid() {
+		writeError(w, http.StatusBadRequest, "invalid status: %s (use: want-to-read, reading, finished, did-not-finish)", input.Status)
+		return
+	}
+	datesStatus := input.Status == models.StatusReading || input.Status == models.StatusFinished
+	if input.Date != "" && input.NoDate {
+		writeError(w, http.StatusBadRequest, "date and no_date cannot be used together")
+		return
+	}
+	if input.Date != "" && !datesStatus {
+		writeError(w, http.StatusBadRequest, "date can only be given when starting or finishing a book")
+		return
+	}
+
+	var date *time.Time
+	if input.Date != "" {
+		parsed, err := models.ParseReadingDate(input.Date, time.Now())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		if err := book.ReadingEntry.CheckStatusDate(input.Status, parsed); err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		date = &parsed
+	} else if !input.NoDate && datesStatus {
+		now := time.Now()
+		date = &now
+	}
+
+	if err := db.UpdateStatusOn(book.Book.ID, input.Status, date); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update status: %v", err)
+		return
+	}
+	writeBook(w, http.StatusOK, book.Book.ID)
+}
+
+// handleSetRating rates a book in stars, from 0.5 to 5 in half steps,
+// whatever rating.scale is set to.
+func (s *Server) handleSetRating(w http.ResponseWriter, r *http.Request) {
+	book, ok := lookupBook(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Rating float64 `json:"rating"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if input.Rating < 0.5 || input.Rating > 5 || input.Rating*2 != math.Trunc(input.Rating*2) {
+		writeError(w, http.StatusBadRequest, "rating must be between 0.5 and 5 stars in half steps")
+		return
+	}
+
+	if err := db.UpdateRating(book.Book.ID, input.Rating); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update rating: %v", err)
+		return
+	}
+	writeBook(w, http.StatusOK, book.Book.ID)
+}
+
+// handleSetReview replaces a book's review; an empty one clears it.
+func (s *Server) handleSetReview(w http.ResponseWriter, r *http.Request) {
+	book, ok := lookupBook(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Review string `json:"review"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+
+	if err := db.UpdateReview(book.Book.ID, strings.TrimSpace(input.Review)); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save review: %v", err)
+		return
+	}
+	writeBook(w, http.StatusOK, book.Book.ID)
+}
@@ -0,0 +1,172 @@
+// Package server is bookshelf's JSON API, served by 'bookshelf api serve'.
+// It works on the same database as the CLI and checks changes with the same
+// code: dates are parsed and checked by models.ParseReadingDate and
+// CheckReadingDates, ratings are stars from 0.5 to 5, and so on. The routes
+// are described by the OpenAPI document at /openapi.json.
+package server
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TokenConfigKey is the site_config key holding the API's bearer token.
+const TokenConfigKey = "api.token"
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithToken requires requests to carry "Authorization: Bearer <token>". The
+// OpenAPI document is served without it. Without a token, the API only
+// answers requests addressed to localhost, so web pages can't reach it
+// through DNS rebinding.
+func WithToken(token string) Option {
+	return func(s *Server) { s.token = token }
+}
+
+// WithConfigValidator checks config keys and values before they're set.
+// Without one, any key is accepted.
+func WithConfigValidator(validate func(key, value string) error) Option {
+	return func(s *Server) { s.validateConfig = validate }
+}
+
+// Server handles API requests. Create one with New.
+type Server struct {
+	token          string
+	validateConfig func(key, value string) error
+	mux            *http.ServeMux
+}
+
+// New creates a Server with its routes.
+func New(opts ...Option) *Server {
+	s := &Server{mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+
+	s.mux.HandleFunc("GET /books", s.handleListBooks)
+	s.mux.HandleFunc("POST /books", s.handleCreateBook)
+	s.mux.HandleFunc("GET /books/{id}", s.handleGetBook)
+	s.mux.HandleFunc("PATCH /books/{id}", s.handlePatchBook)
+	s.mux.HandleFunc("DELETE /books/{id}", s.handleDeleteBook)
+	s.mux.HandleFunc("PUT /books/{id}/status", s.handleSetStatus)
+	s.mux.HandleFunc("PUT /books/{id}/rating", s.handleSetRating)
+	s.mux.HandleFunc("PUT /books/{id}/review", s.handleSetReview)
+
+	s.mux.HandleFunc("GET /goals", s.handleListGoals)
+	s.mux.HandleFunc("GET /goals/{year}", s.handleGetGoal)
+	s.mux.HandleFunc("PUT /goals/{year}", s.handleSetGoal)
+	s.mux.HandleFunc("DELETE /goals/{year}", s.handleClearGoal)
+
+	s.mux.HandleFunc("GET /stats", s.handleStats)
+
+	s.mux.HandleFunc("GET /config", s.handleListConfig)
+	s.mux.HandleFunc("GET /config/{key}", s.handleGetConfig)
+	s.mux.HandleFunc("PUT /config/{key}", s.handleSetConfig)
+	s.mux.HandleFunc("DELETE /config/{key}", s.handleUnsetConfig)
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+	})
+	return s
+}
+
+// ServeHTTP checks the bearer token, or without one the Host, and routes the
+// request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token == "" && !IsLoopbackHost(r.Host) {
+		writeError(w, http.StatusMisdirectedRequest, "without api.token the API only answers requests to localhost")
+		return
+	}
+	if s.token != "" && r.URL.Path != "/openapi.json" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="bookshelf"`)
+		writeError(w, http.StatusUnauthorized, "missing or wrong bearer token")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.token)) == 1
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// writeJSON sends v as the response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError sends {"error": "..."}.
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// readJSON decodes the request body into v, rejecting fields v doesn't have
+// so typos aren't silently ignored. The body must be sent as
+// application/json, which browsers can't do cross-origin without asking, so
+// other web pages can't post to the API.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "request body is empty")
+		} else {
+			writeError(w, http.StatusBadRequest, "invalid JSON: %v", err)
+		}
+		return false
+	}
+	return true
+}
+
+// pathInt parses an integer path parameter such as {id}.
+func pathInt(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	value := r.PathValue(name)
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid %s: %s", name, value)
+		return 0, false
+	}
+	return n, true
+}
+
+// IsLoopbackHost reports whether host, a Host header or listen address with
+// or without a port, names this machine.
+func IsLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bookshelf/internal/testutil"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// do sends a request to h and decodes the JSON response into out, if given.
+func do(t *testing.T, h http.Handler, method, path, body string, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = "localhost:8080"
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: invalid JSON %q: %v", method, path, rec.Body.String(), err)
+		}
+	}
+	return rec
+}
+
+func TestBooks(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New()
+
+	var created bookJSON
+	rec := do(t, h, "POST", "/books", `{"title": "Dune", "author": "Frank Herbert", "pages": 412, "genres": ["Science Fiction"], "series": "Dune", "series_index": 1}`, &created)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d: %s", rec.Code, rec.Body)
+	}
+	if loc := rec.Header().Get("Location"); loc != fmt.Sprintf("/books/%d", created.ID) {
+		t.Errorf("Location = %q", loc)
+	}
+	if created.Status != models.StatusWantToRead || created.Pages != 412 || created.Series != "Dune" {
+		t.Errorf("created = %+v", created)
+	}
+	do(t, h, "POST", "/books", `{"title": "Emma", "author": "Jane Austen", "status": "finished"}`, nil)
+
+	var books []bookJSON
+	do(t, h, "GET", "/books?sort=title", "", &books)
+	if len(books) != 2 || books[0].Title != "Dune" || books[1].Title != "Emma" {
+		t.Fatalf("list = %+v", books)
+	}
+	if books[1].Status != models.StatusFinished || books[1].FinishedAt == nil {
+		t.Errorf("Emma should be finished today, got %+v", books[1])
+	}
+
+	do(t, h, "GET", "/books?status=finished", "", &books)
+	if len(books) != 1 || books[0].Title != "Emma" {
+		t.Errorf("status filter = %+v", books)
+	}
+	do(t, h, "GET", "/books?search=herbert", "", &books)
+	if len(books) != 1 || books[0].Title != "Dune" {
+		t.Errorf("search = %+v", books)
+	}
+
+	path := fmt.Sprintf("/books/%d", created.ID)
+	var patched bookJSON
+	rec = do(t, h, "PATCH", path, `{"pages": 0, "tags": ["favourites"]}`, &patched)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: got %d: %s", rec.Code, rec.Body)
+	}
+	if patched.Pages != 0 || patched.Title != "Dune" || len(patched.Tags) != 1 || len(patched.Genres) != 1 {
+		t.Errorf("patched = %+v", patched)
+	}
+
+	if rec := do(t, h, "DELETE", path, "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(t, h, "GET", path, "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("get after delete: got %d", rec.Code)
+	}
+}
+
+func TestBookErrors(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New()
+	id, _ := db.AddBook("Dune", "Frank Herbert", nil, nil, nil, nil, nil, nil)
+	db.CreateReadingEntry(id, models.StatusWantToRead)
+
+	tests := []struct {
+		method, path, body string
+		code               int
+		errMsg             string
+	}{
+		{"GET", "/books?status=bogus", "", 400, "invalid status: bogus"},
+		{"GET", "/books?sort=bogus", "", 400, "invalid sort option: bogus"},
+		{"GET", "/books/abc", "", 400, "invalid id: abc"},
+		{"GET", "/books/999", "", 404, "book with ID 999 not found"},
+		{"POST", "/books", `{"title": "No Author"}`, 400, "author is required"},
+		{"POST", "/books", `{"title": "Dune", "author": "Frank Herbert", "format": "scroll"}`, 400, "invalid format: scroll"},
+		{"POST", "/books", `{"title": "Dune", "author": "Frank Herbert", "colour": "red"}`, 400, "unknown field"},
+		{"POST", "/books", ``, 400, "request body is empty"},
+		{"PATCH", fmt.Sprintf("/books/%d", id), `{"title": ""}`, 400, "title is required"},
+		{"PUT", fmt.Sprintf("/books/%d/rating", id), `{"rating": 4.3}`, 400, "rating must be between 0.5 and 5"},
+		{"PUT", fmt.Sprintf("/books/%d/rating", id), `{"rating": 6}`, 400, "rating must be between 0.5 and 5"},
+		{"PUT", fmt.Sprintf("/books/%d/status", id), `{"status": "done"}`, 400, "invalid status: done"},
+		{"PUT", fmt.Sprintf("/books/%d/status", id), `{"status": "want-to-read", "date": "2026-01-01"}`, 400, "date can only be given"},
+		{"PUT", "/books/999/review", `{"review": "Hm"}`, 404, "book with ID 999 not found"},
+		{"GET", "/nowhere", "", 404, "no such endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := do(t, h, tt.method, tt.path, tt.body, nil)
+			if rec.Code != tt.code {
+				t.Errorf("got %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			var body struct{ Error string }
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if !strings.Contains(body.Error, tt.errMsg) {
+				t.Errorf("expected '%s' in error, got %q", tt.errMsg, body.Error)
+			}
+		})
+	}
+}
+
+func TestReadingLifecycle(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New()
+	id, _ := db.AddBook("Dune", "Frank Herbert", nil, nil, nil, nil, nil, nil)
+	db.CreateReadingEntry(id, models.StatusWantToRead)
+	base := fmt.Sprintf("/books/%d", id)
+
+	var book bookJSON
+	do(t, h, "PUT", base+"/status", `{"status": "reading", "date": "2026-03-01"}`, &book)
+	if book.Status != models.StatusReading || book.StartedAt == nil || book.StartedAt.Format("2006-01-02") != "2026-03-01" {
+		t.Fatalf("after start: %+v", book)
+	}
+
+	rec := do(t, h, "PUT", base+"/status", `{"status": "finished", "date": "2026-02-01"}`, nil)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "is after finish date") {
+		t.Errorf("finishing before the start: got %d: %s", rec.Code, rec.Body)
+	}
+
+	do(t, h, "PUT", base+"/status", `{"status": "finished", "date": "2026-03-10"}`, &book)
+	if book.Status != models.StatusFinished || book.FinishedAt.Format("2006-01-02") != "2026-03-10" {
+		t.Fatalf("after finish: %+v", book)
+	}
+
+	do(t, h, "PUT", base+"/rating", `{"rating": 4.5}`, &book)
+	if book.Rating == nil || *book.Rating != 4.5 {
+		t.Errorf("rating = %v", book.Rating)
+	}
+
+	do(t, h, "PUT", base+"/review", `{"review": "  Spice!  "}`, &book)
+	if book.Review == nil || *book.Review != "Spice!" {
+		t.Errorf("review = %v", book.Review)
+	}
+	do(t, h, "PUT", base+"/review", `{"review": ""}`, &book)
+	if book.Review != nil {
+		t.Errorf("review should be cleared, got %q", *book.Review)
+	}
+}
+
+func TestGoalsAndStats(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New()
+	id, _ := db.AddBook("Dune", "Frank Herbert", nil, nil, nil, nil, nil, nil)
+	db.CreateReadingEntry(id, models.StatusWantToRead)
+	db.UpdateStatus(id, models.StatusFinished)
+	year := time.Now().Year()
+
+	if rec := do(t, h, "GET", fmt.Sprintf("/goals/%d", year), "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("goal before it's set: got %d", rec.Code)
+	}
+
+	var goal goalJSON
+	do(t, h, "PUT", fmt.Sprintf("/goals/%d", year), `{"target": 12}`, &goal)
+	if goal.Target != 12 || goal.Finished != 1 {
+		t.Errorf("goal = %+v", goal)
+	}
+	if rec := do(t, h, "PUT", "/goals/1800", `{"target": 12}`, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("year out of range: got %d", rec.Code)
+	}
+	if rec := do(t, h, "PUT", fmt.Sprintf("/goals/%d", year), `{"target": 0}`, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("zero target: got %d", rec.Code)
+	}
+
+	var goals []goalJSON
+	do(t, h, "GET", "/goals", "", &goals)
+	if len(goals) != 1 {
+		t.Errorf("goals = %+v", goals)
+	}
+	if rec := do(t, h, "DELETE", fmt.Sprintf("/goals/%d", year), "", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("clear goal: got %d", rec.Code)
+	}
+
+	var stats statsJSON
+	do(t, h, "GET", "/stats", "", &stats)
+	if stats.TotalBooks != 1 || stats.Finished != 1 || stats.BooksThisYear != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New(WithConfigValidator(func(key, value string) error {
+		if key != "site.title" {
+			return fmt.Errorf("unknown config key: %s", key)
+		}
+		return nil
+	}))
+	db.SetConfig(TokenConfigKey, "secret")
+
+	var value map[string]string
+	rec := do(t, h, "PUT", "/config/site.title", `{"value": "Reading"}`, &value)
+	if rec.Code != http.StatusOK || value["value"] != "Reading" {
+		t.Fatalf("set: got %d: %s", rec.Code, rec.Body)
+	}
+	if got, _ := db.GetConfig("site.title"); got != "Reading" {
+		t.Errorf("site.title = %q", got)
+	}
+	if rec := do(t, h, "PUT", "/config/site.bogus", `{"value": "x"}`, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown key: got %d", rec.Code)
+	}
+
+	var all map[string]string
+	do(t, h, "GET", "/config", "", &all)
+	if all["site.title"] != "Reading" || all[TokenConfigKey] != "" {
+		t.Errorf("config = %v", all)
+	}
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		if rec := do(t, h, method, "/config/"+TokenConfigKey, `{"value": "mine"}`, nil); rec.Code != http.StatusForbidden {
+			t.Errorf("%s api.token: got %d", method, rec.Code)
+		}
+	}
+
+	if rec := do(t, h, "DELETE", "/config/site.title", "", nil); rec.Code != http.StatusNoContent {
+		t.Errorf("unset: got %d", rec.Code)
+	}
+	if rec := do(t, h, "GET", "/config/site.title", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("get after unset: got %d", rec.Code)
+	}
+}
+
+func TestToken(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New(WithToken("secret"))
+
+	tests := []struct {
+		path, auth string
+		code       int
+	}{
+		{"/books", "", http.StatusUnauthorized},
+		{"/books", "Bearer wrong", http.StatusUnauthorized},
+		{"/books", "secret", http.StatusUnauthorized},
+		{"/books", "Bearer secret", http.StatusOK},
+		{"/openapi.json", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("GET %s with %q: got %d, want %d", tt.path, tt.auth, rec.Code, tt.code)
+		}
+	}
+}
+
+func TestBrowserRequests(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	h := New()
+
+	// A form or fetch from another page can post text/plain without asking.
+	req := httptest.NewRequest("POST", "/books", strings.NewReader(`{"title": "Dune", "author": "Frank Herbert"}`))
+	req.Host = "localhost:8080"
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain body: got %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+	if books, _ := db.ListBooks(models.ListOptions{}); len(books) != 0 {
+		t.Errorf("text/plain body added %d books", len(books))
+	}
+
+	// A DNS rebinding page reaches the API under its own host name.
+	req = httptest.NewRequest("GET", "/books", nil)
+	req.Host = "evil.example:8080"
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMisdirectedRequest {
+		t.Errorf("Host evil.example without a token: got %d, want %d", rec.Code, http.StatusMisdirectedRequest)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	tests := map[string]bool{
+		"localhost":        true,
+		"LOCALHOST:8080":   true,
+		"127.0.0.1:8080":   true,
+		"[::1]:8080":       true,
+		"::1":              true,
+		":8080":            false,
+		"0.0.0.0:8080":     false,
+		"192.168.1.2:8080": false,
+		"evil.example":     false,
+	}
+	for host, want := range tests {
+		if got := IsLoopbackHost(host); got != want {
+			t.Errorf("IsLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPIDocument, &doc); err != nil {
+		t.Fatalf("openapi.json is invalid: %v", err)
+	}
+
+	routes := []string{
+		"GET /books", "POST /books", "GET /books/{id}", "PATCH /books/{id}", "DELETE /books/{id}",
+		"PUT /books/{id}/status", "PUT /books/{id}/rating", "PUT /books/{id}/review",
+		"GET /goals", "GET /goals/{year}", "PUT /goals/{year}", "DELETE /goals/{year}",
+		"GET /stats", "GET /config", "GET /config/{key}", "PUT /config/{key}", "DELETE /config/{key}",
+	}
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("%s is missing from openapi.json", route)
+		}
+	}
+}
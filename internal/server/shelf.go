@@ -0,0 +1,255 @@
+package server
+
+import (
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"net/http"
+	"strings"
+)
+
+// goalJSON is a yearly goal with the books finished towards it so far.
+type goalJSON struct {
+	Year     int `json:"year"`
+	Target   int `json:"target"`
+	Finished int `json:"finished"`
+}
+
+func toGoalJSON(goal models.ReadingGoal) (goalJSON, error) {
+	finished, err := db.GetBooksFinishedInYear(goal.Year)
+	if err != nil {
+		return goalJSON{}, err
+	}
+	return goalJSON{Year: goal.Year, Target: goal.Target, Finished: finished}, nil
+}
+
+// goalYear parses the {year} path parameter, which must be in the range the
+// goal command accepts.
+func goalYear(w http.ResponseWriter, r *http.Request) (int, bool) {
+	year, ok := pathInt(w, r, "year")
+	if !ok {
+		return 0, false
+	}
+	if year < 1900 || year > 2100 {
+		writeError(w, http.StatusBadRequest, "invalid year: %d (must be between 1900 and 2100)", year)
+		return 0, false
+	}
+	return int(year), true
+}
+
+func (s *Server) handleListGoals(w http.ResponseWriter, r *http.Request) {
+	goals, err := db.GetAllGoals()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get goals: %v", err)
+		return
+	}
+
+	out := make([]goalJSON, len(goals))
+	for i, goal := range goals {
+		if out[i], err = toGoalJSON(goal); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get books finished: %v", err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleGetGoal(w http.ResponseWriter, r *http.Request) {
+	year, ok := goalYear(w, r)
+	if !ok {
+		return
+	}
+	s.writeGoal(w, http.StatusOK, year)
+}
+
+// writeGoal responds with the year's goal, or a 404 if there's none.
+func (s *Server) writeGoal(w http.ResponseWriter, status, year int) {
+	goal, err := db.GetGoal(year)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get goal: %v", err)
+		return
+	}
+	if goal == nil {
+		writeError(w, http.StatusNotFound, "no goal set for %d", year)
+		return
+	}
+	out, err := toGoalJSON(*goal)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get books finished: %v", err)
+		return
+	}
+	writeJSON(w, status, out)
+}
+
+func (s *Server) handleSetGoal(w http.ResponseWriter, r *http.Request) {
+	year, ok := goalYear(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Target int `json:"target"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	if input.Target <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid target: %d (must be a positive number)", input.Target)
+		return
+	}
+
+	if err := db.SetGoal(year, input.Target); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to set goal: %v", err)
+		return
+	}
+	s.writeGoal(w, http.StatusOK, year)
+}
+
+func (s *Server) handleClearGoal(w http.ResponseWriter, r *http.Request) {
+	year, ok := goalYear(w, r)
+	if !ok {
+		return
+	}
+
+	goal, err := db.GetGoal(year)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get goal: %v", err)
+		return
+	}
+	if goal == nil {
+		writeError(w, http.StatusNotFound, "no goal set for %d", year)
+		return
+	}
+	if err := db.ClearGoal(year); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to clear goal: %v", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statsJSON mirrors db.Stats. AverageRating is in stars.
+type statsJSON struct {
+	TotalBooks              int     `json:"total_books"`
+	WantToRead              int     `json:"want_to_read"`
+	Reading                 int     `json:"reading"`
+	Finished                int     `json:"finished"`
+	DidNotFinish            int     `json:"did_not_finish"`
+	BooksThisYear           int     `json:"books_this_year"`
+	PagesThisYear           int     `json:"pages_this_year"`
+	MinutesListenedThisYear int     `json:"minutes_listened_this_year"`
+	AverageRating           float64 `json:"average_rating"`
+	RatedBooks              int     `json:"rated_books"`
+	CurrentStreak           int     `json:"current_streak"`
+	LongestStreak           int     `json:"longest_streak"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := db.GetStats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get stats: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, statsJSON{
+		TotalBooks:              stats.TotalBooks,
+		WantToRead:              stats.WantToRead,
+		Reading:                 stats.Reading,
+		Finished:                stats.Finished,
+		DidNotFinish:            stats.DidNotFinish,
+		BooksThisYear:           stats.BooksThisYear,
+		PagesThisYear:           stats.PagesThisYear,
+		MinutesListenedThisYear: stats.MinutesListenedThisYear,
+		AverageRating:           stats.AverageRating,
+		RatedBooks:              stats.RatedBooksCount,
+		CurrentStreak:           stats.CurrentStreak,
+		LongestStreak:           stats.LongestStreak,
+	})
+}
+
+// configKey reads the {key} path parameter. The API token itself can't be
+// read or changed over the API.
+func configKey(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key := r.PathValue("key")
+	if key == TokenConfigKey {
+		writeError(w, http.StatusForbidden, "%s can only be changed with 'bookshelf config'", TokenConfigKey)
+		return "", false
+	}
+	return key, true
+}
+
+func (s *Server) handleListConfig(w http.ResponseWriter, r *http.Request) {
+	config, err := db.GetAllConfig()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get config: %v", err)
+		return
+	}
+	delete(config, TokenConfigKey)
+	writeJSON(w, http.StatusOK, config)
+}
+
+func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	key, ok := configKey(w, r)
+	if !ok {
+		return
+	}
+	value, err := db.GetConfig(key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get config: %v", err)
+		return
+	}
+	if value == "" {
+		writeError(w, http.StatusNotFound, "%s is not set", key)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"key": key, "value": value})
+}
+
+func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	key, ok := configKey(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Value string `json:"value"`
+	}
+	if !readJSON(w, r, &input) {
+		return
+	}
+	value := strings.TrimSpace(input.Value)
+	if value == "" {
+		writeError(w, http.StatusBadRequest, "value is required; DELETE the key to unset it")
+		return
+	}
+	if s.validateConfig != nil {
+		if err := s.validateConfig(key, value); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", strings.ReplaceAll(err.Error(), "\n", ". "))
+			return
+		}
+	}
+
+	if err := db.SetConfig(key, value); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to set config: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"key": key, "value": value})
+}
+
+func (s *Server) handleUnsetConfig(w http.ResponseWriter, r *http.Request) {
+	key, ok := configKey(w, r)
+	if !ok {
+		return
+	}
+	value, err := db.GetConfig(key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get config: %v", err)
+		return
+	}
+	if value == "" {
+		writeError(w, http.StatusNotFound, "%s is not set", key)
+		return
+	}
+	if err := db.DeleteConfig(key); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to unset config: %v", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
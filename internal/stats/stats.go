@@ -2,6 +2,7 @@ package stats
 
 import (
 	"bookshelf/internal/db"
+	"bookshelf/internal/models"
 	"fmt"
 	"strings"
 	"time"
@@ -21,6 +22,9 @@ func PrintStats() error {
 	fmt.Printf("  Want to read:   %d\n", stats.WantToRead)
 	fmt.Printf("  Reading:        %d\n", stats.Reading)
 	fmt.Printf("  Finished:       %d\n", stats.Finished)
+	if stats.DidNotFinish > 0 {
+		fmt.Printf("  Did not finish: %d\n", stats.DidNotFinish)
+	}
 	fmt.Println()
 
 	fmt.Println("This Year:")
@@ -39,17 +43,40 @@ func PrintStats() error {
 	}
 
 	fmt.Printf("  Pages read:     %d\n", stats.PagesThisYear)
+	if stats.MinutesListenedThisYear > 0 {
+		fmt.Printf("  Hours listened: %.1f\n", float64(stats.MinutesListenedThisYear)/60)
+	}
 	fmt.Println()
 
+	if stats.LongestStreak > 0 {
+		fmt.Println("Reading Streaks:")
+		fmt.Printf("  Current streak: %s\n", pluralDays(stats.CurrentStreak))
+		fmt.Printf("  Longest streak: %s\n", pluralDays(stats.LongestStreak))
+		fmt.Println()
+	}
+
 	if stats.RatedBooksCount > 0 {
+		scale, err := db.GetRatingScale()
+		if err != nil {
+			return err
+		}
 		fmt.Println("Ratings:")
-		fmt.Printf("  Average rating: %.1f/5 (%d books rated)\n", stats.AverageRating, stats.RatedBooksCount)
-		fmt.Printf("  Stars:          %s\n", renderStars(stats.AverageRating))
+		fmt.Printf("  Average rating: %s (%d books rated)\n", scale.FormatAverage(stats.AverageRating), stats.RatedBooksCount)
+		if scale != models.RatingScaleTenPoint {
+			fmt.Printf("  Stars:          %s\n", renderStars(stats.AverageRating))
+		}
 	}
 
 	return nil
 }
 
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
 func renderStars(rating float64) string {
 	fullStars := int(rating)
 	halfStar := rating-float64(fullStars) >= 0.5
@@ -6,6 +6,7 @@ import (
 	"bookshelf/internal/testutil"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPrintStatsEmpty(t *testing.T) {
@@ -103,7 +104,7 @@ func TestRenderProgressBar(t *testing.T) {
 		{5, 10, 10, "[#####-----]"},
 		{10, 10, 10, "[##########]"},
 		{15, 10, 10, "[##########]"}, // Over 100%
-		{0, 0, 10, "[----------]"},  // Edge case: zero total
+		{0, 0, 10, "[----------]"},   // Edge case: zero total
 		{8, 24, 20, "[######--------------]"},
 	}
 
@@ -136,3 +137,26 @@ func TestPrintStatsWithGoal(t *testing.T) {
 		t.Error("expected 'Reading Statistics' in output")
 	}
 }
+
+func TestPrintStatsWithStreaks(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	id, _ := db.AddBook("Book 1", "Author 1", nil, nil, nil, nil, nil, nil)
+	db.CreateReadingEntry(id, models.StatusReading)
+	now := time.Now()
+	db.AddSession(id, now.AddDate(0, 0, -1), testutil.IntPtr(20), nil, nil)
+	db.AddSession(id, now, testutil.IntPtr(20), nil, nil)
+
+	output := testutil.CaptureOutput(t, func() {
+		if err := PrintStats(); err != nil {
+			t.Fatalf("PrintStats failed: %v", err)
+		}
+	})
+
+	for _, expected := range []string{"Reading Streaks:", "Current streak: 2 days", "Longest streak: 2 days"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output missing: %s", expected)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+// Command fakeopenlibrary serves the recorded Open Library fixtures from
+// internal/testutil/fakeol, for demos and trying bookshelf without the network:
+//
+//	go run ./internal/testutil/fakeopenlibrary -addr localhost:8089
+//	BOOKSHELF_OPENLIBRARY_URL=http://localhost:8089 bookshelf add dune
+package main
+
+import (
+	"bookshelf/internal/testutil/fakeol"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8089", "Address to listen on")
+	flag.Parse()
+
+	fmt.Printf("Serving fake Open Library on http://%s\n", *addr)
+	fmt.Printf("Use it with: export BOOKSHELF_OPENLIBRARY_URL=http://%s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, fakeol.Handler()))
+}
@@ -0,0 +1,17 @@
+package testutil
+
+import (
+	"bookshelf/internal/testutil/fakeol"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewOpenLibraryServer starts a fake Open Library serving the recorded
+// fixtures in package fakeol, closed when the test ends. Point the api
+// package at it with BOOKSHELF_OPENLIBRARY_URL.
+func NewOpenLibraryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(fakeol.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
@@ -0,0 +1,607 @@
+// Package tui is the full-screen terminal interface run by 'bookshelf tui':
+// a filterable list of books beside the selected book's details, with keys to
+// move books between shelves, rate, review, tag and remove them, and an
+// inline search to add new ones.
+package tui
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reloadInterval is how often the list is reloaded while browsing, to pick
+// up changes made elsewhere, e.g. by 'bookshelf finish' in another terminal.
+const reloadInterval = 5 * time.Second
+
+// searchTimeout bounds searching for and adding a book.
+const searchTimeout = 30 * time.Second
+
+// Option configures a Model.
+type Option func(*Model)
+
+// WithProvider searches provider when adding books. Without one, adding says
+// searching isn't available.
+func WithProvider(provider api.MetadataProvider) Option {
+	return func(m *Model) { m.provider = provider }
+}
+
+// mode is what keys currently do.
+type mode int
+
+const (
+	modeBrowse  mode = iota
+	modeFilter       // Typing in the filter
+	modeRate         // Typing a rating
+	modeReview       // Editing the review
+	modeTag          // Editing the tags
+	modeRemove       // Confirming a removal
+	modeSearch       // Typing a search for a book to add
+	modeResults      // Picking a search result to add
+)
+
+// Model is the TUI's state. Create one with New and run it with bubbletea.
+type Model struct {
+	provider api.MetadataProvider
+	scale    models.RatingScale
+
+	books  []models.BookWithEntry
+	cursor int
+	detail *models.BookWithEntry // The selected book, from db.GetBook
+
+	status *models.BookStatus // Shelf filter; nil shows every book
+	filter textinput.Model    // Title/author filter
+	input  textinput.Model    // Rating, tags or search
+	review textarea.Model
+
+	results   []api.SearchDoc
+	result    int
+	searching bool // A search or add is running
+
+	mode     mode
+	message  string
+	isError  bool
+	showHelp bool
+
+	width, height int
+}
+
+type tickMsg struct{}
+
+type searchResultsMsg struct {
+	docs []api.SearchDoc
+	err  error
+}
+
+type addedMsg struct {
+	id     int64
+	title  string
+	author string
+	err    error
+}
+
+// New creates a Model showing every book.
+func New(opts ...Option) (Model, error) {
+	m := Model{
+		filter: textinput.New(),
+		input:  textinput.New(),
+		review: textarea.New(),
+		width:  80,
+		height: 24,
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	m.filter.Prompt = "/"
+	m.filter.Placeholder = "title or author"
+	m.review.ShowLineNumbers = false
+	m.review.CharLimit = 0
+
+	scale, err := db.GetRatingScale()
+	if err != nil {
+		return m, fmt.Errorf("failed to get rating scale: %w", err)
+	}
+	m.scale = scale
+
+	if err := m.reload(); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// Init starts the periodic reload.
+func (m Model) Init() tea.Cmd {
+	return tick()
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(reloadInterval, func(time.Time) tea.Msg { return tickMsg{} })
+}
+
+// Update handles a key press, resize, reload or finished search.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.review.SetWidth(max(msg.Width-4, 20))
+		m.review.SetHeight(max(msg.Height/3, 3))
+		return m, nil
+
+	case tickMsg:
+		// Reloading while typing could move the selection under the user.
+		if m.mode == modeBrowse {
+			if err := m.reload(); err != nil {
+				m.setError(err)
+			}
+		}
+		return m, tick()
+
+	case searchResultsMsg:
+		m.searching = false
+		if msg.err != nil {
+			m.mode = modeBrowse
+			m.setError(fmt.Errorf("search failed: %w", msg.err))
+			return m, nil
+		}
+		if len(msg.docs) == 0 {
+			m.mode = modeBrowse
+			m.setMessage(fmt.Sprintf("No books found for %q", m.input.Value()))
+			return m, nil
+		}
+		m.results, m.result = msg.docs, 0
+		m.mode = modeResults
+		return m, nil
+
+	case addedMsg:
+		m.searching = false
+		m.mode = modeBrowse
+		if msg.err != nil {
+			m.setError(msg.err)
+			return m, nil
+		}
+		// Show every book, so the new one is in the list to select.
+		m.status = nil
+		m.filter.SetValue("")
+		if err := m.reload(); err != nil {
+			m.setError(err)
+			return m, nil
+		}
+		m.selectID(msg.id)
+		m.setMessage(fmt.Sprintf("Added \"%s\" by %s", msg.title, msg.author))
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeBrowse:
+			return m.updateBrowse(msg)
+		case modeFilter:
+			return m.updateFilter(msg)
+		case modeRate, modeTag, modeSearch:
+			return m.updateInput(msg)
+		case modeReview:
+			return m.updateReview(msg)
+		case modeRemove:
+			return m.updateRemove(msg)
+		case modeResults:
+			return m.updateResults(msg)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message = ""
+	if m.showHelp {
+		m.showHelp = false
+		return m, nil
+	}
+	book := m.selected()
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "?":
+		m.showHelp = true
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup":
+		m.move(-m.listHeight())
+	case "pgdown":
+		m.move(m.listHeight())
+	case "home", "g":
+		m.move(-len(m.books))
+	case "end", "G":
+		m.move(len(m.books))
+	case "/":
+		m.mode = modeFilter
+		return m, m.filter.Focus()
+	case "tab":
+		m.cycleStatus(1)
+	case "shift+tab":
+		m.cycleStatus(-1)
+	case "esc":
+		if m.filter.Value() != "" || m.status != nil {
+			m.filter.SetValue("")
+			m.status = nil
+			m.refresh()
+		}
+	case "a":
+		if m.provider == nil {
+			m.setError(fmt.Errorf("searching isn't available"))
+			return m, nil
+		}
+		return m, m.prompt(modeSearch, "Add a book: ", "")
+	}
+
+	if book == nil {
+		return m, nil
+	}
+	switch msg.String() {
+	case "s":
+		m.setStatus(book, models.StatusReading)
+	case "f":
+		m.setStatus(book, models.StatusFinished)
+	case "r":
+		value := ""
+		if book.ReadingEntry.Rating.Valid {
+			value = fmt.Sprint(m.scale.Value(book.ReadingEntry.Rating.Float64))
+		}
+		return m, m.prompt(modeRate, fmt.Sprintf("Rating (%s): ", m.scale.Range()), value)
+	case "w":
+		m.mode = modeReview
+		m.review.SetValue(book.ReadingEntry.Review.String)
+		return m, m.review.Focus()
+	case "t":
+		return m, m.prompt(modeTag, "Tags (comma-separated): ", strings.Join(book.Book.TagList(), ", "))
+	case "x":
+		m.mode = modeRemove
+	}
+	return m, nil
+}
+
+func (m Model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filter.SetValue("")
+		fallthrough
+	case "enter":
+		m.filter.Blur()
+		m.mode = modeBrowse
+		m.refresh()
+		return m, nil
+	case "up", "down":
+		m.filter.Blur()
+		m.mode = modeBrowse
+		return m.updateBrowse(msg)
+	}
+
+	before := m.filter.Value()
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != before {
+		m.refresh()
+	}
+	return m, cmd
+}
+
+func (m Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.input.Blur()
+		m.mode = modeBrowse
+		return m, nil
+	case "enter":
+		m.input.Blur()
+		value := strings.TrimSpace(m.input.Value())
+		switch m.mode {
+		case modeRate:
+			m.mode = modeBrowse
+			m.setRating(m.selected(), value)
+		case modeTag:
+			m.mode = modeBrowse
+			m.setTags(m.selected(), value)
+		case modeSearch:
+			if value == "" {
+				m.mode = modeBrowse
+				return m, nil
+			}
+			m.searching = true
+			return m, m.search(value)
+		}
+		return m, nil
+	}
+
+	if m.searching {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.review.Blur()
+		m.mode = modeBrowse
+		m.setMessage("Review not saved")
+		return m, nil
+	case "ctrl+s":
+		m.review.Blur()
+		m.mode = modeBrowse
+		m.setReview(m.selected(), m.review.Value())
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.review, cmd = m.review.Update(msg)
+	return m, cmd
+}
+
+func (m Model) updateRemove(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = modeBrowse
+	if msg.String() == "y" || msg.String() == "Y" {
+		m.remove(m.selected())
+	}
+	return m, nil
+}
+
+func (m Model) updateResults(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searching {
+		return m, nil
+	}
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = modeBrowse
+		m.results = nil
+	case "up", "k":
+		m.result = max(m.result-1, 0)
+	case "down", "j":
+		m.result = min(m.result+1, len(m.results)-1)
+	case "enter", "a":
+		m.searching = true
+		return m, m.add(m.results[m.result])
+	}
+	return m, nil
+}
+
+// prompt switches to an input mode, with the input showing value.
+func (m *Model) prompt(mode mode, label, value string) tea.Cmd {
+	m.mode = mode
+	m.input.Prompt = label
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// selected returns the book under the cursor, or nil if the list is empty.
+func (m *Model) selected() *models.BookWithEntry {
+	return m.detail
+}
+
+func (m *Model) move(delta int) {
+	if len(m.books) == 0 {
+		return
+	}
+	cursor := min(max(m.cursor+delta, 0), len(m.books)-1)
+	if cursor != m.cursor {
+		m.cursor = cursor
+		m.loadDetail()
+	}
+}
+
+// cycleStatus moves the shelf filter through every shelf and back to all.
+func (m *Model) cycleStatus(delta int) {
+	shelves := len(models.BookStatuses) + 1 // Every shelf, then all books
+	current := len(models.BookStatuses)
+	for i, status := range models.BookStatuses {
+		if m.status != nil && *m.status == status {
+			current = i
+		}
+	}
+	next := (current + delta + shelves) % shelves
+	if next == len(models.BookStatuses) {
+		m.status = nil
+	} else {
+		status := models.BookStatuses[next]
+		m.status = &status
+	}
+	m.refresh()
+}
+
+// reload lists the books again, keeping the selected book selected if it's
+// still listed.
+func (m *Model) reload() error {
+	var selectedID int64
+	if m.detail != nil {
+		selectedID = m.detail.Book.ID
+	}
+
+	books, err := db.ListBooks(models.ListOptions{
+		StatusFilter: m.status,
+		SearchQuery:  strings.TrimSpace(m.filter.Value()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list books: %w", err)
+	}
+	m.books = books
+
+	m.cursor = min(m.cursor, max(len(books)-1, 0))
+	for i, book := range books {
+		if book.Book.ID == selectedID {
+			m.cursor = i
+		}
+	}
+	return m.loadDetail()
+}
+
+// refresh reloads, showing any error.
+func (m *Model) refresh() {
+	if err := m.reload(); err != nil {
+		m.setError(err)
+	}
+}
+
+// loadDetail fetches the book under the cursor for the detail pane.
+func (m *Model) loadDetail() error {
+	m.detail = nil
+	if len(m.books) == 0 {
+		return nil
+	}
+	book, err := db.GetBook(m.books[m.cursor].Book.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get book: %w", err)
+	}
+	m.detail = book
+	return nil
+}
+
+func (m *Model) selectID(id int64) {
+	for i, book := range m.books {
+		if book.Book.ID == id {
+			m.cursor = i
+			if err := m.loadDetail(); err != nil {
+				m.setError(err)
+			}
+			return
+		}
+	}
+}
+
+func (m *Model) setMessage(message string) {
+	m.message, m.isError = message, false
+}
+
+func (m *Model) setError(err error) {
+	m.message, m.isError = err.Error(), true
+}
+
+// setStatus moves book to a shelf as of now, like 'bookshelf start' and
+// 'bookshelf finish'.
+func (m *Model) setStatus(book *models.BookWithEntry, status models.BookStatus) {
+	if book.ReadingEntry.Status == status {
+		m.setMessage(fmt.Sprintf("\"%s\" is already %s", book.Book.Title, status))
+		return
+	}
+	now := time.Now()
+	if err := db.UpdateStatusOn(book.Book.ID, status, &now); err != nil {
+		m.setError(fmt.Errorf("failed to update status: %w", err))
+		return
+	}
+	m.refresh()
+	if status == models.StatusFinished {
+		m.setMessage(fmt.Sprintf("Finished reading \"%s\". Press r to rate it", book.Book.Title))
+	} else {
+		m.setMessage(fmt.Sprintf("Started reading \"%s\"", book.Book.Title))
+	}
+}
+
+func (m *Model) setRating(book *models.BookWithEntry, value string) {
+	if value == "" {
+		return
+	}
+	rating, err := m.scale.Parse(value)
+	if err != nil {
+		m.setError(err)
+		return
+	}
+	if err := db.UpdateRating(book.Book.ID, rating); err != nil {
+		m.setError(fmt.Errorf("failed to update rating: %w", err))
+		return
+	}
+	m.refresh()
+	m.setMessage(fmt.Sprintf("Rated \"%s\" %s", book.Book.Title, m.scale.Format(rating)))
+}
+
+func (m *Model) setReview(book *models.BookWithEntry, review string) {
+	review = strings.TrimSpace(review)
+	if err := db.UpdateReview(book.Book.ID, review); err != nil {
+		m.setError(fmt.Errorf("failed to save review: %w", err))
+		return
+	}
+	m.refresh()
+	if review == "" {
+		m.setMessage("Cleared your review")
+	} else {
+		m.setMessage("Saved your review")
+	}
+}
+
+// setTags replaces book's tags with a comma-separated list.
+func (m *Model) setTags(book *models.BookWithEntry, value string) {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	updated := book.Book
+	updated.SetTags(tags)
+	if err := db.UpdateBook(&updated); err != nil {
+		m.setError(fmt.Errorf("failed to update tags: %w", err))
+		return
+	}
+	m.refresh()
+	if len(tags) == 0 {
+		m.setMessage(fmt.Sprintf("Removed the tags from \"%s\"", book.Book.Title))
+	} else {
+		m.setMessage(fmt.Sprintf("Tagged \"%s\": %s", book.Book.Title, strings.Join(tags, ", ")))
+	}
+}
+
+func (m *Model) remove(book *models.BookWithEntry) {
+	if err := db.DeleteBook(book.Book.ID); err != nil {
+		m.setError(fmt.Errorf("failed to remove book: %w", err))
+		return
+	}
+	m.refresh()
+	m.setMessage(fmt.Sprintf("Removed \"%s\"", book.Book.Title))
+}
+
+// search looks for books to add in the background.
+func (m Model) search(query string) tea.Cmd {
+	provider := m.provider
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+		defer cancel()
+		docs, err := provider.Search(ctx, api.SearchQuery{Text: query, Limit: 10})
+		return searchResultsMsg{docs: docs, err: err}
+	}
+}
+
+// add adds a search result to the want-to-read shelf in the background,
+// fetching its description and genres first.
+func (m Model) add(doc api.SearchDoc) tea.Cmd {
+	provider := m.provider
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+		defer cancel()
+		book := api.BookFromSearch(ctx, provider, doc)
+		if book.Title == "" {
+			return addedMsg{err: fmt.Errorf("title is required")}
+		}
+
+		id, err := db.InsertBook(&book)
+		if err != nil {
+			return addedMsg{err: fmt.Errorf("failed to add book: %w", err)}
+		}
+		if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+			return addedMsg{err: fmt.Errorf("failed to create reading entry: %w", err)}
+		}
+		return addedMsg{id: id, title: book.Title, author: book.Author}
+	}
+}
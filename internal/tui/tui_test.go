@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bookshelf/internal/testutil"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// press sends keys to m, one at a time: named keys like "enter", "tab" and
+// "ctrl+s", or text to type. It runs any command a key returns except ticks
+// and blinks, feeding its message back in, as bubbletea would.
+func press(t *testing.T, m Model, keys ...string) Model {
+	t.Helper()
+	named := map[string]tea.KeyType{
+		"enter":     tea.KeyEnter,
+		"esc":       tea.KeyEsc,
+		"tab":       tea.KeyTab,
+		"shift+tab": tea.KeyShiftTab,
+		"up":        tea.KeyUp,
+		"down":      tea.KeyDown,
+		"ctrl+s":    tea.KeyCtrlS,
+		"backspace": tea.KeyBackspace,
+	}
+	for _, k := range keys {
+		var msgs []tea.Msg
+		if kt, ok := named[k]; ok {
+			msgs = append(msgs, tea.KeyMsg{Type: kt})
+		} else {
+			for _, r := range k {
+				msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			}
+		}
+		for _, msg := range msgs {
+			m = update(m, msg)
+		}
+	}
+	return m
+}
+
+// update sends msg to m, then the messages of the searches and adds it
+// starts.
+func update(m Model, msg tea.Msg) Model {
+	next, cmd := m.Update(msg)
+	m = next.(Model)
+	if m.searching && cmd != nil {
+		return update(m, cmd())
+	}
+	return m
+}
+
+func seed(t *testing.T, title, author string) int64 {
+	t.Helper()
+	id, err := db.InsertBook(&models.Book{Title: title, Author: author})
+	if err != nil {
+		t.Fatalf("InsertBook() error: %v", err)
+	}
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		t.Fatalf("CreateReadingEntry() error: %v", err)
+	}
+	return id
+}
+
+func newModel(t *testing.T, opts ...Option) Model {
+	t.Helper()
+	m, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return update(m, tea.WindowSizeMsg{Width: 120, Height: 30})
+}
+
+func titles(m Model) []string {
+	var list []string
+	for _, book := range m.books {
+		list = append(list, book.Book.Title)
+	}
+	return list
+}
+
+func TestFilter(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	seed(t, "Dune", "Frank Herbert")
+	seed(t, "Emma", "Jane Austen")
+	persuasion := seed(t, "Persuasion", "Jane Austen")
+	db.UpdateStatus(persuasion, models.StatusReading)
+
+	m := newModel(t)
+	if len(m.books) != 3 {
+		t.Fatalf("got %v, want every book", titles(m))
+	}
+
+	m = press(t, m, "/", "austen")
+	if got := titles(m); len(got) != 2 {
+		t.Errorf("filter austen = %v", got)
+	}
+	m = press(t, m, "enter", "tab", "tab")
+	if got := titles(m); len(got) != 1 || got[0] != "Persuasion" {
+		t.Errorf("austen on the reading shelf = %v", got)
+	}
+	if !strings.Contains(m.View(), "Persuasion") {
+		t.Error("view should show the selected book")
+	}
+
+	m = press(t, m, "esc")
+	if m.status != nil || m.filter.Value() != "" || len(m.books) != 3 {
+		t.Errorf("esc should show every book, got %v", titles(m))
+	}
+}
+
+func TestBookKeys(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	id := seed(t, "Dune", "Frank Herbert")
+	m := newModel(t)
+
+	m = press(t, m, "s")
+	if m.message != `Started reading "Dune"` || m.detail.ReadingEntry.Status != models.StatusReading {
+		t.Errorf("start: %q, status %s", m.message, m.detail.ReadingEntry.Status)
+	}
+	m = press(t, m, "f")
+	if m.detail.ReadingEntry.Status != models.StatusFinished || !m.detail.ReadingEntry.FinishedAt.Valid {
+		t.Errorf("finish: status %s", m.detail.ReadingEntry.Status)
+	}
+
+	m = press(t, m, "r", "9", "enter")
+	if !m.isError || !strings.Contains(m.message, "rating must be between") {
+		t.Errorf("out-of-range rating: %q", m.message)
+	}
+	m = press(t, m, "r", "4", "enter")
+	if m.message != `Rated "Dune" 4/5` || m.detail.ReadingEntry.Rating.Float64 != 4 {
+		t.Errorf("rate: %q", m.message)
+	}
+
+	m = press(t, m, "w", "A classic.", "ctrl+s")
+	if m.message != "Saved your review" || m.detail.ReadingEntry.Review.String != "A classic." {
+		t.Errorf("review: %q, %q", m.message, m.detail.ReadingEntry.Review.String)
+	}
+	m = press(t, m, "w", "backspace", "esc")
+	if review, _ := db.GetReview(id); review != "A classic." {
+		t.Errorf("esc should discard the edit, got %q", review)
+	}
+
+	m = press(t, m, "t", "sci-fi, , favourites", "enter")
+	if tags := m.detail.Book.TagList(); len(tags) != 2 || tags[0] != "sci-fi" || tags[1] != "favourites" {
+		t.Errorf("tags = %v", tags)
+	}
+	if m.detail.Book.Title != "Dune" {
+		t.Error("tagging shouldn't change the rest of the book")
+	}
+
+	m = press(t, m, "x", "n")
+	if exists, _ := db.BookExists(id); !exists {
+		t.Fatal("n shouldn't remove the book")
+	}
+	m = press(t, m, "x", "y")
+	if exists, _ := db.BookExists(id); exists {
+		t.Error("y should remove the book")
+	}
+	if m.message != `Removed "Dune"` || len(m.books) != 0 || m.detail != nil {
+		t.Errorf("remove: %q, %v", m.message, titles(m))
+	}
+
+	// With no books, book keys do nothing.
+	m = press(t, m, "s", "r")
+	if m.mode != modeBrowse {
+		t.Errorf("mode = %v, want browsing", m.mode)
+	}
+}
+
+func TestReloadKeepsSelection(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	seed(t, "Dune", "Frank Herbert")
+	seed(t, "Emma", "Jane Austen")
+	m := newModel(t)
+
+	m = press(t, m, "down")
+	selected := m.detail.Book.ID
+	seed(t, "Beloved", "Toni Morrison")
+	m = update(m, tickMsg{})
+	if len(m.books) != 3 || m.detail.Book.ID != selected {
+		t.Errorf("after reload got %v, selected %d, want %d", titles(m), m.detail.Book.ID, selected)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+
+	m := newModel(t)
+	if m = press(t, m, "a"); !m.isError || m.message != "searching isn't available" {
+		t.Errorf("add without a provider: %q", m.message)
+	}
+
+	ol := testutil.NewOpenLibraryServer(t)
+	m = newModel(t, WithProvider(api.NewClient(api.WithBaseURL(ol.URL))))
+
+	m = press(t, m, "a", "nothing matches this", "enter")
+	if m.mode != modeBrowse || !strings.HasPrefix(m.message, "No books found") {
+		t.Errorf("empty search: mode %v, %q", m.mode, m.message)
+	}
+
+	m = press(t, m, "a", "dune", "enter")
+	if m.mode != modeResults || len(m.results) == 0 {
+		t.Fatalf("search: mode %v, %d results", m.mode, len(m.results))
+	}
+	if !strings.Contains(m.View(), m.results[0].Title) {
+		t.Error("view should list the results")
+	}
+
+	want := m.results[0].Title
+	m = press(t, m, "enter")
+	if m.mode != modeBrowse || !strings.HasPrefix(m.message, `Added "`+want+`"`) {
+		t.Fatalf("add: mode %v, %q", m.mode, m.message)
+	}
+	if len(m.books) != 1 || m.detail.Book.Title != want || m.detail.ReadingEntry.Status != models.StatusWantToRead {
+		t.Errorf("added book = %+v", m.detail)
+	}
+}
@@ -0,0 +1,271 @@
+package tui
+
+import (
+	"bookshelf/internal/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("0")).Background(lipgloss.Color("110"))
+	tabStyle      = lipgloss.NewStyle().Padding(0, 1)
+	activeTab     = tabStyle.Bold(true).Foreground(lipgloss.Color("0")).Background(lipgloss.Color("110"))
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+	labelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Width(10)
+	messageStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	starStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+)
+
+// statusMarks are the list's shelf markers.
+var statusMarks = map[models.BookStatus]string{
+	models.StatusWantToRead: "○",
+	models.StatusReading:    "◐",
+	models.StatusFinished:   "●",
+	models.StatusDNF:        "✕",
+}
+
+const shortHelp = "↑/↓ move  / filter  tab shelf  s start  f finish  r rate  w review  t tag  x remove  a add  ? help  q quit"
+
+const fullHelp = `Moving around
+  ↑/k ↓/j       Previous / next book
+  pgup pgdown   Previous / next page
+  g G           First / last book
+  /             Filter by title or author (enter keeps it, esc clears it)
+  tab shift+tab Next / previous shelf
+  esc           Show every book again
+
+Changing the selected book
+  s             Start reading it, as of now
+  f             Finish it, as of now
+  r             Rate it
+  w             Write or edit your review (ctrl+s saves, esc cancels)
+  t             Edit its tags, separated by commas
+  x             Remove it, after asking
+
+Adding books
+  a             Search for a book; enter adds the highlighted result to
+                want-to-read
+
+  q ctrl+c      Quit
+
+Press any key to close this help.`
+
+// View draws the shelf tabs, list and details, and whatever's being typed.
+func (m Model) View() string {
+	var b strings.Builder
+	b.WriteString(m.tabsView())
+	b.WriteString("\n")
+	if m.mode == modeFilter || m.filter.Value() != "" {
+		b.WriteString(m.filter.View())
+	}
+	b.WriteString("\n")
+
+	switch {
+	case m.showHelp:
+		b.WriteString(paneStyle.Width(m.width - 2).Render(fullHelp))
+	case m.mode == modeReview:
+		b.WriteString(m.reviewView())
+	case m.mode == modeResults || (m.mode == modeSearch && m.searching):
+		b.WriteString(m.resultsView())
+	default:
+		b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.listView(), m.detailView()))
+	}
+	b.WriteString("\n")
+	b.WriteString(m.footerView())
+	return b.String()
+}
+
+func (m Model) tabsView() string {
+	tabs := []string{titleStyle.Render("Bookshelf") + " "}
+	label := func(name string, active bool) string {
+		if active {
+			return activeTab.Render(name)
+		}
+		return tabStyle.Render(name)
+	}
+	tabs = append(tabs, label("all", m.status == nil))
+	for _, status := range models.BookStatuses {
+		tabs = append(tabs, label(string(status), m.status != nil && *m.status == status))
+	}
+	tabs = append(tabs, dimStyle.Render(fmt.Sprintf("  %d books", len(m.books))))
+	return lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+}
+
+// Pane sizes, leaving room for the tabs, filter, footer and borders.
+func (m Model) listHeight() int  { return max(m.height-6, 3) }
+func (m Model) listWidth() int   { return max(m.width*2/5, 24) }
+func (m Model) detailWidth() int { return max(m.width-m.listWidth()-8, 20) }
+
+func (m Model) listView() string {
+	width, height := m.listWidth(), m.listHeight()
+	if len(m.books) == 0 {
+		empty := "No books yet. Press a to add one."
+		if m.status != nil || m.filter.Value() != "" {
+			empty = "No books match. Press esc to show every book."
+		}
+		return paneStyle.Width(width).Height(height).Render(dimStyle.Render(empty))
+	}
+
+	// Scroll just enough to keep the cursor on screen.
+	start := max(m.cursor-height+1, 0)
+	end := min(start+height, len(m.books))
+	rows := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		book := m.books[i]
+		mark := statusMarks[book.ReadingEntry.Status]
+		if i == m.cursor {
+			row := ansi.Truncate(fmt.Sprintf("%s %s · %s", mark, book.Book.Title, book.Book.Author), width, "…")
+			rows = append(rows, selectedStyle.Width(width).Render(row))
+			continue
+		}
+		row := ansi.Truncate(fmt.Sprintf("%s %s", mark, book.Book.Title)+dimStyle.Render(" · "+book.Book.Author), width, "…")
+		rows = append(rows, row)
+	}
+	return paneStyle.Width(width).Height(height).Render(strings.Join(rows, "\n"))
+}
+
+func (m Model) detailView() string {
+	width, height := m.detailWidth(), m.listHeight()
+	style := paneStyle.Width(width).Height(height)
+	book := m.detail
+	if book == nil {
+		return style.Render("")
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(book.Book.Title) + "\n")
+	if book.Book.Author != "" {
+		b.WriteString("by " + book.Book.Author + "\n")
+	}
+	b.WriteString("\n")
+
+	field := func(label, value string) {
+		b.WriteString(labelStyle.Render(label) + value + "\n")
+	}
+	field("Status", string(book.ReadingEntry.Status))
+	if book.ReadingEntry.Rating.Valid {
+		field("Rating", m.ratingView(book.ReadingEntry.Rating.Float64))
+	}
+	if book.ReadingEntry.StartedAt.Valid {
+		field("Started", book.ReadingEntry.StartedAt.Time.Format("Jan 02, 2006"))
+	}
+	if book.ReadingEntry.FinishedAt.Valid {
+		field("Finished", book.ReadingEntry.FinishedAt.Time.Format("Jan 02, 2006"))
+	}
+	if book.ReadingEntry.CurrentPage.Valid && book.ReadingEntry.Status == models.StatusReading {
+		field("Page", fmt.Sprint(book.ReadingEntry.CurrentPage.Int64))
+	}
+	if book.Book.Pages.Valid {
+		field("Pages", fmt.Sprint(book.Book.Pages.Int64))
+	}
+	if series := book.Book.SeriesLabel(); series != "" {
+		field("Series", series)
+	}
+	if book.Book.Format.Valid {
+		field("Format", book.Book.Format.String)
+	}
+	if tags := book.Book.TagList(); len(tags) > 0 {
+		field("Tags", strings.Join(tags, ", "))
+	}
+	if genres := genreList(book.Book.Genres.String); len(genres) > 0 {
+		field("Genres", strings.Join(genres, ", "))
+	}
+	if book.Book.ISBN.Valid {
+		field("ISBN", book.Book.ISBN.String)
+	}
+
+	if book.ReadingEntry.Review.Valid && book.ReadingEntry.Review.String != "" {
+		b.WriteString("\n" + titleStyle.Render("Your Review") + "\n" + book.ReadingEntry.Review.String + "\n")
+	}
+	if book.Book.Description.Valid && book.Book.Description.String != "" {
+		b.WriteString("\n" + titleStyle.Render("Description") + "\n" + dimStyle.Render(book.Book.Description.String) + "\n")
+	}
+	// Wrap, then cut off whatever doesn't fit, so the pane keeps its border.
+	content := lipgloss.NewStyle().Width(width - 2).MaxHeight(height).Render(strings.TrimRight(b.String(), "\n"))
+	return style.Render(content)
+}
+
+func (m Model) reviewView() string {
+	title := "Your review"
+	if m.detail != nil {
+		title = fmt.Sprintf("Your review of \"%s\"", m.detail.Book.Title)
+	}
+	return paneStyle.Width(m.width - 2).Render(
+		titleStyle.Render(title) + "\n\n" + m.review.View() + "\n\n" + dimStyle.Render("ctrl+s save · esc cancel"))
+}
+
+func (m Model) resultsView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Results for %q", strings.TrimSpace(m.input.Value()))) + "\n\n")
+	if m.searching && len(m.results) == 0 {
+		b.WriteString(dimStyle.Render("Searching..."))
+	}
+	for i, doc := range m.results {
+		row := doc.Title
+		if author := doc.Author(); author != "" {
+			row += " · " + author
+		}
+		if doc.FirstPublishYear > 0 {
+			row += fmt.Sprintf(" (%d)", doc.FirstPublishYear)
+		}
+		if i == m.result {
+			row = selectedStyle.Render(row)
+		}
+		b.WriteString(ansi.Truncate(row, m.width-4, "…") + "\n")
+	}
+	if len(m.results) > 0 {
+		hint := "enter add to want-to-read · esc cancel"
+		if m.searching {
+			hint = "Adding..."
+		}
+		b.WriteString("\n" + dimStyle.Render(hint))
+	}
+	return paneStyle.Width(m.width - 2).Render(strings.TrimRight(b.String(), "\n"))
+}
+
+// footerView shows the prompt being answered, else the last message, and the
+// keys.
+func (m Model) footerView() string {
+	var line string
+	switch {
+	case m.mode == modeRate || m.mode == modeTag || (m.mode == modeSearch && !m.searching):
+		line = m.input.View()
+	case m.mode == modeRemove && m.detail != nil:
+		line = errorStyle.Render(fmt.Sprintf("Remove \"%s\" and its reading history? (y/N)", m.detail.Book.Title))
+	case m.message != "" && m.isError:
+		line = errorStyle.Render("Error: " + m.message)
+	case m.message != "":
+		line = messageStyle.Render(m.message)
+	}
+	return line + "\n" + dimStyle.Render(ansi.Truncate(shortHelp, m.width, "…"))
+}
+
+// ratingView shows a rating as stars, plus its value on point scales.
+func (m Model) ratingView(rating float64) string {
+	if m.scale == models.RatingScaleTenPoint {
+		return m.scale.Format(rating)
+	}
+	full := int(rating)
+	s := strings.Repeat("★", full)
+	if rating-float64(full) >= 0.5 {
+		s += "½"
+		full++
+	}
+	return starStyle.Render(s) + dimStyle.Render(strings.Repeat("☆", max(5-full, 0))) + " " + m.scale.Format(rating)
+}
+
+// genreList decodes a book's Genres, returning nil if it's empty or malformed.
+func genreList(genres string) []string {
+	var list []string
+	if genres == "" || json.Unmarshal([]byte(genres), &list) != nil {
+		return nil
+	}
+	return list
+}
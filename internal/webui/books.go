@@ -0,0 +1,409 @@
+package webui
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/importer"
+	"bookshelf/internal/models"
+	"bookshelf/internal/publish"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type indexPage struct {
+	page
+	Books    []models.BookWithEntry
+	Stats    *db.Stats
+	Goal     *publish.GoalProgress
+	Statuses []models.BookStatus
+	Status   string // The status filter, or "" for every book
+	Search   string
+}
+
+type bookPage struct {
+	page
+	Book     models.BookWithEntry
+	Quotes   []models.Quote
+	Statuses []models.BookStatus
+	Stars    []ratingStep
+	Today    string
+}
+
+// ratingStep is one clickable star, or half star, of the rating form.
+type ratingStep struct {
+	Value  string // As entered on the rating scale, e.g. "4" or "8"
+	Label  string // e.g. "4/5"
+	Half   string // "left" or "right" for half stars, otherwise ""
+	Filled bool
+}
+
+type searchPage struct {
+	page
+	Query     string
+	Results   []searchResult
+	Searched  bool
+	Available bool // Whether there's a provider to search
+}
+
+// searchResult is a search result as the add form sends it back.
+type searchResult struct {
+	Key    string
+	Title  string
+	Author string
+	ISBN   string
+	Cover  string
+	Pages  int
+	Year   int
+}
+
+func newSearchResult(doc api.SearchDoc) searchResult {
+	result := searchResult{
+		Key:    doc.Key,
+		Title:  doc.Title,
+		Author: doc.Author(),
+		Pages:  doc.NumberOfPages,
+		Year:   doc.FirstPublishYear,
+	}
+	if isbn := doc.FirstISBN(); isbn != nil {
+		result.ISBN = *isbn
+	}
+	if cover := doc.CoverURL(); cover != nil {
+		result.Cover = *cover
+	}
+	return result
+}
+
+type removePage struct {
+	page
+	Book models.BookWithEntry
+}
+
+// ratingSteps lays out the stars of the rating form: one per whole star, or
+// per half star on the half-stars scale, or per point on the 10-point scale.
+func ratingSteps(scale models.RatingScale, rating sql.NullFloat64) []ratingStep {
+	step := 1.0
+	if scale == models.RatingScaleHalfStars {
+		step = 0.5
+	}
+	var steps []ratingStep
+	for v := step; v <= float64(scale.Max()); v += step {
+		stars := v
+		if scale == models.RatingScaleTenPoint {
+			stars = v / 2
+		}
+		s := ratingStep{
+			Value:  strconv.FormatFloat(v, 'f', -1, 64),
+			Label:  scale.Format(stars),
+			Filled: rating.Valid && stars <= rating.Float64,
+		}
+		if scale == models.RatingScaleHalfStars {
+			s.Half = "right"
+			if v != float64(int(v)) {
+				s.Half = "left"
+			}
+		}
+		steps = append(steps, s)
+	}
+	return steps
+}
+
+// localCovers points covers stored on this computer, such as a Calibre
+// library's, at /covers so the browser can load them.
+func localCovers(books []models.BookWithEntry) {
+	for i := range books {
+		if strings.HasPrefix(books[i].Book.CoverURL.String, "file://") {
+			books[i].Book.CoverURL.String = fmt.Sprintf("/covers/%d", books[i].Book.ID)
+		}
+	}
+}
+
+// lookupBook loads the book named by the {id} path parameter, writing a 404
+// if there isn't one.
+func (s *Server) lookupBook(w http.ResponseWriter, r *http.Request) (*models.BookWithEntry, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	book, err := db.GetBook(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, fmt.Sprintf("book with ID %d not found", id), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to get book: %w", err))
+		return nil, false
+	}
+	return book, true
+}
+
+func bookPath(id int64) string {
+	return fmt.Sprintf("/books/%d", id)
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	p, err := s.newPage("shelf")
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+	data := indexPage{page: p, Statuses: models.BookStatuses, Status: r.FormValue("status"), Search: strings.TrimSpace(r.FormValue("search"))}
+
+	opts, err := models.ParseListOptions(data.Status, data.Search, r.FormValue("sort"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if data.Books, err = db.ListBooks(opts); err != nil {
+		s.serverError(w, fmt.Errorf("failed to list books: %w", err))
+		return
+	}
+	localCovers(data.Books)
+	if data.Stats, err = db.GetStats(); err != nil {
+		s.serverError(w, fmt.Errorf("failed to fetch stats: %w", err))
+		return
+	}
+	if data.Goal, err = publish.ReadingGoalProgress(time.Now().Year()); err != nil {
+		s.serverError(w, err)
+		return
+	}
+	s.render(w, http.StatusOK, "index", data)
+}
+
+func (s *Server) handleBook(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+	p, err := s.newPage("shelf")
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+
+	quotes, err := db.GetQuotes(book.Book.ID)
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to fetch quotes: %w", err))
+		return
+	}
+	books := []models.BookWithEntry{*book}
+	localCovers(books)
+
+	s.render(w, http.StatusOK, "book", bookPage{
+		page:     p,
+		Book:     books[0],
+		Quotes:   quotes,
+		Statuses: models.BookStatuses,
+		Stars:    ratingSteps(p.Config.RatingScale, book.ReadingEntry.Rating),
+		Today:    time.Now().Format("2006-01-02"),
+	})
+}
+
+// handleCover serves a cover stored on this computer. Only images in the
+// Calibre library (calibre.library) are served; any other file is a 404, so
+// a cover URL can't be used to read the rest of the disk.
+func (s *Server) handleCover(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+	u, err := url.Parse(book.Book.CoverURL.String)
+	if err != nil || u.Scheme != "file" {
+		http.NotFound(w, r)
+		return
+	}
+	library, err := db.GetConfig("calibre.library")
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to get config: %w", err))
+		return
+	}
+	path := filepath.FromSlash(u.Path)
+	if library == "" || !importer.IsCoverIn(library, path) {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeFile(w, r, path)
+}
+
+// handleSetStatus moves a book to another shelf. Starting and finishing
+// record the date given, or today.
+func (s *Server) handleSetStatus(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+	back := bookPath(book.Book.ID)
+
+	status := models.BookStatus(r.PostFormValue("status"))
+	if !status.IsValid() {
+		s.redirect(w, r, back, fmt.Sprintf("invalid status: %s", status), true)
+		return
+	}
+
+	var date *time.Time
+	if status == models.StatusReading || status == models.StatusFinished {
+		now := time.Now()
+		date = &now
+		if input := strings.TrimSpace(r.PostFormValue("date")); input != "" {
+			parsed, err := models.ParseReadingDate(input, now)
+			if err != nil {
+				s.redirect(w, r, back, err.Error(), true)
+				return
+			}
+			date = &parsed
+		}
+		if err := book.ReadingEntry.CheckStatusDate(status, *date); err != nil {
+			s.redirect(w, r, back, err.Error(), true)
+			return
+		}
+	}
+
+	if err := db.UpdateStatusOn(book.Book.ID, status, date); err != nil {
+		s.serverError(w, fmt.Errorf("failed to update status: %w", err))
+		return
+	}
+	s.redirect(w, r, back, fmt.Sprintf("Moved \"%s\" to %s", book.Book.Title, status), false)
+}
+
+// handleSetRating rates a book on the configured scale.
+func (s *Server) handleSetRating(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+	back := bookPath(book.Book.ID)
+
+	scale, err := db.GetRatingScale()
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to get rating scale: %w", err))
+		return
+	}
+	rating, err := scale.Parse(r.PostFormValue("rating"))
+	if err != nil {
+		s.redirect(w, r, back, err.Error(), true)
+		return
+	}
+
+	if err := db.UpdateRating(book.Book.ID, rating); err != nil {
+		s.serverError(w, fmt.Errorf("failed to update rating: %w", err))
+		return
+	}
+	s.redirect(w, r, back, fmt.Sprintf("Rated \"%s\" %s", book.Book.Title, scale.Format(rating)), false)
+}
+
+// handleSetReview saves a book's review; an empty one clears it.
+func (s *Server) handleSetReview(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+
+	review := strings.TrimSpace(strings.ReplaceAll(r.PostFormValue("review"), "\r\n", "\n"))
+	if err := db.UpdateReview(book.Book.ID, review); err != nil {
+		s.serverError(w, fmt.Errorf("failed to save review: %w", err))
+		return
+	}
+	message := "Saved your review"
+	if review == "" {
+		message = "Cleared your review"
+	}
+	s.redirect(w, r, bookPath(book.Book.ID), message, false)
+}
+
+func (s *Server) handleConfirmRemove(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+	p, err := s.newPage("shelf")
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+	s.render(w, http.StatusOK, "remove", removePage{page: p, Book: *book})
+}
+
+func (s *Server) handleRemove(w http.ResponseWriter, r *http.Request) {
+	book, ok := s.lookupBook(w, r)
+	if !ok {
+		return
+	}
+	if err := db.DeleteBook(book.Book.ID); err != nil {
+		s.serverError(w, fmt.Errorf("failed to remove book: %w", err))
+		return
+	}
+	s.redirect(w, r, "/", fmt.Sprintf("Removed \"%s\"", book.Book.Title), false)
+}
+
+// handleSearch shows the add page, with search results if there's a query.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	p, err := s.newPage("add")
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+	data := searchPage{page: p, Query: strings.TrimSpace(r.FormValue("q")), Available: s.provider != nil}
+
+	if data.Query != "" && s.provider != nil {
+		data.Searched = true
+		docs, err := s.provider.Search(r.Context(), api.SearchQuery{Text: data.Query, Limit: 10})
+		if err != nil {
+			data.Flash = flash{Message: fmt.Sprintf("search failed: %v", err), Error: true}
+		}
+		for _, doc := range docs {
+			data.Results = append(data.Results, newSearchResult(doc))
+		}
+	}
+	s.render(w, http.StatusOK, "add", data)
+}
+
+// handleAdd adds a search result, sent back as the form's hidden fields, to
+// the want-to-read shelf.
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	if s.provider == nil {
+		s.redirect(w, r, "/add", "searching isn't available", true)
+		return
+	}
+
+	doc := api.SearchDoc{
+		Key:        r.PostFormValue("key"),
+		Title:      strings.TrimSpace(r.PostFormValue("title")),
+		CoverImage: r.PostFormValue("cover"),
+	}
+	if author := strings.TrimSpace(r.PostFormValue("author")); author != "" {
+		doc.AuthorName = []string{author}
+	}
+	if isbn := strings.TrimSpace(r.PostFormValue("isbn")); isbn != "" {
+		doc.ISBN = []string{isbn}
+	}
+	doc.NumberOfPages, _ = strconv.Atoi(r.PostFormValue("pages"))
+	if doc.Title == "" {
+		s.redirect(w, r, "/add", "title is required", true)
+		return
+	}
+	if doc.CoverImage != "" {
+		if u, err := url.Parse(doc.CoverImage); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			s.redirect(w, r, "/add", "cover must be an http or https URL", true)
+			return
+		}
+	}
+
+	book := api.BookFromSearch(r.Context(), s.provider, doc)
+	id, err := db.InsertBook(&book)
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to add book: %w", err))
+		return
+	}
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		s.serverError(w, fmt.Errorf("failed to create reading entry: %w", err))
+		return
+	}
+	s.redirect(w, r, bookPath(id), fmt.Sprintf("Added \"%s\" by %s", book.Title, book.Author), false)
+}
@@ -0,0 +1,160 @@
+package webui
+
+import (
+	"bookshelf/internal/db"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type goalsPage struct {
+	page
+	Goals       []goalRow
+	CurrentYear int
+}
+
+type goalRow struct {
+	Year     int
+	Target   int
+	Finished int
+	Percent  int
+}
+
+type settingsPage struct {
+	page
+	Settings []setting
+}
+
+type setting struct {
+	Key         string
+	Description string
+	Value       string
+}
+
+func (s *Server) handleGoals(w http.ResponseWriter, r *http.Request) {
+	p, err := s.newPage("goals")
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+
+	goals, err := db.GetAllGoals()
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to get goals: %w", err))
+		return
+	}
+	data := goalsPage{page: p, CurrentYear: time.Now().Year()}
+	for _, goal := range goals {
+		finished, err := db.GetBooksFinishedInYear(goal.Year)
+		if err != nil {
+			s.serverError(w, fmt.Errorf("failed to get books finished: %w", err))
+			return
+		}
+		data.Goals = append(data.Goals, goalRow{
+			Year:     goal.Year,
+			Target:   goal.Target,
+			Finished: finished,
+			Percent:  min(finished*100/goal.Target, 100),
+		})
+	}
+	s.render(w, http.StatusOK, "goals", data)
+}
+
+func (s *Server) handleSetGoal(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(strings.TrimSpace(r.PostFormValue("year")))
+	if err != nil || year < 1900 || year > 2100 {
+		s.redirect(w, r, "/goals", fmt.Sprintf("invalid year: %s (must be between 1900 and 2100)", r.PostFormValue("year")), true)
+		return
+	}
+	target, err := strconv.Atoi(strings.TrimSpace(r.PostFormValue("target")))
+	if err != nil || target <= 0 {
+		s.redirect(w, r, "/goals", fmt.Sprintf("invalid target: %s (must be a positive number)", r.PostFormValue("target")), true)
+		return
+	}
+
+	if err := db.SetGoal(year, target); err != nil {
+		s.serverError(w, fmt.Errorf("failed to set goal: %w", err))
+		return
+	}
+	s.redirect(w, r, "/goals", fmt.Sprintf("Set reading goal for %d: %d books", year, target), false)
+}
+
+func (s *Server) handleClearGoal(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := db.ClearGoal(year); err != nil {
+		s.redirect(w, r, "/goals", err.Error(), true)
+		return
+	}
+	s.redirect(w, r, "/goals", fmt.Sprintf("Cleared reading goal for %d", year), false)
+}
+
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	p, err := s.newPage("settings")
+	if err != nil {
+		s.serverError(w, err)
+		return
+	}
+
+	config, err := db.GetAllConfig()
+	if err != nil {
+		s.serverError(w, fmt.Errorf("failed to get config: %w", err))
+		return
+	}
+	keys := make([]string, 0, len(s.configKeys))
+	for k := range s.configKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	data := settingsPage{page: p}
+	for _, k := range keys {
+		data.Settings = append(data.Settings, setting{Key: k, Description: s.configKeys[k], Value: config[k]})
+	}
+	s.render(w, http.StatusOK, "settings", data)
+}
+
+// handleSetConfig saves one setting, or unsets it when the form's unset
+// button was pressed or the value is blank.
+func (s *Server) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	key := r.PostFormValue("key")
+	if _, ok := s.configKeys[key]; !ok {
+		s.redirect(w, r, "/settings", fmt.Sprintf("unknown config key: %s", key), true)
+		return
+	}
+	value := strings.TrimSpace(r.PostFormValue("value"))
+
+	if r.PostFormValue("unset") != "" || value == "" {
+		current, err := db.GetConfig(key)
+		if err != nil {
+			s.serverError(w, fmt.Errorf("failed to get config: %w", err))
+			return
+		}
+		if current != "" {
+			if err := db.DeleteConfig(key); err != nil {
+				s.serverError(w, fmt.Errorf("failed to unset config: %w", err))
+				return
+			}
+		}
+		s.redirect(w, r, "/settings", fmt.Sprintf("Unset %s (reverted to default)", key), false)
+		return
+	}
+
+	if s.validateConfig != nil {
+		if err := s.validateConfig(key, value); err != nil {
+			s.redirect(w, r, "/settings", err.Error(), true)
+			return
+		}
+	}
+	if err := db.SetConfig(key, value); err != nil {
+		s.serverError(w, fmt.Errorf("failed to set config: %w", err))
+		return
+	}
+	s.redirect(w, r, "/settings", fmt.Sprintf("Set %s = %s", key, value), false)
+}
@@ -0,0 +1,583 @@
+package webui
+
+// layoutTemplate wraps every page: the published site's header and footer,
+// with navigation and the flash message. Pages define "title" and "content".
+const layoutTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>{{template "title" .}} - {{.Config.Title}}</title>
+    <link rel="icon" href="data:image/svg+xml,<svg xmlns='http://www.w3.org/2000/svg' viewBox='0 0 100 100'><text y='.9em' font-size='90'>📚</text></svg>">
+    <link rel="stylesheet" href="/style.css">
+</head>
+<body>
+    <header>
+        <h1><a href="/">{{.Config.Title}}</a></h1>
+        <nav class="ui-nav">
+            <a href="/"{{if eq .Nav "shelf"}} class="active"{{end}}>Shelf</a>
+            <a href="/add"{{if eq .Nav "add"}} class="active"{{end}}>Add a book</a>
+            <a href="/goals"{{if eq .Nav "goals"}} class="active"{{end}}>Goals</a>
+            <a href="/settings"{{if eq .Nav "settings"}} class="active"{{end}}>Settings</a>
+        </nav>
+    </header>
+
+    <main>
+        {{with .Flash.Message}}<p class="flash{{if $.Flash.Error}} error{{end}}" role="status">{{.}}</p>{{end}}
+        {{template "content" .}}
+    </main>
+
+    <footer>
+        <p>{{if .Config.Author}}{{.Config.Author}}'s bookshelf. {{end}}Served by <code>bookshelf ui</code></p>
+    </footer>
+</body>
+</html>`
+
+// pageTemplates are each page's "title" and "content", by name.
+var pageTemplates = map[string]string{
+	"index":    indexTemplate,
+	"book":     bookTemplate,
+	"remove":   removeTemplate,
+	"add":      addTemplate,
+	"goals":    goalsTemplate,
+	"settings": settingsTemplate,
+}
+
+const indexTemplate = `{{define "title"}}Shelf{{end}}
+{{define "content"}}
+        <section class="stats">
+            <div class="stat-card">
+                <span class="stat-number">{{.Stats.TotalBooks}}</span>
+                <span class="stat-label">Total Books</span>
+            </div>
+            <div class="stat-card">
+                <span class="stat-number">{{.Stats.Finished}}</span>
+                <span class="stat-label">Finished</span>
+            </div>
+            <div class="stat-card">
+                <span class="stat-number">{{.Stats.Reading}}</span>
+                <span class="stat-label">Reading</span>
+            </div>
+            <div class="stat-card">
+                <span class="stat-number">{{.Stats.WantToRead}}</span>
+                <span class="stat-label">Want to Read</span>
+            </div>
+            {{if gt .Stats.BooksThisYear 0}}
+            <div class="stat-card highlight">
+                <span class="stat-number">{{.Stats.BooksThisYear}}</span>
+                <span class="stat-label">This Year</span>
+            </div>
+            {{end}}
+            {{if gt .Stats.RatedBooksCount 0}}
+            <div class="stat-card">
+                <span class="stat-number">{{averageRating .Config.RatingScale .Stats.AverageRating}}</span>
+                <span class="stat-label">Avg Rating</span>
+            </div>
+            {{end}}
+        </section>
+
+        {{if .Goal}}
+        <section class="reading-goal">
+            <h2><a href="/goals">{{.Goal.Year}} Reading Goal</a></h2>
+            <div class="goal-progress">
+                <div class="progress-bar">
+                    <div class="progress-fill" style="width: {{.Goal.Percent}}%"></div>
+                </div>
+                <div class="goal-stats">
+                    <span class="goal-current">{{.Goal.Current}} of {{.Goal.Target}} books</span>
+                    <span class="goal-percent">{{.Goal.Percent}}%</span>
+                </div>
+            </div>
+        </section>
+        {{end}}
+
+        <section class="books">
+            <div class="books-header">
+                <h2>{{if .Status}}{{.Status}}{{else}}All Books{{end}}</h2>
+                <div class="filters">
+                    <div class="filter-tabs">
+                        <a class="filter-btn{{if not .Status}} active{{end}}" href="/?search={{.Search}}">All</a>
+                        {{range .Statuses}}
+                        <a class="filter-btn{{if eq (print .) $.Status}} active{{end}}" href="/?status={{.}}&amp;search={{$.Search}}">{{.}}</a>
+                        {{end}}
+                    </div>
+                    <form class="ui-search" method="get" action="/">
+                        {{if .Status}}<input type="hidden" name="status" value="{{.Status}}">{{end}}
+                        <input type="search" name="search" value="{{.Search}}" placeholder="Title or author" aria-label="Search your books">
+                    </form>
+                </div>
+            </div>
+            {{if .Books}}
+            <div class="book-grid">
+                {{range .Books}}
+                <article class="book-card">
+                    <a href="/books/{{.Book.ID}}" class="book-cover-link">
+                        {{if .Book.CoverURL.Valid}}
+                        <img src="{{.Book.CoverURL.String}}" alt="{{.Book.Title}}" class="book-cover" loading="lazy">
+                        {{else}}
+                        <div class="book-cover placeholder">
+                            <span class="initials">{{initials .Book.Title}}</span>
+                            <span class="placeholder-title">{{truncate .Book.Title 40}}</span>
+                        </div>
+                        {{end}}
+                    </a>
+                    <div class="book-info">
+                        <h3><a href="/books/{{.Book.ID}}">{{.Book.Title}}</a></h3>
+                        <p class="author">{{.Book.Author}}</p>
+                        <span class="status {{statusClass .ReadingEntry.Status}}">{{.ReadingEntry.Status}}</span>
+                        {{if .ReadingEntry.Rating.Valid}}
+                        <span class="rating">{{rating $.Config.RatingScale .ReadingEntry.Rating.Float64}}</span>
+                        {{end}}
+                    </div>
+                </article>
+                {{end}}
+            </div>
+            {{else if or .Status .Search}}
+            <p class="empty">No books match. <a href="/">Show all books</a></p>
+            {{else}}
+            <p class="empty">No books yet. <a href="/add">Add your first book</a></p>
+            {{end}}
+        </section>
+{{end}}`
+
+const bookTemplate = `{{define "title"}}{{.Book.Book.Title}}{{end}}
+{{define "content"}}
+        <article class="book-detail">
+            <div class="book-header">
+                {{if .Book.Book.CoverURL.Valid}}
+                <img src="{{.Book.Book.CoverURL.String}}" alt="{{.Book.Book.Title}}" class="book-cover-large">
+                {{else}}
+                <div class="book-cover-large placeholder">
+                    <span class="initials">{{initials .Book.Book.Title}}</span>
+                </div>
+                {{end}}
+                <div class="book-meta">
+                    <h2>{{.Book.Book.Title}}</h2>
+                    <p class="author">by {{.Book.Book.Author}}</p>
+
+                    <dl class="details">
+                        <dt>Status</dt>
+                        <dd>
+                            <form class="ui-inline" method="post" action="/books/{{.Book.Book.ID}}/status">
+                                <input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+                                <select name="status" aria-label="Status">
+                                    {{range .Statuses}}
+                                    <option value="{{.}}"{{if eq . $.Book.ReadingEntry.Status}} selected{{end}}>{{.}}</option>
+                                    {{end}}
+                                </select>
+                                <input type="date" name="date" max="{{.Today}}" aria-label="Start or finish date" title="Start or finish date (default: today)">
+                                <button type="submit">Save</button>
+                            </form>
+                        </dd>
+
+                        <dt>Rating</dt>
+                        <dd>
+                            <form class="star-rating" method="post" action="/books/{{.Book.Book.ID}}/rating">
+                                <input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+                                {{range .Stars}}
+                                <button type="submit" name="rating" value="{{.Value}}" title="{{.Label}}" aria-label="Rate {{.Label}}" class="{{if .Filled}}filled{{end}}{{with .Half}} half {{.}}{{end}}"><span>★</span></button>
+                                {{end}}
+                            </form>
+                        </dd>
+
+                        {{with .Book.Book.SeriesLabel}}
+                        <dt>Series</dt>
+                        <dd>{{.}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.Pages.Valid}}
+                        <dt>Pages</dt>
+                        <dd>{{.Book.Book.Pages.Int64}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.ISBN.Valid}}
+                        <dt>ISBN</dt>
+                        <dd>{{.Book.Book.ISBN.String}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.Format.Valid}}
+                        <dt>Format</dt>
+                        <dd class="format">{{.Book.Book.Format.String}}</dd>
+                        {{end}}
+
+                        {{if .Book.ReadingEntry.StartedAt.Valid}}
+                        <dt>Started</dt>
+                        <dd>{{formatDate .Book.ReadingEntry.StartedAt.Time}}</dd>
+                        {{end}}
+
+                        {{if .Book.ReadingEntry.FinishedAt.Valid}}
+                        <dt>Finished</dt>
+                        <dd>{{formatDate .Book.ReadingEntry.FinishedAt.Time}}</dd>
+                        {{end}}
+
+                        {{if .Book.Book.Genres.Valid}}
+                        <dt>Genres</dt>
+                        <dd class="genres-list">
+                            {{range parseGenres .Book.Book.Genres.String}}
+                            <span class="genre-tag">{{.}}</span>
+                            {{end}}
+                        </dd>
+                        {{end}}
+                    </dl>
+
+                    {{if .Book.Book.EditionKey.Valid}}
+                    <a href="{{openLibraryURL .Book.Book.EditionKey.String}}" class="external-link" target="_blank" rel="noopener">View on Open Library →</a>
+                    {{else if .Book.Book.OpenLibraryKey.Valid}}
+                    <a href="{{openLibraryURL .Book.Book.OpenLibraryKey.String}}" class="external-link" target="_blank" rel="noopener">View on Open Library →</a>
+                    {{end}}
+                </div>
+            </div>
+
+            {{if .Book.Book.Description.Valid}}
+            <section class="description">
+                <h3>Description</h3>
+                <p>{{.Book.Book.Description.String}}</p>
+            </section>
+            {{end}}
+
+            <section class="review">
+                <h3>My Review</h3>
+                <form method="post" action="/books/{{.Book.Book.ID}}/review">
+                    <input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+                    <textarea name="review" rows="8" aria-label="Review">{{.Book.ReadingEntry.Review.String}}</textarea>
+                    <button type="submit">Save review</button>
+                </form>
+            </section>
+
+            {{if .Quotes}}
+            <section class="quotes">
+                <h3>Quotes</h3>
+                {{range .Quotes}}
+                <figure class="quote">
+                    <blockquote>{{nl2br .Text}}</blockquote>
+                    {{if .Page.Valid}}<figcaption>Page {{.Page.Int64}}</figcaption>{{end}}
+                    {{if .Note.Valid}}<p class="quote-note">{{.Note.String}}</p>{{end}}
+                </figure>
+                {{end}}
+            </section>
+            {{end}}
+
+            <p class="ui-actions">
+                <a href="/" class="back-link">← Back to all books</a>
+                <a href="/books/{{.Book.Book.ID}}/remove" class="danger-link">Remove from shelf</a>
+            </p>
+        </article>
+{{end}}`
+
+const removeTemplate = `{{define "title"}}Remove {{.Book.Book.Title}}{{end}}
+{{define "content"}}
+        <section class="ui-panel">
+            <h2>Remove "{{.Book.Book.Title}}" by {{.Book.Book.Author}}?</h2>
+            <p>Its reading history, review and quotes are removed too.</p>
+            <form class="ui-inline" method="post" action="/books/{{.Book.Book.ID}}/remove">
+                <input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+                <button type="submit" class="danger">Remove</button>
+                <a href="/books/{{.Book.Book.ID}}">Cancel</a>
+            </form>
+        </section>
+{{end}}`
+
+const addTemplate = `{{define "title"}}Add a book{{end}}
+{{define "content"}}
+        <section class="ui-panel">
+            <h2>Add a book</h2>
+            {{if .Available}}
+            <form class="ui-inline" method="get" action="/add">
+                <input type="search" name="q" value="{{.Query}}" placeholder="Title, author or ISBN" aria-label="Search for a book" autofocus>
+                <button type="submit">Search</button>
+            </form>
+            {{else}}
+            <p>Searching isn't available. Add books with <code>bookshelf add</code>.</p>
+            {{end}}
+        </section>
+
+        {{if .Results}}
+        <section class="ui-results">
+            {{range .Results}}
+            <article class="ui-result">
+                {{if .Cover}}<img src="{{.Cover}}" alt="" loading="lazy">{{else}}<div class="ui-result-cover placeholder"><span class="initials">{{initials .Title}}</span></div>{{end}}
+                <div>
+                    <h3>{{.Title}}</h3>
+                    <p class="author">{{.Author}}{{if .Year}} ({{.Year}}){{end}}</p>
+                </div>
+                <form method="post" action="/add">
+                    <input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+                    <input type="hidden" name="key" value="{{.Key}}">
+                    <input type="hidden" name="title" value="{{.Title}}">
+                    <input type="hidden" name="author" value="{{.Author}}">
+                    <input type="hidden" name="isbn" value="{{.ISBN}}">
+                    <input type="hidden" name="cover" value="{{.Cover}}">
+                    <input type="hidden" name="pages" value="{{.Pages}}">
+                    <button type="submit">Add to want-to-read</button>
+                </form>
+            </article>
+            {{end}}
+        </section>
+        {{else if .Searched}}
+        <p class="empty">No books found for "{{.Query}}".</p>
+        {{end}}
+{{end}}`
+
+const goalsTemplate = `{{define "title"}}Goals{{end}}
+{{define "content"}}
+        <section class="ui-panel">
+            <h2>Set a reading goal</h2>
+            <form class="ui-inline" method="post" action="/goals">
+                <input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+                <label>Year <input type="number" name="year" value="{{.CurrentYear}}" min="1900" max="2100" required></label>
+                <label>Books <input type="number" name="target" min="1" required></label>
+                <button type="submit">Save goal</button>
+            </form>
+        </section>
+
+        {{range .Goals}}
+        <section class="reading-goal">
+            <h2>{{.Year}} Reading Goal{{if eq .Year $.CurrentYear}} (current){{end}}</h2>
+            <div class="goal-progress">
+                <div class="progress-bar">
+                    <div class="progress-fill" style="width: {{.Percent}}%"></div>
+                </div>
+                <div class="goal-stats">
+                    <span class="goal-current">{{.Finished}} of {{.Target}} books{{if ge .Finished .Target}}, goal complete!{{end}}</span>
+                    <form method="post" action="/goals/{{.Year}}/clear">
+                        <input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+                        <button type="submit" class="link-button">Clear</button>
+                    </form>
+                </div>
+            </div>
+        </section>
+        {{else}}
+        <p class="empty">No reading goals set yet.</p>
+        {{end}}
+{{end}}`
+
+const settingsTemplate = `{{define "title"}}Settings{{end}}
+{{define "content"}}
+        <section class="ui-panel">
+            <h2>Settings</h2>
+            <p>Leave a value blank to use the default.</p>
+            <div class="ui-settings">
+                {{range .Settings}}
+                <form method="post" action="/settings">
+                    <input type="hidden" name="csrf_token" value="{{$.CSRFToken}}">
+                    <input type="hidden" name="key" value="{{.Key}}">
+                    <label for="setting-{{.Key}}"><code>{{.Key}}</code><small>{{.Description}}</small></label>
+                    <input id="setting-{{.Key}}" type="text" name="value" value="{{.Value}}">
+                    <button type="submit">Save</button>
+                    {{if .Value}}<button type="submit" name="unset" value="1" class="link-button">Unset</button>{{end}}
+                </form>
+                {{end}}
+            </div>
+        </section>
+{{end}}`
+
+// uiStylesheet is added to the published site's stylesheet for the forms.
+const uiStylesheet = `
+/* bookshelf ui */
+.ui-nav {
+    display: flex;
+    justify-content: center;
+    gap: 1.5rem;
+    margin-top: 1rem;
+}
+
+.ui-nav a {
+    color: var(--text-header);
+    opacity: 0.8;
+    text-decoration: none;
+}
+
+.ui-nav a.active, .ui-nav a:hover {
+    opacity: 1;
+    text-decoration: underline;
+}
+
+.flash {
+    background: #e8f8f0;
+    color: #27ae60;
+    padding: 0.75rem 1rem;
+    border-radius: 8px;
+    margin-bottom: 1.5rem;
+}
+
+.flash.error {
+    background: #fdecea;
+    color: #c0392b;
+}
+
+a.filter-btn {
+    text-decoration: none;
+}
+
+.reading-goal h2 a {
+    color: inherit;
+    text-decoration: none;
+}
+
+input[type="search"], input[type="text"], input[type="number"], input[type="date"], select, textarea {
+    padding: 0.5rem 0.75rem;
+    border: 1px solid var(--border);
+    border-radius: 4px;
+    background: var(--bg-card);
+    color: var(--text-primary);
+    font: inherit;
+}
+
+textarea {
+    width: 100%;
+    margin-bottom: 0.5rem;
+}
+
+button {
+    padding: 0.5rem 1rem;
+    border: 1px solid var(--accent);
+    border-radius: 4px;
+    background: var(--accent);
+    color: white;
+    font: inherit;
+    cursor: pointer;
+}
+
+button:hover {
+    background: var(--accent-hover);
+}
+
+button.danger {
+    background: #c0392b;
+    border-color: #c0392b;
+}
+
+button.link-button {
+    background: none;
+    border: none;
+    padding: 0;
+    color: var(--accent);
+}
+
+.ui-inline {
+    display: flex;
+    flex-wrap: wrap;
+    align-items: center;
+    gap: 0.5rem;
+}
+
+.ui-panel {
+    background: var(--bg-card);
+    padding: 1.5rem;
+    border-radius: 8px;
+    box-shadow: 0 2px 4px var(--shadow);
+    margin-bottom: 2rem;
+}
+
+.ui-panel h2 {
+    font-size: 1.1rem;
+    margin-bottom: 1rem;
+}
+
+.ui-panel p {
+    margin-bottom: 1rem;
+    color: var(--text-secondary);
+}
+
+.ui-actions {
+    display: flex;
+    justify-content: space-between;
+    align-items: baseline;
+}
+
+.danger-link {
+    color: #c0392b;
+}
+
+.star-rating {
+    display: inline-flex;
+}
+
+.star-rating button {
+    background: none;
+    border: none;
+    padding: 0 0.1rem;
+    font-size: 1.5rem;
+    line-height: 1;
+    color: var(--border);
+}
+
+.star-rating button.filled {
+    color: #f39c12;
+}
+
+.star-rating:hover button {
+    color: #f39c12;
+}
+
+.star-rating button:hover ~ button {
+    color: var(--border);
+}
+
+.star-rating button.half {
+    width: 0.5em;
+    overflow: hidden;
+    padding: 0;
+}
+
+.star-rating button.half span {
+    display: inline-block;
+}
+
+.star-rating button.half.right span {
+    margin-left: -0.5em;
+}
+
+.ui-results {
+    display: flex;
+    flex-direction: column;
+    gap: 1rem;
+}
+
+.ui-result {
+    display: grid;
+    grid-template-columns: 60px 1fr auto;
+    gap: 1rem;
+    align-items: center;
+    background: var(--bg-card);
+    padding: 1rem;
+    border-radius: 8px;
+    box-shadow: 0 2px 4px var(--shadow);
+}
+
+.ui-result img, .ui-result-cover {
+    width: 60px;
+    height: 90px;
+    object-fit: cover;
+    border-radius: 4px;
+}
+
+.ui-result-cover.placeholder {
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+    color: white;
+    font-weight: bold;
+}
+
+.ui-settings form {
+    display: grid;
+    grid-template-columns: 1fr 1fr auto auto;
+    gap: 0.5rem 1rem;
+    align-items: center;
+    padding: 0.75rem 0;
+    border-top: 1px solid var(--border);
+}
+
+.ui-settings label small {
+    display: block;
+    color: var(--text-secondary);
+}
+
+@media (max-width: 600px) {
+    .ui-settings form, .ui-result {
+        grid-template-columns: 1fr;
+    }
+}
+`
@@ -0,0 +1,211 @@
+// Package webui is the web app served by 'bookshelf ui': the published
+// site's look, with forms to add books, move them between shelves, rate and
+// review them, and manage goals and config.
+//
+// It's meant to run on your own computer. Every form carries a CSRF token,
+// so other web pages can't post to it, and with WithLoopbackOnly requests
+// must be addressed to localhost, so pages can't reach it through DNS
+// rebinding either.
+package webui
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bookshelf/internal/publish"
+	"bookshelf/internal/server"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithProvider searches provider when adding books. Without one, the add
+// page says searching isn't available.
+func WithProvider(provider api.MetadataProvider) Option {
+	return func(s *Server) { s.provider = provider }
+}
+
+// WithConfigKeys lists the config keys the settings page shows, with their
+// descriptions.
+func WithConfigKeys(keys map[string]string) Option {
+	return func(s *Server) { s.configKeys = keys }
+}
+
+// WithConfigValidator checks config values before they're set.
+func WithConfigValidator(validate func(key, value string) error) Option {
+	return func(s *Server) { s.validateConfig = validate }
+}
+
+// WithLoopbackOnly rejects requests whose Host isn't localhost or a loopback
+// address. Use it when listening on localhost.
+func WithLoopbackOnly() Option {
+	return func(s *Server) { s.loopbackOnly = true }
+}
+
+// Server handles the web app's requests. Create one with New.
+type Server struct {
+	provider       api.MetadataProvider
+	configKeys     map[string]string
+	validateConfig func(key, value string) error
+	loopbackOnly   bool
+
+	csrfToken string
+	pages     map[string]*template.Template
+	mux       *http.ServeMux
+
+	mu    sync.Mutex
+	flash flash
+}
+
+// flash is a message shown once, on the page a form redirects to.
+type flash struct {
+	Message string
+	Error   bool
+}
+
+// page holds what every page's layout needs.
+type page struct {
+	Config    models.SiteConfig
+	CSRFToken string
+	Flash     flash
+	Nav       string // The highlighted navigation link
+}
+
+// New creates a Server with its routes and templates.
+func New(opts ...Option) (*Server, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate CSRF token: %w", err)
+	}
+
+	s := &Server{csrfToken: hex.EncodeToString(token), mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	layout, err := template.New("layout").Funcs(publish.TemplateFuncs()).Parse(layoutTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse layout template: %w", err)
+	}
+	s.pages = make(map[string]*template.Template)
+	for name, content := range pageTemplates {
+		tmpl, err := template.Must(layout.Clone()).Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
+		}
+		s.pages[name] = tmpl
+	}
+
+	s.mux.HandleFunc("GET /style.css", s.handleStylesheet)
+	s.mux.HandleFunc("GET /covers/{id}", s.handleCover)
+
+	s.mux.HandleFunc("GET /{$}", s.handleIndex)
+	s.mux.HandleFunc("GET /books/{id}", s.handleBook)
+	s.mux.HandleFunc("POST /books/{id}/status", s.handleSetStatus)
+	s.mux.HandleFunc("POST /books/{id}/rating", s.handleSetRating)
+	s.mux.HandleFunc("POST /books/{id}/review", s.handleSetReview)
+	s.mux.HandleFunc("GET /books/{id}/remove", s.handleConfirmRemove)
+	s.mux.HandleFunc("POST /books/{id}/remove", s.handleRemove)
+	s.mux.HandleFunc("GET /add", s.handleSearch)
+	s.mux.HandleFunc("POST /add", s.handleAdd)
+
+	s.mux.HandleFunc("GET /goals", s.handleGoals)
+	s.mux.HandleFunc("POST /goals", s.handleSetGoal)
+	s.mux.HandleFunc("POST /goals/{year}/clear", s.handleClearGoal)
+	s.mux.HandleFunc("GET /settings", s.handleSettings)
+	s.mux.HandleFunc("POST /settings", s.handleSetConfig)
+	return s, nil
+}
+
+// ServeHTTP checks the Host and, for forms, the CSRF token, then routes the
+// request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; img-src * data:; style-src 'self' 'unsafe-inline'; frame-ancestors 'none'")
+	w.Header().Set("Referrer-Policy", "same-origin")
+
+	if s.loopbackOnly && !server.IsLoopbackHost(r.Host) {
+		http.Error(w, "bookshelf ui only answers requests to localhost", http.StatusMisdirectedRequest)
+		return
+	}
+	if r.Method == http.MethodPost && !s.validCSRF(r) {
+		http.Error(w, "invalid or missing CSRF token; reload the page and try again", http.StatusForbidden)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// validCSRF reports whether a form came from one of our pages: it carries
+// the token, and its Origin, if the browser sent one, is this server.
+func (s *Server) validCSRF(r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host != r.Host {
+			return false
+		}
+	}
+	token := r.PostFormValue("csrf_token")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.csrfToken)) == 1
+}
+
+// setFlash shows message on the next page rendered.
+func (s *Server) setFlash(message string, isError bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flash = flash{Message: message, Error: isError}
+}
+
+// newPage builds the layout's data, taking the pending flash message.
+func (s *Server) newPage(nav string) (page, error) {
+	config, err := db.GetSiteConfig()
+	if err != nil {
+		return page{}, fmt.Errorf("failed to fetch site config: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := page{Config: config, CSRFToken: s.csrfToken, Flash: s.flash, Nav: nav}
+	s.flash = flash{}
+	return p, nil
+}
+
+// render writes a page, or a 500 if it fails.
+func (s *Server) render(w http.ResponseWriter, status int, name string, data any) {
+	var b strings.Builder
+	if err := s.pages[name].Execute(&b, data); err != nil {
+		s.serverError(w, fmt.Errorf("failed to render %s: %w", name, err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprint(w, b.String())
+}
+
+// serverError logs err and shows it.
+func (s *Server) serverError(w http.ResponseWriter, err error) {
+	log.Print(err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// redirect sends the browser to path after a form, with message shown there.
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request, path, message string, isError bool) {
+	if message != "" {
+		s.setFlash(message, isError)
+	}
+	http.Redirect(w, r, path, http.StatusSeeOther)
+}
+
+func (s *Server) handleStylesheet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	fmt.Fprint(w, publish.Stylesheet, uiStylesheet)
+}
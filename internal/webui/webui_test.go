@@ -0,0 +1,361 @@
+package webui
+
+import (
+	"bookshelf/internal/api"
+	"bookshelf/internal/db"
+	"bookshelf/internal/models"
+	"bookshelf/internal/testutil"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestServer creates a Server, failing the test if it can't.
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	s, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return s
+}
+
+// get fetches path and returns the response.
+func get(t *testing.T, s *Server, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", path, nil)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+// post submits a form to path with the server's CSRF token, expecting a
+// redirect, and returns the flash message shown on the page it redirects to.
+func post(t *testing.T, s *Server, path string, form url.Values) string {
+	t.Helper()
+	if form == nil {
+		form = url.Values{}
+	}
+	form.Set("csrf_token", s.csrfToken)
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("POST %s: got %d: %s", path, rec.Code, rec.Body)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message := s.flash.Message
+	s.flash = flash{}
+	return message
+}
+
+func seed(t *testing.T, title, author string) int64 {
+	t.Helper()
+	id, err := db.InsertBook(&models.Book{Title: title, Author: author})
+	if err != nil {
+		t.Fatalf("InsertBook() error: %v", err)
+	}
+	if err := db.CreateReadingEntry(id, models.StatusWantToRead); err != nil {
+		t.Fatalf("CreateReadingEntry() error: %v", err)
+	}
+	return id
+}
+
+func TestPages(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	s := newTestServer(t, WithConfigKeys(map[string]string{"site.title": "Title for the published site"}))
+
+	id := seed(t, "Dune", "Frank Herbert")
+	seed(t, "Emma", "Jane Austen")
+	db.SetGoal(2024, 12)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/", []string{"Dune", "Emma", `href="/books/` + fmt.Sprint(id) + `"`}},
+		{"/?search=austen", []string{"Emma"}},
+		{fmt.Sprintf("/books/%d", id), []string{"Frank Herbert", `name="csrf_token" value="` + s.csrfToken + `"`, `name="rating" value="5"`}},
+		{fmt.Sprintf("/books/%d/remove", id), []string{`Remove "Dune" by Frank Herbert?`}},
+		{"/add", []string{"Searching isn't available"}},
+		{"/goals", []string{"2024 Reading Goal", "0 of 12 books"}},
+		{"/settings", []string{"site.title", "Title for the published site"}},
+		{"/style.css", []string{".book-grid", ".star-rating"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := get(t, s, tt.path)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got %d: %s", rec.Code, rec.Body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body doesn't contain %q", want)
+				}
+			}
+		})
+	}
+
+	if body := get(t, s, "/?search=austen").Body.String(); strings.Contains(body, "Dune") {
+		t.Error("search should filter out Dune")
+	}
+	if rec := get(t, s, "/books/999"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing book: got %d", rec.Code)
+	}
+}
+
+func TestCSRF(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	s := newTestServer(t)
+	id := seed(t, "Dune", "Frank Herbert")
+
+	tests := []struct {
+		name   string
+		token  string
+		origin string
+	}{
+		{"missing token", "", ""},
+		{"wrong token", "not-the-token", ""},
+		{"cross-origin", s.csrfToken, "http://evil.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"rating": {"5"}, "csrf_token": {tt.token}}
+			req := httptest.NewRequest("POST", fmt.Sprintf("/books/%d/rating", id), strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("got %d, want 403", rec.Code)
+			}
+		})
+	}
+
+	book, _ := db.GetBook(id)
+	if book.ReadingEntry.Rating.Valid {
+		t.Error("rating should not have been set")
+	}
+}
+
+func TestLoopbackOnly(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	s := newTestServer(t, WithLoopbackOnly())
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"localhost:8090", http.StatusOK},
+		{"127.0.0.1:8090", http.StatusOK},
+		{"[::1]:8090", http.StatusOK},
+		{"evil.example:8090", http.StatusMisdirectedRequest},
+		{"192.168.1.10:8090", http.StatusMisdirectedRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Host %s: got %d, want %d", tt.host, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestBookForms(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	s := newTestServer(t)
+	id := seed(t, "Dune", "Frank Herbert")
+	path := fmt.Sprintf("/books/%d", id)
+
+	if msg := post(t, s, path+"/status", url.Values{"status": {"reading"}, "date": {"2024-01-05"}}); msg != `Moved "Dune" to reading` {
+		t.Errorf("start flash = %q", msg)
+	}
+	if msg := post(t, s, path+"/status", url.Values{"status": {"finished"}, "date": {"2024-01-01"}}); !strings.Contains(msg, "is after finish date") {
+		t.Errorf("finishing before starting should fail, got %q", msg)
+	}
+	if msg := post(t, s, path+"/status", url.Values{"status": {"finished"}, "date": {"2024-02-01"}}); msg != `Moved "Dune" to finished` {
+		t.Errorf("finish flash = %q", msg)
+	}
+	if msg := post(t, s, path+"/rating", url.Values{"rating": {"4"}}); msg != `Rated "Dune" 4/5` {
+		t.Errorf("rating flash = %q", msg)
+	}
+	if msg := post(t, s, path+"/rating", url.Values{"rating": {"7"}}); msg == "" || strings.HasPrefix(msg, "Rated") {
+		t.Errorf("out-of-range rating should fail, got %q", msg)
+	}
+	if msg := post(t, s, path+"/review", url.Values{"review": {"  A classic.\n"}}); msg != "Saved your review" {
+		t.Errorf("review flash = %q", msg)
+	}
+
+	book, err := db.GetBook(id)
+	if err != nil {
+		t.Fatalf("GetBook() error: %v", err)
+	}
+	entry := book.ReadingEntry
+	if entry.Status != models.StatusFinished || entry.FinishedAt.Time.Format("2006-01-02") != "2024-02-01" {
+		t.Errorf("status = %s, finished %v", entry.Status, entry.FinishedAt)
+	}
+	if entry.Rating.Float64 != 4 {
+		t.Errorf("rating = %v, want 4", entry.Rating.Float64)
+	}
+	if entry.Review.String != "A classic." {
+		t.Errorf("review = %q", entry.Review.String)
+	}
+
+	if msg := post(t, s, path+"/review", url.Values{"review": {""}}); msg != "Cleared your review" {
+		t.Errorf("clear review flash = %q", msg)
+	}
+	if msg := post(t, s, path+"/remove", nil); msg != `Removed "Dune"` {
+		t.Errorf("remove flash = %q", msg)
+	}
+	if exists, _ := db.BookExists(id); exists {
+		t.Error("book should have been removed")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	ol := testutil.NewOpenLibraryServer(t)
+	s := newTestServer(t, WithProvider(api.NewClient(api.WithBaseURL(ol.URL))))
+
+	body := get(t, s, "/add?q=dune").Body.String()
+	if !strings.Contains(body, "Dune") || !strings.Contains(body, `name="key"`) {
+		t.Fatalf("search results missing Dune:\n%s", body)
+	}
+	if body := get(t, s, "/add?q=nothing+matches+this").Body.String(); !strings.Contains(body, "No books found") {
+		t.Error("empty search should say no books found")
+	}
+
+	form := url.Values{"key": {"/works/OL893415W"}, "title": {"Dune"}, "author": {"Frank Herbert"}, "pages": {"412"}}
+	if msg := post(t, s, "/add", form); msg != `Added "Dune" by Frank Herbert` {
+		t.Errorf("add flash = %q", msg)
+	}
+	if msg := post(t, s, "/add", url.Values{"title": {" "}}); msg != "title is required" {
+		t.Errorf("blank title flash = %q", msg)
+	}
+	if msg := post(t, s, "/add", url.Values{"title": {"Secrets"}, "cover": {"file:///etc/passwd"}}); msg != "cover must be an http or https URL" {
+		t.Errorf("file cover flash = %q", msg)
+	}
+
+	books, err := db.ListBooks(models.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListBooks() error: %v", err)
+	}
+	if len(books) != 1 {
+		t.Fatalf("got %d books, want 1", len(books))
+	}
+	book := books[0]
+	if book.ReadingEntry.Status != models.StatusWantToRead || book.Book.Pages.Int64 != 412 || !book.Book.OpenLibraryKey.Valid {
+		t.Errorf("added book = %+v", book)
+	}
+}
+
+func TestCovers(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	s := newTestServer(t)
+
+	library := t.TempDir()
+	cover := filepath.Join(library, "Frank Herbert", "Dune (1)", "cover.jpg")
+	if err := os.MkdirAll(filepath.Dir(cover), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cover, []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetConfig("calibre.library", library); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cover string
+		want  int
+	}{
+		{"file://" + filepath.ToSlash(cover), http.StatusOK},
+		{"file:///etc/passwd", http.StatusNotFound},
+		{"file://" + filepath.ToSlash(filepath.Join(library, "..", "cover.jpg")), http.StatusNotFound},
+		{"https://covers.openlibrary.org/b/id/1-L.jpg", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		id := seed(t, "Dune", "Frank Herbert")
+		if _, err := db.DB.Exec(`UPDATE books SET cover_url = ? WHERE id = ?`, tt.cover, id); err != nil {
+			t.Fatal(err)
+		}
+		if rec := get(t, s, fmt.Sprintf("/covers/%d", id)); rec.Code != tt.want {
+			t.Errorf("cover %s: got %d, want %d", tt.cover, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestGoalsAndSettings(t *testing.T) {
+	cleanup := testutil.SetupTestDB(t)
+	defer cleanup()
+	s := newTestServer(t,
+		WithConfigKeys(map[string]string{"site.title": "", "rating.scale": ""}),
+		WithConfigValidator(func(key, value string) error {
+			if key == "rating.scale" && value != "5" {
+				return errors.New("invalid rating scale")
+			}
+			return nil
+		}),
+	)
+
+	if msg := post(t, s, "/goals", url.Values{"year": {"2024"}, "target": {"0"}}); !strings.HasPrefix(msg, "invalid target") {
+		t.Errorf("zero target flash = %q", msg)
+	}
+	if msg := post(t, s, "/goals", url.Values{"year": {"1800"}, "target": {"5"}}); !strings.HasPrefix(msg, "invalid year") {
+		t.Errorf("bad year flash = %q", msg)
+	}
+	if msg := post(t, s, "/goals", url.Values{"year": {"2024"}, "target": {"20"}}); msg != "Set reading goal for 2024: 20 books" {
+		t.Errorf("set goal flash = %q", msg)
+	}
+	if goal, _ := db.GetGoal(2024); goal == nil || goal.Target != 20 {
+		t.Errorf("goal = %+v", goal)
+	}
+	if msg := post(t, s, "/goals/2024/clear", nil); msg != "Cleared reading goal for 2024" {
+		t.Errorf("clear goal flash = %q", msg)
+	}
+	if msg := post(t, s, "/goals/2024/clear", nil); msg != "no goal found for year 2024" {
+		t.Errorf("clear missing goal flash = %q", msg)
+	}
+
+	if msg := post(t, s, "/settings", url.Values{"key": {"site.title"}, "value": {"My Books"}}); msg != "Set site.title = My Books" {
+		t.Errorf("set config flash = %q", msg)
+	}
+	if msg := post(t, s, "/settings", url.Values{"key": {"rating.scale"}, "value": {"7"}}); msg != "invalid rating scale" {
+		t.Errorf("invalid config flash = %q", msg)
+	}
+	if msg := post(t, s, "/settings", url.Values{"key": {"api.token"}, "value": {"secret"}}); msg != "unknown config key: api.token" {
+		t.Errorf("unknown key flash = %q", msg)
+	}
+	if value, _ := db.GetConfig("site.title"); value != "My Books" {
+		t.Errorf("site.title = %q", value)
+	}
+	if body := get(t, s, "/").Body.String(); !strings.Contains(body, "<title>Shelf - My Books</title>") {
+		t.Error("pages should use the configured site title")
+	}
+
+	if msg := post(t, s, "/settings", url.Values{"key": {"site.title"}, "value": {"My Books"}, "unset": {"1"}}); msg != "Unset site.title (reverted to default)" {
+		t.Errorf("unset flash = %q", msg)
+	}
+	if value, _ := db.GetConfig("site.title"); value != "" {
+		t.Errorf("site.title after unset = %q", value)
+	}
+}